@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"text/template"
 
 	"github.com/HarounAhmad/vpn-certd/internal/bundle"
 )
@@ -19,22 +20,24 @@ func mustRead(path string) string {
 }
 
 func main() {
-	var cn, caPath, taPath, certPath, keyPath, outZip, remote, proto string
+	var cn, caPath, taPath, tcv2Path, certPath, keyPath, tmplPath, outZip, remote, proto string
 	var port int
 
 	flag.StringVar(&cn, "cn", "", "Common Name")
 	flag.StringVar(&caPath, "ca", "", "Path to ca.crt (PEM)")
 	flag.StringVar(&taPath, "ta", "", "Path to ta.key (tls-crypt)")
+	flag.StringVar(&tcv2Path, "tls-crypt-v2", "", "Path to the client's tls-crypt-v2 key (replaces -ta)")
 	flag.StringVar(&certPath, "cert", "", "Path to client cert PEM")
 	flag.StringVar(&keyPath, "key", "", "Path to client key PEM (optional)")
 	flag.StringVar(&remote, "remote", "vpn.example.com", "OpenVPN remote host")
 	flag.IntVar(&port, "port", 1194, "OpenVPN remote port")
 	flag.StringVar(&proto, "proto", "udp", "OpenVPN proto (udp|tcp)")
+	flag.StringVar(&tmplPath, "template", "", "Profile template file (default: built-in)")
 	flag.StringVar(&outZip, "out", "", "Output zip path (default: ./dist/<cn>.zip)")
 	flag.Parse()
 
-	if cn == "" || caPath == "" || taPath == "" || certPath == "" {
-		fmt.Fprintln(os.Stderr, "missing required flags: -cn -ca -ta -cert")
+	if cn == "" || caPath == "" || (taPath == "" && tcv2Path == "") || certPath == "" {
+		fmt.Fprintln(os.Stderr, "missing required flags: -cn -ca -ta|-tls-crypt-v2 -cert")
 		os.Exit(2)
 	}
 
@@ -47,16 +50,34 @@ func main() {
 	if keyPath != "" {
 		key = mustRead(keyPath)
 	}
+	ta, tcv2 := "", ""
+	if tcv2Path != "" {
+		tcv2 = mustRead(tcv2Path)
+	} else {
+		ta = mustRead(taPath)
+	}
+
+	var tmpl *template.Template
+	if tmplPath != "" {
+		var err error
+		if tmpl, err = bundle.ParseTemplate(filepath.Base(tmplPath), mustRead(tmplPath)); err != nil {
+			fmt.Fprintln(os.Stderr, "template:", err)
+			os.Exit(2)
+		}
+	}
 
 	in := bundle.Inputs{
 		CN:         cn,
 		CAPEM:      mustRead(caPath),
-		TaKey:      mustRead(taPath),
+		TaKey:      ta,
 		CertPEM:    mustRead(certPath),
 		KeyPEMOpt:  key,
 		RemoteHost: remote,
 		RemotePort: port,
 		Proto:      proto,
+
+		TLSCryptV2Key: tcv2,
+		Template:      tmpl,
 	}
 	out, err := bundle.Build(in)
 	if err != nil {
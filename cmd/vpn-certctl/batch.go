@@ -0,0 +1,481 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+	"github.com/HarounAhmad/vpn-certd/pkg/client"
+)
+
+// batchStateFile records a batch run in its output directory for --resume.
+const batchStateFile = "batch-state.json"
+
+// batchGrace is added to --timeout for each BATCH call: the daemon stops
+// starting rows at its own deadline and still answers with what it finished.
+const batchGrace = 10 * time.Second
+
+// manifestRow is one manifest entry. Empty fields take the command's flags;
+// a row gets a bundle when it has a remote, unless bundle is false.
+type manifestRow struct {
+	CN         string `yaml:"cn"`
+	Profile    string `yaml:"profile"`
+	KeyType    string `yaml:"key_type"`
+	Pass       string `yaml:"pass"`
+	Bundle     *bool  `yaml:"bundle"`
+	Remote     string `yaml:"remote"`
+	Port       int    `yaml:"port"`
+	Proto      string `yaml:"proto"`
+	IncludeKey *bool  `yaml:"include_key"`
+	Template   string `yaml:"template"`
+}
+
+// batchState is the progress of a run, keyed by CN. Rows are retried under
+// the same request_id, so a row issued by a run that died before recording it
+// is replayed rather than issued twice.
+type batchState struct {
+	RequestID string               `json:"request_id"`
+	Rows      map[string]*batchRow `json:"rows"`
+}
+
+// batchRow is the outcome of one row, as saved and as reported.
+type batchRow struct {
+	CN       string   `json:"cn"`
+	Status   string   `json:"status"`
+	Serial   string   `json:"serial,omitempty"`
+	NotAfter string   `json:"not_after,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Warning  string   `json:"warning,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Row statuses. Only ok rows are skipped on --resume.
+const (
+	rowOK      = "ok"
+	rowFailed  = "failed"
+	rowPending = "pending"
+	rowSkipped = "skipped"
+	rowDryRun  = "dry-run"
+)
+
+func runBatch(c *cli, args []string) error {
+	var manifest, format, outDir, passSrc string
+	var def manifestRow
+	var includeKey, resume bool
+	var chunk int
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&manifest, "manifest", "", "CSV or YAML manifest, - for stdin")
+	fs.StringVar(&format, "format", "", "manifest format: csv|yaml (default: from the file extension)")
+	fs.StringVar(&outDir, "out", "", "directory for certificates, keys, bundles and the run state")
+	fs.StringVar(&def.Profile, "profile", "client", "default profile: client|server")
+	fs.StringVar(&def.KeyType, "key-type", "rsa4096", "default key type: rsa4096|ed25519")
+	fs.StringVar(&passSrc, "pass", "", "default key passphrase: "+passHelp)
+	fs.StringVar(&def.Remote, "remote", "", "default VPN server host; rows with a remote get a bundle")
+	fs.IntVar(&def.Port, "port", 1194, "default VPN server port")
+	fs.StringVar(&def.Proto, "proto", "udp", "default protocol: udp|tcp")
+	fs.BoolVar(&includeKey, "include-key", true, "put the encrypted key into bundles")
+	fs.StringVar(&def.Template, "template", "", "default bundle profile template (default: the daemon's choice)")
+	fs.IntVar(&chunk, "chunk", 5, "rows per BATCH request")
+	fs.BoolVar(&resume, "resume", false, "continue the run recorded in --out, retrying rows that did not succeed")
+	fs.StringVar(&rf.requestID, "request-id", "", "batch request_id (default: generated, or the resumed run's)")
+	fs.BoolVar(&rf.dryRun, "dry-run", false, "validate every row without issuing anything")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if manifest == "" || (outDir == "" && !rf.dryRun) {
+		return usageErr("--manifest and --out required")
+	}
+	if chunk < 1 {
+		return usageErr("--chunk must be at least 1")
+	}
+	def.IncludeKey = &includeKey
+	rows, err := readManifest(manifest, format)
+	if err != nil {
+		return err
+	}
+
+	st := &batchState{RequestID: rf.requestID, Rows: map[string]*batchRow{}}
+	statePath := filepath.Join(outDir, batchStateFile)
+	if !rf.dryRun {
+		if st, err = openBatch(statePath, outDir, rows, resume, rf.requestID); err != nil {
+			return err
+		}
+	}
+
+	// read each passphrase source once; the prompt only when a row needs it
+	var defPass string
+	sources := map[string]string{}
+	items := make([]client.BatchItem, 0, len(rows))
+	report := make([]*batchRow, 0, len(rows))
+	for _, r := range rows {
+		r = r.withDefaults(def)
+		if done := st.Rows[r.CN]; done != nil && done.Status == rowOK {
+			report = append(report, &batchRow{CN: r.CN, Status: rowSkipped, Serial: done.Serial,
+				NotAfter: done.NotAfter, Files: done.Files})
+			continue
+		}
+		it := client.BatchItem{CN: r.CN, Profile: client.Profile(r.Profile), KeyType: client.KeyType(r.KeyType)}
+		if r.Pass != "" {
+			if _, ok := sources[r.Pass]; !ok {
+				b, err := passphrase(r.Pass, "", false)
+				if err != nil {
+					return fmt.Errorf("row %s: %w", r.CN, err)
+				}
+				sources[r.Pass] = string(b)
+			}
+			it.Passphrase = sources[r.Pass]
+		} else {
+			if defPass == "" {
+				b, err := passphrase(passSrc, "Key passphrase for the batch", true)
+				if err != nil {
+					return err
+				}
+				defPass = string(b)
+			}
+			it.Passphrase = defPass
+		}
+		if *r.Bundle {
+			it.Bundle = &client.BundleReq{RemoteHost: r.Remote, RemotePort: r.Port, Proto: r.Proto, IncludeKey: *r.IncludeKey, Template: r.Template}
+		}
+		items = append(items, it)
+		report = append(report, &batchRow{CN: r.CN, Status: rowPending})
+	}
+	// report[i] for the pending rows, in item order
+	byItem := make([]*batchRow, 0, len(items))
+	for _, row := range report {
+		if row.Status == rowPending {
+			byItem = append(byItem, row)
+		}
+	}
+
+	cl := c.client()
+	cl.Timeout = c.timeout + batchGrace
+	opts := rf.opts()
+	if !rf.dryRun {
+		opts = append(opts, client.WithRequestID(st.RequestID))
+	}
+	var callErr error
+	for start := 0; start < len(items) && callErr == nil; start += chunk {
+		end := min(start+chunk, len(items))
+		results, err := cl.Batch(c.ctx, items[start:end], opts...)
+		if err != nil {
+			callErr = err
+			break
+		}
+		for i, res := range results {
+			row := byItem[start+i]
+			if rf.dryRun {
+				row.Status = rowDryRun
+				if err := client.BatchErr(res); err != nil {
+					row.Status, row.Error = rowFailed, err.Error()
+				}
+				continue
+			}
+			saveBatchResult(outDir, row, res, items[start+i].Bundle)
+			st.Rows[row.CN] = row
+		}
+		if !rf.dryRun {
+			if err := saveBatchState(statePath, st); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := showBatch(c, report); err != nil {
+		return err
+	}
+	failed := 0
+	for _, row := range report {
+		if row.Status == rowFailed || row.Status == rowPending {
+			failed++
+		}
+	}
+	if callErr != nil {
+		return fmt.Errorf("%d of %d rows not done: %w", failed, len(report), callErr)
+	}
+	if failed > 0 && rf.dryRun {
+		return fmt.Errorf("%d of %d rows failed validation", failed, len(report))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed; fix them and rerun with --resume", failed, len(report))
+	}
+	return nil
+}
+
+func (r manifestRow) withDefaults(def manifestRow) manifestRow {
+	if r.Profile == "" {
+		r.Profile = def.Profile
+	}
+	if r.KeyType == "" {
+		r.KeyType = def.KeyType
+	}
+	if r.Remote == "" {
+		r.Remote = def.Remote
+	}
+	if r.Port == 0 {
+		r.Port = def.Port
+	}
+	if r.Proto == "" {
+		r.Proto = def.Proto
+	}
+	if r.IncludeKey == nil {
+		r.IncludeKey = def.IncludeKey
+	}
+	if r.Template == "" {
+		r.Template = def.Template
+	}
+	if r.Bundle == nil {
+		b := r.Remote != ""
+		r.Bundle = &b
+	}
+	return r
+}
+
+// openBatch loads the state of the run in dir for --resume, or starts a new
+// run, refusing to touch a directory holding another run or the rows' files.
+func openBatch(statePath, dir string, rows []manifestRow, resume bool, requestID string) (*batchState, error) {
+	b, err := os.ReadFile(statePath)
+	switch {
+	case err == nil && !resume:
+		return nil, usageErr(statePath + " exists; use --resume to continue that run")
+	case err == nil:
+		var st batchState
+		if err := json.Unmarshal(b, &st); err != nil {
+			return nil, fmt.Errorf("%s: %w", statePath, err)
+		}
+		if requestID != "" && requestID != st.RequestID {
+			return nil, usageErr("--request-id differs from the resumed run's " + st.RequestID)
+		}
+		if st.Rows == nil {
+			st.Rows = map[string]*batchRow{}
+		}
+		return &st, nil
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	case resume:
+		return nil, usageErr("nothing to resume: " + statePath + " not found")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if err := checkFree(rowFiles(dir, r.CN)...); err != nil {
+			return nil, err
+		}
+	}
+	if requestID == "" {
+		id := make([]byte, 8)
+		_, _ = rand.Read(id)
+		requestID = "b-" + hex.EncodeToString(id)
+	}
+	st := &batchState{RequestID: requestID, Rows: map[string]*batchRow{}}
+	return st, saveBatchState(statePath, st)
+}
+
+func rowFiles(dir, cn string) []string {
+	return []string{filepath.Join(dir, cn+".crt"), filepath.Join(dir, cn+".key"), filepath.Join(dir, cn+".zip")}
+}
+
+// saveBatchResult writes the files of one result and records it in row. A row
+// is ok once its certificate is saved, even if its bundle failed after.
+func saveBatchResult(dir string, row *batchRow, res client.BatchResult, b *client.BundleReq) {
+	row.Serial, row.NotAfter, row.Error, row.Files = res.Serial, res.NotAfter, "", nil
+	if err := client.BatchErr(res); err != nil {
+		row.Error = err.Error()
+	}
+	if res.Serial == "" {
+		row.Status = rowFailed
+		return
+	}
+	files := rowFiles(dir, row.CN)
+	crt, key, zip := files[0], files[1], files[2]
+	write := func(path string, data []byte) bool {
+		if err := security.AtomicWrite(path, data, 0o600); err != nil {
+			row.Status, row.Error = rowFailed, err.Error()
+			return false
+		}
+		row.Files = append(row.Files, path)
+		return true
+	}
+	if !write(crt, []byte(res.CertPEM)) {
+		return
+	}
+	keyInZip := b != nil && b.IncludeKey && res.ZipB64 != ""
+	if res.KeyPEMEnc != "" && !keyInZip {
+		if !write(key, []byte(res.KeyPEMEnc)) {
+			return
+		}
+	}
+	if res.ZipB64 != "" {
+		data, err := base64.StdEncoding.DecodeString(res.ZipB64)
+		if err != nil {
+			row.Status, row.Error = rowFailed, "bundle: "+err.Error()
+			return
+		}
+		if !write(zip, data) {
+			return
+		}
+	}
+	row.Status = rowOK
+	if res.KeyPEMEnc == "" && !keyInZip {
+		row.Warning = "key not returned on replay; fetch it with bundle --include-key"
+	}
+}
+
+func saveBatchState(path string, st *batchState) error {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := security.AtomicWrite(path, append(b, '\n'), 0o600); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return nil
+}
+
+func showBatch(c *cli, report []*batchRow) error {
+	if c.output == "json" {
+		return printJSON(report)
+	}
+	rows := make([][]string, 0, len(report))
+	for _, r := range report {
+		note := r.Warning
+		if r.Error != "" {
+			note = r.Error
+		}
+		rows = append(rows, []string{r.CN, r.Status, r.Serial, r.NotAfter, strings.Join(r.Files, ","), note})
+	}
+	return printTable([]string{"CN", "STATUS", "SERIAL", "NOT AFTER", "FILES", "NOTE"}, rows)
+}
+
+// readManifest reads rows from a CSV file with a header line naming the
+// manifestRow fields, or from a YAML list of them.
+func readManifest(path, format string) ([]manifestRow, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = "csv"
+		case ".yaml", ".yml":
+			format = "yaml"
+		default:
+			return nil, usageErr("--format required for " + path)
+		}
+	}
+	var rows []manifestRow
+	var err error
+	switch format {
+	case "csv":
+		rows, err = readCSV(r)
+	case "yaml":
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err = dec.Decode(&rows); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	default:
+		return nil, usageErr("--format: want csv or yaml")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("manifest: no rows")
+	}
+	seen := map[string]bool{}
+	for i, row := range rows {
+		if row.CN == "" {
+			return nil, fmt.Errorf("manifest: row %d: cn required", i+1)
+		}
+		if seen[row.CN] {
+			return nil, fmt.Errorf("manifest: row %d: duplicate cn %s", i+1, row.CN)
+		}
+		seen[row.CN] = true
+	}
+	return rows, nil
+}
+
+func readCSV(r io.Reader) ([]manifestRow, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	recs, err := cr.ReadAll()
+	if err != nil || len(recs) == 0 {
+		return nil, err
+	}
+	header := recs[0]
+	var rows []manifestRow
+	for n, rec := range recs[1:] {
+		var row manifestRow
+		for i, v := range rec {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			if err := row.set(strings.TrimSpace(header[i]), v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+2, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (r *manifestRow) set(col, v string) error {
+	var err error
+	boolean := func() *bool {
+		var b bool
+		b, err = strconv.ParseBool(v)
+		return &b
+	}
+	switch col {
+	case "cn":
+		r.CN = v
+	case "profile":
+		r.Profile = v
+	case "key_type":
+		r.KeyType = v
+	case "pass":
+		r.Pass = v
+	case "bundle":
+		r.Bundle = boolean()
+	case "remote":
+		r.Remote = v
+	case "port":
+		r.Port, err = strconv.Atoi(v)
+	case "proto":
+		r.Proto = v
+	case "include_key":
+		r.IncludeKey = boolean()
+	case "template":
+		r.Template = v
+	default:
+		return fmt.Errorf("unknown column %q", col)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", col, err)
+	}
+	return nil
+}
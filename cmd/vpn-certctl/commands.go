@@ -0,0 +1,583 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/pkg/client"
+)
+
+// requestFlags are the per-request options a command may expose.
+type requestFlags struct {
+	requestID string
+	dryRun    bool
+	caID      string
+}
+
+func (r *requestFlags) opts() []client.Option {
+	var o []client.Option
+	if r.requestID != "" {
+		o = append(o, client.WithRequestID(r.requestID))
+	}
+	if r.dryRun {
+		o = append(o, client.DryRun())
+	}
+	if r.caID != "" {
+		o = append(o, client.WithCA(r.caID))
+	}
+	return o
+}
+
+// serialArg takes the serial from --serial or the first argument.
+func serialArg(flagVal string, args []string) (string, error) {
+	switch {
+	case flagVal != "":
+		return flagVal, nil
+	case len(args) == 1:
+		return args[0], nil
+	default:
+		return "", usageErr("serial required (--serial N or as the argument)")
+	}
+}
+
+// committed reports whether resp should be shown despite err: the operation
+// took effect and only a later step failed.
+func committed(resp client.Response, err error) bool {
+	return err == nil || resp.Serial != "" || resp.CRLPEM != ""
+}
+
+func runIssue(c *cli, args []string) error {
+	var cn, profile, keyType, passSrc, keyOut, certOut string
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&cn, "cn", "", "common name")
+	fs.StringVar(&profile, "profile", "client", "profile: client|server")
+	fs.StringVar(&keyType, "key-type", "rsa4096", "key type: rsa4096|ed25519")
+	fs.StringVar(&passSrc, "pass", "", passHelp)
+	fs.StringVar(&keyOut, "key-out", "", "write the encrypted key here (table output default: ./<cn>.key)")
+	fs.StringVar(&certOut, "cert-out", "", "write the certificate here (table output default: ./<cn>.crt)")
+	fs.StringVar(&rf.requestID, "request-id", "", "idempotency key: retrying with the same ID returns the first response")
+	fs.BoolVar(&rf.dryRun, "dry-run", false, "validate and show the certificate without issuing it")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if cn == "" {
+		return usageErr("--cn required")
+	}
+	if c.output == "table" && !rf.dryRun {
+		if keyOut == "" {
+			keyOut = "./" + cn + ".key"
+		}
+		if certOut == "" {
+			certOut = "./" + cn + ".crt"
+		}
+	}
+	if !rf.dryRun {
+		if err := checkFree(keyOut, certOut); err != nil {
+			return err
+		}
+	}
+	pass, err := passphrase(passSrc, "Key passphrase", true)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client().Issue(c.ctx, cn, client.Profile(profile), client.KeyType(keyType), string(pass), rf.opts()...)
+	if !committed(resp, err) {
+		return err
+	}
+	if werr := saveIssued(resp, certOut, keyOut); werr != nil {
+		return errors.Join(err, werr)
+	}
+	return errors.Join(err, c.showIssued(resp, certOut, keyOut))
+}
+
+func runSign(c *cli, args []string) error {
+	var cn, profile, csrPath, certOut string
+	var chain bool
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&cn, "cn", "", "common name (default: the CSR subject CN)")
+	fs.StringVar(&profile, "profile", "client", "profile: client|server")
+	fs.StringVar(&csrPath, "csr", "-", "CSR PEM file, - for stdin")
+	fs.StringVar(&certOut, "cert-out", "", "write the certificate here")
+	fs.BoolVar(&chain, "chain", false, "pem output: append the CA chain")
+	fs.StringVar(&rf.requestID, "request-id", "", "idempotency key: retrying with the same ID returns the first response")
+	fs.BoolVar(&rf.dryRun, "dry-run", false, "validate and show the certificate without issuing it")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	var csr []byte
+	var err error
+	if csrPath == "-" {
+		csr, err = io.ReadAll(os.Stdin)
+	} else {
+		csr, err = os.ReadFile(csrPath)
+	}
+	if err != nil {
+		return fmt.Errorf("read csr: %w", err)
+	}
+	if cn == "" {
+		if cn, err = csrCN(csr); err != nil {
+			return err
+		}
+	}
+	if !rf.dryRun {
+		if err := checkFree(certOut); err != nil {
+			return err
+		}
+	}
+	resp, err := c.client().Sign(c.ctx, cn, client.Profile(profile), string(csr), rf.opts()...)
+	if !committed(resp, err) {
+		return err
+	}
+	if werr := saveIssued(resp, certOut, ""); werr != nil {
+		return errors.Join(err, werr)
+	}
+	if c.output == "pem" && !chain {
+		resp.ChainPEM = ""
+	}
+	return errors.Join(err, c.showIssued(resp, certOut, ""))
+}
+
+func csrCN(b []byte) (string, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return "", fmt.Errorf("csr: no PEM block")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("csr: %w", err)
+	}
+	if csr.Subject.CommonName == "" {
+		return "", usageErr("csr has no subject CN; pass --cn")
+	}
+	return csr.Subject.CommonName, nil
+}
+
+func saveIssued(resp client.Response, certOut, keyOut string) error {
+	if resp.DryRun {
+		return nil
+	}
+	if certOut != "" && resp.CertPEM != "" {
+		if err := writeNew(certOut, []byte(resp.CertPEM), 0o644); err != nil {
+			return fmt.Errorf("write cert: %w", err)
+		}
+	}
+	if keyOut != "" && resp.KeyPEMEnc != "" {
+		if err := writeNew(keyOut, []byte(resp.KeyPEMEnc), 0o600); err != nil {
+			return fmt.Errorf("write key: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *cli) showIssued(resp client.Response, certOut, keyOut string) error {
+	switch c.output {
+	case "json":
+		return printJSON(resp)
+	case "pem":
+		if resp.DryRun {
+			return fmt.Errorf("dry run: nothing to print as PEM")
+		}
+		return printPEM(resp.CertPEM, resp.ChainPEM, resp.KeyPEMEnc)
+	}
+	if p := resp.Preview; p != nil {
+		return printFields(
+			"dry run", "nothing issued",
+			"subject", p.Subject,
+			"issuer", p.Issuer,
+			"ca", p.CAID,
+			"profile", string(p.Profile),
+			"key type", p.KeyType,
+			"sans", strings.Join(p.SANs, ", "),
+			"key usage", strings.Join(p.KeyUsage, ", "),
+			"ext key usage", strings.Join(p.ExtKeyUsage, ", "),
+			"valid", p.NotBefore+" .. "+p.NotAfter,
+		)
+	}
+	if resp.KeyPEMEnc == "" {
+		keyOut = ""
+	}
+	if resp.CertPEM == "" {
+		certOut = ""
+	}
+	return printFields(
+		"serial", resp.Serial,
+		"ca", resp.CAID,
+		"not after", resp.NotAfter,
+		"certificate", certOut,
+		"key", keyOut,
+		"request id", resp.RequestID,
+		"replayed", replayed(resp, " (key not returned again)"),
+		"hooks failed", strings.Join(resp.HooksFailed, ", "),
+	)
+}
+
+func replayed(resp client.Response, note string) string {
+	if !resp.Replayed {
+		return ""
+	}
+	return "yes" + note
+}
+
+func runRevoke(c *cli, args []string) error {
+	var serial, reason string
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&serial, "serial", "", "serial (decimal)")
+	fs.StringVar(&reason, "reason", "", "reason, e.g. keyCompromise, superseded, certificateHold")
+	fs.StringVar(&rf.caID, "ca-id", "", "CA ID; needed only when several CAs issued the serial")
+	fs.StringVar(&rf.requestID, "request-id", "", "idempotency key: retrying with the same ID returns the first response")
+	fs.BoolVar(&rf.dryRun, "dry-run", false, "show the effect without revoking")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	serial, err := serialArg(serial, c.args)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client().Revoke(c.ctx, serial, reason, rf.opts()...)
+	if !committed(resp, err) {
+		return err
+	}
+	return errors.Join(err, c.showCRLChange(resp, serial))
+}
+
+func runRevokeTLSKey(c *cli, args []string) error {
+	var serial string
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&serial, "serial", "", "serial (decimal)")
+	fs.StringVar(&rf.caID, "ca-id", "", "CA ID; needed only when several CAs issued the serial")
+	fs.StringVar(&rf.requestID, "request-id", "", "idempotency key: retrying with the same ID returns the first response")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	serial, err := serialArg(serial, c.args)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client().Do(c.ctx, client.Request{Op: api.OpRevokeTLSKey, Serial: serial}, rf.opts()...)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(resp)
+	}
+	return printFields("serial", resp.Serial, "ca_id", resp.CAID, "tls_key_id", resp.TLSKeyID, "replayed", replayed(resp, ""))
+}
+
+func (c *cli) showCRLChange(resp client.Response, serial string) error {
+	switch c.output {
+	case "json":
+		return printJSON(resp)
+	case "pem":
+		return printPEM(resp.CRLPEM)
+	}
+	if p := resp.Revoke; p != nil {
+		return printFields(
+			"dry run", "nothing revoked",
+			"serial", p.Serial,
+			"ca", p.CAID,
+			"cn", p.CN,
+			"known", yesNo(p.Known),
+			"current reason", p.Current,
+			"reason", p.Reason,
+			"effect", p.Effect,
+			"crl entries", fmt.Sprint(p.CRLEntries),
+		)
+	}
+	rows, err := crlRows(resp.CRLPEM)
+	if err != nil {
+		return err
+	}
+	entries := ""
+	if len(rows) == 1 {
+		entries = rows[0][4]
+	}
+	return printFields(
+		"serial", serial,
+		"ca", resp.CAID,
+		"crl entries", entries,
+		"request id", resp.RequestID,
+		"replayed", replayed(resp, ""),
+		"hooks failed", strings.Join(resp.HooksFailed, ", "),
+	)
+}
+
+func runCRL(c *cli, args []string) error {
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&rf.caID, "ca-id", "", "only this CA's CRL")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	crl, err := c.client().GetCRL(c.ctx, rf.opts()...)
+	if err != nil {
+		return err
+	}
+	switch c.output {
+	case "json":
+		return printJSON(client.Response{CRLPEM: crl})
+	case "pem":
+		return printPEM(crl)
+	}
+	rows, err := crlRows(crl)
+	if err != nil {
+		return err
+	}
+	return printTable([]string{"ISSUER", "NUMBER", "THIS UPDATE", "NEXT UPDATE", "ENTRIES"}, rows)
+}
+
+func runList(c *cli, args []string) error {
+	fs := c.flags("table")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	list, err := c.client().ListIssued(c.ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(list)
+	}
+	rows := make([][]string, 0, len(list))
+	for _, m := range list {
+		rows = append(rows, []string{m.Serial, m.CN, m.Profile, m.NotAfter, m.CAID})
+	}
+	return printTable([]string{"SERIAL", "CN", "PROFILE", "NOT AFTER", "CA"}, rows)
+}
+
+func runShow(c *cli, args []string) error {
+	var serial string
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&serial, "serial", "", "serial (decimal)")
+	fs.StringVar(&rf.caID, "ca-id", "", "CA ID; needed only when several CAs issued the serial")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	serial, err := serialArg(serial, c.args)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client().Do(c.ctx, client.Request{Op: api.OpGetCert, Serial: serial}, rf.opts()...)
+	if err != nil {
+		return err
+	}
+	switch c.output {
+	case "json":
+		return printJSON(resp.Cert)
+	case "pem":
+		if resp.CertPEM == "" {
+			return fmt.Errorf("certificate PEM is no longer cached by the daemon")
+		}
+		return printPEM(resp.CertPEM)
+	}
+	ci := resp.Cert
+	revoked := "no"
+	if ci.Revoked {
+		revoked = "yes, " + ci.RevocationReason
+		if ci.RevokedAt != "" {
+			revoked += " at " + ci.RevokedAt
+		}
+	}
+	return printFields(
+		"serial", ci.Serial,
+		"cn", ci.CN,
+		"profile", ci.Profile,
+		"ca", ci.CAID,
+		"not after", ci.NotAfter,
+		"sha256", ci.SHA256,
+		"revoked", revoked,
+	)
+}
+
+func runExpiring(c *cli, args []string) error {
+	var days int
+	fs := c.flags("table")
+	fs.IntVar(&days, "days", 0, "window in days (default: largest policy window)")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	resp, err := c.client().Do(c.ctx, client.Request{Op: api.OpExpiring, Days: days})
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(resp.Expiring)
+	}
+	rows := make([][]string, 0, len(resp.Expiring))
+	for _, e := range resp.Expiring {
+		rows = append(rows, []string{e.Serial, e.CN, e.Profile, e.NotAfter, fmt.Sprint(e.DaysLeft), e.CAID})
+	}
+	return printTable([]string{"SERIAL", "CN", "PROFILE", "NOT AFTER", "DAYS LEFT", "CA"}, rows)
+}
+
+func runBundle(c *cli, args []string) error {
+	var b client.BundleReq
+	var out string
+	fs := c.flags("table")
+	fs.StringVar(&b.CN, "cn", "", "CN of an issued certificate")
+	fs.StringVar(&b.RemoteHost, "remote", "", "VPN server host")
+	fs.IntVar(&b.RemotePort, "port", 1194, "VPN server port")
+	fs.StringVar(&b.Proto, "proto", "udp", "udp|tcp")
+	fs.BoolVar(&b.IncludeKey, "include-key", false, "include the key if the daemon still caches it")
+	fs.StringVar(&b.Template, "template", "", "profile template (default: the daemon's choice for the certificate's profile)")
+	fs.StringVar(&out, "out", "", "output zip (default ./<cn>.zip)")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if b.CN == "" || b.RemoteHost == "" {
+		return usageErr("--cn and --remote required")
+	}
+	if out == "" {
+		out = "./" + b.CN + ".zip"
+	}
+	if err := checkFree(out); err != nil {
+		return err
+	}
+	zip, err := c.client().BuildBundle(c.ctx, b)
+	if err != nil {
+		return err
+	}
+	if err := writeNew(out, zip, 0o600); err != nil {
+		return fmt.Errorf("write bundle: %w", err)
+	}
+	if c.output == "json" {
+		return printJSON(map[string]any{"path": out, "bytes": len(zip)})
+	}
+	return printFields("bundle", out, "bytes", fmt.Sprint(len(zip)))
+}
+
+func runHealth(c *cli, args []string) error {
+	fs := c.flags("table")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	resp, err := c.client().Do(c.ctx, client.Request{Op: api.OpHealth})
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(resp)
+	}
+	fmt.Println(resp.Serial)
+	return nil
+}
+
+func runStatus(c *cli, args []string) error {
+	fs := c.flags("table")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	resp, err := c.client().Do(c.ctx, client.Request{Op: api.OpStatus})
+	if err != nil {
+		return err
+	}
+	return c.showStatus(resp.Status)
+}
+
+func runUnlock(c *cli, args []string) error {
+	var passSrc string
+	fs := c.flags("table")
+	fs.StringVar(&passSrc, "pass", "", passHelp)
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	pass, err := passphrase(passSrc, "CA key passphrase", false)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client().Do(c.ctx, client.Request{Op: api.OpUnlock, Passphrase: string(pass)})
+	if err != nil {
+		return err
+	}
+	return c.showStatus(resp.Status)
+}
+
+func (c *cli) showStatus(st *api.StatusReport) error {
+	if c.output == "json" || st == nil {
+		return printJSON(st)
+	}
+	if err := printFields(
+		"verdict", string(st.Verdict),
+		"tenant", st.Tenant,
+		"version", st.Version,
+		"uptime", (time.Duration(st.UptimeSeconds) * time.Second).String(),
+		"problems", strings.Join(st.Problems, "; "),
+	); err != nil {
+		return err
+	}
+	cas := st.CAs
+	if len(cas) == 0 && st.CA != nil {
+		cas = []api.CAStatus{*st.CA}
+	}
+	if len(cas) == 0 {
+		return nil
+	}
+	fmt.Println()
+	rows := make([][]string, 0, len(cas))
+	for _, ca := range cas {
+		rows = append(rows, []string{ca.ID, yesNo(ca.Active), yesNo(ca.Locked), ca.NotAfter, fmt.Sprint(ca.DaysLeft), ca.Subject})
+	}
+	return printTable([]string{"CA", "ACTIVE", "LOCKED", "NOT AFTER", "DAYS LEFT", "SUBJECT"}, rows)
+}
+
+// runSubscribe streams events over a plain protocol 1 connection until
+// interrupted.
+func runSubscribe(c *cli, args []string) error {
+	var since uint64
+	fs := c.flags("table")
+	fs.Uint64Var(&since, "since-seq", 0, "replay events after this sequence number")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(c.ctx, "unix", c.socket)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	go func() {
+		<-c.ctx.Done()
+		_ = conn.Close()
+	}()
+	b, _ := json.Marshal(api.Request{Op: api.OpSubscribe, Tenant: c.tenant, SinceSeq: since})
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(conn)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if c.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var resp client.Response
+		if json.Unmarshal(raw, &resp) == nil && resp.Err != nil {
+			return &client.Error{Code: client.Code(resp.Err.Code), Field: resp.Err.Field, Message: resp.Err.Message}
+		}
+		if c.output == "json" {
+			fmt.Println(string(raw))
+			continue
+		}
+		var ev api.Event
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			return err
+		}
+		detail := strings.TrimSpace(strings.Join([]string{ev.CN, ev.Serial, ev.Reason}, " "))
+		fmt.Printf("%d\t%s\t%s\t%s\n", ev.Seq, ev.Time, ev.Type, detail)
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+)
+
+// Kinds of decoded objects.
+const (
+	kindCert  = "certificate"
+	kindCSR   = "csr"
+	kindCRL   = "crl"
+	kindKey   = "private_key"
+	kindOther = "other"
+)
+
+// object is one thing found in an input file or bundle entry.
+type object struct {
+	Source string    `json:"source"`
+	Kind   string    `json:"kind"`
+	Cert   *certInfo `json:"certificate,omitempty"`
+	CSR    *csrInfo  `json:"csr,omitempty"`
+	CRL    *crlInfo  `json:"crl,omitempty"`
+	// Type is the PEM type of keys and of blocks that are not decoded.
+	Type      string `json:"type,omitempty"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+
+	cert *x509.Certificate
+	crl  *x509.RevocationList
+}
+
+type certInfo struct {
+	Subject            string   `json:"subject"`
+	Issuer             string   `json:"issuer"`
+	Serial             string   `json:"serial"`
+	SANs               []string `json:"sans,omitempty"`
+	KeyUsage           []string `json:"key_usage"`
+	ExtKeyUsage        []string `json:"ext_key_usage"`
+	IsCA               bool     `json:"is_ca"`
+	NotBefore          string   `json:"not_before"`
+	NotAfter           string   `json:"not_after"`
+	DaysLeft           int      `json:"days_left"`
+	PublicKey          string   `json:"public_key"`
+	SignatureAlgorithm string   `json:"signature_algorithm"`
+	SHA256             string   `json:"sha256"`
+	SHA1               string   `json:"sha1"`
+}
+
+type csrInfo struct {
+	Subject            string   `json:"subject"`
+	SANs               []string `json:"sans,omitempty"`
+	PublicKey          string   `json:"public_key"`
+	SignatureAlgorithm string   `json:"signature_algorithm"`
+	SignatureValid     bool     `json:"signature_valid"`
+}
+
+type crlInfo struct {
+	Issuer     string     `json:"issuer"`
+	Number     string     `json:"number,omitempty"`
+	ThisUpdate string     `json:"this_update"`
+	NextUpdate string     `json:"next_update"`
+	Entries    []crlEntry `json:"entries"`
+}
+
+type crlEntry struct {
+	Serial    string `json:"serial"`
+	RevokedAt string `json:"revoked_at"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func runInspect(c *cli, args []string) error {
+	fs := c.flags("table")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if len(c.args) == 0 {
+		return usageErr("file required (a certificate, CSR, CRL, key, .ovpn or bundle zip; - for stdin)")
+	}
+	var all []object
+	for _, path := range c.args {
+		objs, err := readObjects(path)
+		if err != nil {
+			return err
+		}
+		all = append(all, objs...)
+	}
+	if c.output == "json" {
+		return printJSON(all)
+	}
+	for i, o := range all {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := showObject(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func showObject(o object) error {
+	fmt.Printf("%s (%s)\n", o.Source, strings.ReplaceAll(o.Kind, "_", " "))
+	switch {
+	case o.Cert != nil:
+		ci := o.Cert
+		validity := fmt.Sprintf("%s .. %s (%d days left)", ci.NotBefore, ci.NotAfter, ci.DaysLeft)
+		if ci.DaysLeft < 0 {
+			validity = fmt.Sprintf("%s .. %s (EXPIRED %d days ago)", ci.NotBefore, ci.NotAfter, -ci.DaysLeft)
+		}
+		return printFields(
+			"  subject", ci.Subject,
+			"  issuer", ci.Issuer,
+			"  serial", ci.Serial,
+			"  sans", strings.Join(ci.SANs, ", "),
+			"  key usage", strings.Join(ci.KeyUsage, ", "),
+			"  ext key usage", strings.Join(ci.ExtKeyUsage, ", "),
+			"  ca", yesNo(ci.IsCA),
+			"  validity", validity,
+			"  public key", ci.PublicKey,
+			"  signature", ci.SignatureAlgorithm,
+			"  sha256", ci.SHA256,
+			"  sha1", ci.SHA1,
+		)
+	case o.CSR != nil:
+		return printFields(
+			"  subject", o.CSR.Subject,
+			"  sans", strings.Join(o.CSR.SANs, ", "),
+			"  public key", o.CSR.PublicKey,
+			"  signature", o.CSR.SignatureAlgorithm,
+			"  signature valid", yesNo(o.CSR.SignatureValid),
+		)
+	case o.CRL != nil:
+		if err := printFields(
+			"  issuer", o.CRL.Issuer,
+			"  number", o.CRL.Number,
+			"  this update", o.CRL.ThisUpdate,
+			"  next update", o.CRL.NextUpdate,
+			"  entries", fmt.Sprint(len(o.CRL.Entries)),
+		); err != nil || len(o.CRL.Entries) == 0 {
+			return err
+		}
+		rows := make([][]string, 0, len(o.CRL.Entries))
+		for _, e := range o.CRL.Entries {
+			rows = append(rows, []string{"  " + e.Serial, e.RevokedAt, e.Reason})
+		}
+		return printTable([]string{"  SERIAL", "REVOKED AT", "REASON"}, rows)
+	default:
+		return printFields("  type", o.Type, "  encrypted", yesNo(o.Encrypted))
+	}
+}
+
+// readObjects decodes path, or stdin for "-".
+func readObjects(path string) ([]object, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	objs, err := decodeObjects(path, b)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("%s: no certificate, CSR, CRL or key found", path)
+	}
+	return objs, nil
+}
+
+// decodeObjects finds everything in b: the entries of a zip, PEM blocks
+// anywhere in text (so inline .ovpn files work), or a single DER object.
+func decodeObjects(source string, b []byte) ([]object, error) {
+	if bytes.HasPrefix(b, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		var out []object
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", source, f.Name, err)
+			}
+			data, err := io.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", source, f.Name, err)
+			}
+			objs, err := decodeObjects(source+":"+f.Name, data)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, objs...)
+		}
+		return out, nil
+	}
+	if bytes.Contains(b, []byte("-----BEGIN ")) {
+		var out []object
+		rest := b
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				return out, nil
+			}
+			o, err := decodeBlock(source, block.Type, block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+				o.Encrypted = true
+			}
+			out = append(out, o)
+		}
+	}
+	for _, kind := range []string{"CERTIFICATE", "X509 CRL", "CERTIFICATE REQUEST"} {
+		if o, err := decodeBlock(source, kind, b); err == nil {
+			return []object{o}, nil
+		}
+	}
+	return nil, nil
+}
+
+func decodeBlock(source, typ string, der []byte) (object, error) {
+	o := object{Source: source}
+	switch typ {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return o, fmt.Errorf("%s: certificate: %w", source, err)
+		}
+		o.Kind, o.Cert, o.cert = kindCert, describeCert(cert), cert
+	case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			return o, fmt.Errorf("%s: csr: %w", source, err)
+		}
+		o.Kind = kindCSR
+		o.CSR = &csrInfo{
+			Subject:            csr.Subject.String(),
+			SANs:               sans(csr.DNSNames, csr.IPAddresses, csr.EmailAddresses, csr.URIs),
+			PublicKey:          publicKeyName(csr.PublicKey),
+			SignatureAlgorithm: csr.SignatureAlgorithm.String(),
+			SignatureValid:     csr.CheckSignature() == nil,
+		}
+	case "X509 CRL":
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return o, fmt.Errorf("%s: crl: %w", source, err)
+		}
+		o.Kind, o.CRL, o.crl = kindCRL, describeCRL(crl), crl
+	default:
+		o.Kind, o.Type = kindOther, typ
+		if strings.HasSuffix(typ, "PRIVATE KEY") {
+			o.Kind = kindKey
+			o.Encrypted = strings.HasPrefix(typ, "ENCRYPTED")
+		}
+	}
+	return o, nil
+}
+
+func describeCert(cert *x509.Certificate) *certInfo {
+	s256 := sha256.Sum256(cert.Raw)
+	s1 := sha1.Sum(cert.Raw)
+	return &certInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		Serial:             cert.SerialNumber.String(),
+		SANs:               sans(cert.DNSNames, cert.IPAddresses, cert.EmailAddresses, cert.URIs),
+		KeyUsage:           pki.KeyUsageNames(cert.KeyUsage),
+		ExtKeyUsage:        pki.ExtKeyUsageNames(cert.ExtKeyUsage),
+		IsCA:               cert.IsCA,
+		NotBefore:          cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:           cert.NotAfter.UTC().Format(time.RFC3339),
+		DaysLeft:           int(time.Until(cert.NotAfter).Hours() / 24),
+		PublicKey:          publicKeyName(cert.PublicKey),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		SHA256:             fingerprint(s256[:]),
+		SHA1:               fingerprint(s1[:]),
+	}
+}
+
+func describeCRL(crl *x509.RevocationList) *crlInfo {
+	ci := &crlInfo{
+		Issuer:     crl.Issuer.String(),
+		ThisUpdate: crl.ThisUpdate.UTC().Format(time.RFC3339),
+		NextUpdate: crl.NextUpdate.UTC().Format(time.RFC3339),
+		Entries:    []crlEntry{},
+	}
+	if crl.Number != nil {
+		ci.Number = crl.Number.String()
+	}
+	for _, e := range crl.RevokedCertificateEntries {
+		ci.Entries = append(ci.Entries, crlEntry{
+			Serial:    e.SerialNumber.String(),
+			RevokedAt: e.RevocationTime.UTC().Format(time.RFC3339),
+			Reason:    crlReason(e),
+		})
+	}
+	return ci
+}
+
+var oidReasonCode = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// crlReason names the reasonCode of e; CRLs written before reason codes
+// were recorded have none.
+func crlReason(e x509.RevocationListEntry) string {
+	for _, ext := range e.Extensions {
+		if ext.Id.Equal(oidReasonCode) {
+			return pki.ReasonName(e.ReasonCode)
+		}
+	}
+	return ""
+}
+
+func sans(dns []string, ips []net.IP, emails []string, uris []*url.URL) []string {
+	out := append([]string(nil), dns...)
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	out = append(out, emails...)
+	for _, u := range uris {
+		out = append(out, u.String())
+	}
+	return out
+}
+
+func publicKeyName(pub any) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", pub)
+	}
+}
+
+// fingerprint formats a digest as openssl does: upper-case hex pairs joined
+// by colons.
+func fingerprint(sum []byte) string {
+	h := strings.ToUpper(hex.EncodeToString(sum))
+	parts := make([]string, 0, len(sum))
+	for i := 0; i < len(h); i += 2 {
+		parts = append(parts, h[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
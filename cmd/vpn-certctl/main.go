@@ -1,95 +1,196 @@
 package main
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/pkg/client"
 )
 
+const usage = `usage: vpn-certctl <command> [flags]
+
+commands:
+  issue           generate a key pair and certificate (GENKEY_AND_SIGN)
+  sign            sign a CSR from a file or stdin (SIGN)
+  revoke          revoke a serial (REVOKE)
+  revoke-tls-key  retire a certificate's tls-crypt-v2 client key (REVOKE_TLS_KEY)
+  crl             print the current CRL (GET_CRL)
+  list            list recently issued certificates (LIST_ISSUED)
+  show            show one certificate and its revocation state (GET_CERT)
+  expiring        list certificates inside their renewal window (EXPIRING)
+  bundle          build a client bundle zip (BUILD_BUNDLE)
+  batch           issue and bundle every row of a CSV/YAML manifest (BATCH)
+  inspect         decode certificates, CSRs, CRLs, keys and bundle zips (local)
+  verify          check a certificate as OpenVPN would, against the daemon's CA and CRL
+  health          check that the daemon answers (HEALTH)
+  status          print the daemon status report (STATUS)
+  unlock          unlock an encrypted CA key (UNLOCK)
+  subscribe       stream lifecycle events (SUBSCRIBE)
+
+Every command takes --socket, --tenant, --timeout and -o table|json|pem.
+Run "vpn-certctl <command> -h" for its flags.`
+
+// Exit codes. Daemon errors map to one code per xerr.Code.
+const (
+	exitOK         = 0
+	exitFailure    = 1
+	exitUsage      = 2
+	exitBadRequest = 3
+	exitForbidden  = 4
+	exitNotFound   = 5
+	exitConflict   = 6
+	exitInternal   = 7
+	exitNotImpl    = 8
+	// the daemon could not be reached or did not answer
+	exitUnreached = 9
+	// verify: OpenVPN would reject the certificate
+	exitRejected = 10
+)
+
+type command struct {
+	run func(c *cli, args []string) error
+	// pem reports whether -o pem is meaningful for the command.
+	pem bool
+}
+
+var commands = map[string]command{
+	"issue":          {runIssue, true},
+	"sign":           {runSign, true},
+	"revoke":         {runRevoke, true},
+	"revoke-tls-key": {runRevokeTLSKey, false},
+	"crl":            {runCRL, true},
+	"list":           {runList, false},
+	"show":           {runShow, true},
+	"expiring":       {runExpiring, false},
+	"bundle":         {runBundle, false},
+	"batch":          {runBatch, false},
+	"inspect":        {runInspect, false},
+	"verify":         {runVerify, false},
+	"health":         {runHealth, false},
+	"status":         {runStatus, false},
+	"unlock":         {runUnlock, false},
+	"subscribe":      {runSubscribe, false},
+}
+
 func main() {
-	var socket, op, cn, profile, keyType, pass, csr, serial, bundleCN, bundleRemote, bundleProto, bundleOut, reason string
-	var bundlePort int
-	var bundleIncludeKey bool
-	flag.StringVar(&socket, "socket", "./dist/run/vpn-certd.sock", "unix socket")
-	flag.StringVar(&op, "op", "HEALTH", "op: HEALTH|SIGN|GENKEY_AND_SIGN|REVOKE|GET_CRL|LIST_ISSUED")
-	flag.StringVar(&cn, "cn", "", "common name")
-	flag.StringVar(&profile, "profile", "client", "profile: client|server")
-	flag.StringVar(&keyType, "key-type", "rsa4096", "key type: rsa4096|ed25519")
-	flag.StringVar(&pass, "passphrase", "", "passphrase (for GENKEY_AND_SIGN)")
-	flag.StringVar(&csr, "csr", "", "PEM CSR (for SIGN)")
-	flag.StringVar(&serial, "serial", "", "serial (for REVOKE)")
-	flag.StringVar(&reason, "reason", "", "reason (for REVOKE)")
-	flag.StringVar(&bundleCN, "bundle-cn", "", "BUILD_BUNDLE: CN")
-	flag.StringVar(&bundleRemote, "bundle-remote", "", "BUILD_BUNDLE: remote host")
-	flag.IntVar(&bundlePort, "bundle-port", 1194, "BUILD_BUNDLE: remote port")
-	flag.StringVar(&bundleProto, "bundle-proto", "udp", "BUILD_BUNDLE: proto")
-	flag.BoolVar(&bundleIncludeKey, "bundle-include-key", true, "BUILD_BUNDLE: include key if cached")
-	flag.StringVar(&bundleOut, "bundle-out", "", "BUILD_BUNDLE: output zip path")
-	flag.Parse()
-
-	req := api.Request{
-		Op:         api.Op(op),
-		CN:         cn,
-		Profile:    api.Profile(profile),
-		KeyType:    api.KeyType(keyType),
-		Passphrase: pass,
-		CSRPEM:     csr,
-		Serial:     serial,
-		Reason:     reason,
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(exitUsage)
 	}
-
-	if op == "BUILD_BUNDLE" {
-		req.Bundle = &api.BundleReq{
-			CN:         bundleCN,
-			IncludeKey: bundleIncludeKey,
-			RemoteHost: bundleRemote,
-			RemotePort: bundlePort,
-			Proto:      bundleProto,
-		}
+	name := os.Args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "vpn-certctl: unknown command %q\n\n%s\n", name, usage)
+		os.Exit(exitUsage)
 	}
-
-	b, _ := json.Marshal(req)
-	conn, err := net.DialTimeout("unix", socket, 3*time.Second)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	c := &cli{ctx: ctx, name: name, pemOK: cmd.pem}
+	err := cmd.run(c, os.Args[2:])
+	stop()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "dial:", err)
-		os.Exit(2)
-	}
-	defer conn.Close()
-	if _, err := conn.Write(append(b, '\n')); err != nil {
-		fmt.Fprintln(os.Stderr, "write:", err)
-		os.Exit(2)
+		fmt.Fprintf(os.Stderr, "vpn-certctl %s: %v\n", name, err)
 	}
-	dec := json.NewDecoder(conn)
-	var resp api.Response
-	if err := dec.Decode(&resp); err != nil {
-		fmt.Fprintln(os.Stderr, "decode:", err)
-		os.Exit(2)
+	os.Exit(exitCode(err))
+}
+
+// cli holds the flags every command shares.
+type cli struct {
+	ctx     context.Context
+	name    string
+	pemOK   bool
+	socket  string
+	tenant  string
+	output  string
+	timeout time.Duration
+	args    []string
+}
+
+// flags returns the command's flag set with the common flags registered.
+func (c *cli) flags(defaultOutput string) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	socket := os.Getenv(constants.EnvPrefix + "SOCKET")
+	if socket == "" {
+		socket = constants.DefaultSocketPath
 	}
-	if op == "BUILD_BUNDLE" && resp.ZipB64 != "" {
-		out := bundleOut
-		if out == "" {
-			out = fmt.Sprintf("./dist/%s.zip", bundleCN)
-		}
-		b, err := base64.StdEncoding.DecodeString(resp.ZipB64)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "decode zip:", err)
-			os.Exit(2)
+	fs.StringVar(&c.socket, "socket", socket, "daemon socket (env VPN_CERTD_SOCKET)")
+	fs.StringVar(&c.tenant, "tenant", "", "tenant (when the daemon serves several)")
+	fs.DurationVar(&c.timeout, "timeout", client.DefaultTimeout, "per-attempt timeout")
+	fs.StringVar(&c.output, "o", defaultOutput, "output: table|json|pem")
+	return fs
+}
+
+// parse parses args, allowing flags after positional arguments, which end
+// up in c.args, and checks the output mode.
+func (c *cli) parse(fs *flag.FlagSet, args []string) error {
+	for {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
 		}
-		if err := os.WriteFile(out, b, 0o600); err != nil {
-			fmt.Fprintln(os.Stderr, "write zip:", err)
-			os.Exit(2)
+		c.args = append(c.args, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	switch c.output {
+	case "table", "json":
+	case "pem":
+		if !c.pemOK {
+			return usageErr("-o pem is not available for " + c.name)
 		}
-		fmt.Println(out)
-		return
+	default:
+		return usageErr("-o: want table, json or pem")
 	}
+	return nil
+}
+
+func (c *cli) client() *client.Client {
+	cl := client.New(c.socket)
+	cl.Tenant = c.tenant
+	cl.Timeout = c.timeout
+	return cl
+}
+
+type usageErr string
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	_ = enc.Encode(resp)
+func (e usageErr) Error() string { return string(e) }
+
+func exitCode(err error) int {
+	var u usageErr
+	var r rejectedErr
+	var ne net.Error
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &u):
+		return exitUsage
+	case errors.As(err, &r):
+		return exitRejected
+	case errors.As(err, &ne), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return exitUnreached
+	}
+	switch client.CodeOf(err) {
+	case client.BadRequest:
+		return exitBadRequest
+	case client.Forbidden:
+		return exitForbidden
+	case client.NotFound:
+		return exitNotFound
+	case client.Conflict:
+		return exitConflict
+	case client.Internal:
+		return exitInternal
+	case client.NotImpl:
+		return exitNotImpl
+	default:
+		return exitFailure
+	}
 }
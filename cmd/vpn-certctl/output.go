@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printPEM writes the non-empty blocks in order.
+func printPEM(blocks ...string) error {
+	n := 0
+	for _, b := range blocks {
+		if b == "" {
+			continue
+		}
+		if !strings.HasSuffix(b, "\n") {
+			b += "\n"
+		}
+		if _, err := io.WriteString(os.Stdout, b); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("nothing to print as PEM")
+	}
+	return nil
+}
+
+// printTable writes a header row and rows aligned in columns.
+func printTable(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printFields writes label/value pairs, skipping empty values.
+func printFields(kv ...string) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", kv[i], kv[i+1])
+		}
+	}
+	return tw.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// crlRows summarises every CRL in a PEM blob, one row per CRL.
+func crlRows(crlPEM string) ([][]string, error) {
+	var rows [][]string
+	rest := []byte(crlPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse crl: %w", err)
+		}
+		number := ""
+		if crl.Number != nil {
+			number = crl.Number.String()
+		}
+		rows = append(rows, []string{
+			crl.Issuer.String(), number,
+			crl.ThisUpdate.UTC().Format(time.RFC3339), crl.NextUpdate.UTC().Format(time.RFC3339),
+			fmt.Sprint(len(crl.RevokedCertificateEntries)),
+		})
+	}
+	return rows, nil
+}
+
+// writeNew writes b to path, refusing to replace an existing file.
+func writeNew(path string, b []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkFree fails when path already exists, so nothing is issued that could
+// not be saved.
+func checkFree(paths ...string) error {
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if _, err := os.Lstat(p); err == nil {
+			return fmt.Errorf("%s exists", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+)
+
+const passHelp = "read the passphrase from credential:NAME|fd:N|file:PATH (default: prompt on the terminal)"
+
+// passphrase reads a secret from src or, without one, prompts on the
+// controlling terminal; confirm asks twice. Passphrases never go on argv.
+func passphrase(src, prompt string, confirm bool) ([]byte, error) {
+	if src != "" {
+		b, err := security.ReadSecret(src)
+		if err != nil {
+			return nil, fmt.Errorf("passphrase: %w", err)
+		}
+		if len(b) == 0 {
+			return nil, fmt.Errorf("passphrase: empty")
+		}
+		return b, nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, usageErr("passphrase: no terminal to prompt on; use --pass")
+	}
+	defer tty.Close()
+	read := func(p string) ([]byte, error) {
+		fmt.Fprint(tty, p)
+		b, err := term.ReadPassword(int(tty.Fd()))
+		fmt.Fprintln(tty)
+		return b, err
+	}
+	b, err := read(prompt + ": ")
+	if err != nil {
+		return nil, fmt.Errorf("passphrase: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("passphrase: empty")
+	}
+	if confirm {
+		again, err := read("Repeat " + prompt + ": ")
+		if err != nil {
+			return nil, fmt.Errorf("passphrase: %w", err)
+		}
+		if !bytes.Equal(b, again) {
+			return nil, fmt.Errorf("passphrase: entries do not match")
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+	"github.com/HarounAhmad/vpn-certd/pkg/client"
+)
+
+// rejectedErr reports that verify found a reason OpenVPN would refuse the
+// certificate.
+type rejectedErr string
+
+func (e rejectedErr) Error() string { return "rejected: " + string(e) }
+
+// check is one verify step; steps run in the order OpenVPN fails them.
+type check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+type verifyReport struct {
+	Certificate *certInfo `json:"certificate"`
+	Role        string    `json:"role"`
+	Accepted    bool      `json:"accepted"`
+	Reason      string    `json:"reason,omitempty"`
+	Checks      []check   `json:"checks"`
+}
+
+// minRSABits is the smallest RSA key tls-cert-profile preferred, OpenVPN's
+// default, accepts.
+const minRSABits = 2048
+
+func runVerify(c *cli, args []string) error {
+	var caFile, crlFile, role string
+	var rf requestFlags
+	fs := c.flags("table")
+	fs.StringVar(&caFile, "ca", "", "CA certificates to trust (default: the daemon's)")
+	fs.StringVar(&crlFile, "crl", "", "CRL file (default: the daemon's current CRL)")
+	fs.StringVar(&role, "role", "client", "the certificate is presented by a client|server (remote-cert-tls)")
+	fs.StringVar(&rf.caID, "ca-id", "", "check only against this CA of the daemon")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if len(c.args) != 1 {
+		return usageErr("one file required (a certificate, .ovpn or bundle zip; - for stdin)")
+	}
+	if role != "client" && role != "server" {
+		return usageErr("--role: want client or server")
+	}
+	objs, err := readObjects(c.args[0])
+	if err != nil {
+		return err
+	}
+	var leaf *x509.Certificate
+	for _, o := range objs {
+		if o.cert != nil && !o.cert.IsCA {
+			leaf = o.cert
+			break
+		}
+	}
+	if leaf == nil {
+		return fmt.Errorf("%s: no end-entity certificate found", c.args[0])
+	}
+
+	caPEM, crlPEM, err := c.trust(caFile, crlFile, rf)
+	if err != nil {
+		return err
+	}
+	var cas []*x509.Certificate
+	var crls []*x509.RevocationList
+	for _, src := range []struct{ name, pem string }{{"ca", caPEM}, {"crl", crlPEM}} {
+		objs, err := decodeObjects(src.name, []byte(src.pem))
+		if err != nil {
+			return err
+		}
+		for _, o := range objs {
+			if o.cert != nil {
+				cas = append(cas, o.cert)
+			}
+			if o.crl != nil {
+				crls = append(crls, o.crl)
+			}
+		}
+	}
+	if len(cas) == 0 {
+		return fmt.Errorf("no CA certificate to verify against")
+	}
+
+	rep := verifyReport{Certificate: describeCert(leaf), Role: role}
+	rep.Checks = verifyChecks(leaf, cas, crls, role, time.Now())
+	rep.Accepted = true
+	for _, ch := range rep.Checks {
+		if !ch.OK {
+			rep.Accepted, rep.Reason = false, ch.Detail
+			break
+		}
+	}
+
+	if c.output == "json" {
+		if err := printJSON(rep); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, 0, len(rep.Checks))
+		for _, ch := range rep.Checks {
+			rows = append(rows, []string{ch.Name, map[bool]string{true: "ok", false: "FAIL"}[ch.OK], ch.Detail})
+		}
+		if err := printFields("subject", rep.Certificate.Subject, "serial", rep.Certificate.Serial); err != nil {
+			return err
+		}
+		if err := printTable([]string{"CHECK", "RESULT", "DETAIL"}, rows); err != nil {
+			return err
+		}
+	}
+	if !rep.Accepted {
+		return rejectedErr(rep.Reason)
+	}
+	if c.output == "table" {
+		fmt.Println("accepted")
+	}
+	return nil
+}
+
+// trust returns the CA certificates and CRLs to verify against, read from the
+// given files or fetched with GET_CRL.
+func (c *cli) trust(caFile, crlFile string, rf requestFlags) (string, string, error) {
+	var caPEM, crlPEM string
+	if caFile == "" || crlFile == "" {
+		resp, err := c.client().Do(c.ctx, client.Request{Op: api.OpGetCRL}, rf.opts()...)
+		if err != nil {
+			return "", "", err
+		}
+		caPEM, crlPEM = resp.ChainPEM, resp.CRLPEM
+	}
+	if caFile != "" {
+		b, err := os.ReadFile(caFile)
+		if err != nil {
+			return "", "", err
+		}
+		caPEM = string(b)
+	}
+	if crlFile != "" {
+		b, err := os.ReadFile(crlFile)
+		if err != nil {
+			return "", "", err
+		}
+		crlPEM = string(b)
+	}
+	return caPEM, crlPEM, nil
+}
+
+func verifyChecks(leaf *x509.Certificate, cas []*x509.Certificate, crls []*x509.RevocationList, role string, now time.Time) []check {
+	var out []check
+	add := func(name string, ok bool, format string, a ...any) {
+		out = append(out, check{Name: name, OK: ok, Detail: fmt.Sprintf(format, a...)})
+	}
+
+	issuer, sameName := issuerOf(leaf, cas)
+	switch {
+	case issuer != nil:
+		add("issuer", true, "issued by %s", issuer.Subject)
+	case sameName != nil:
+		add("issuer", false, "wrong issuer: the signature does not verify with %s; a CA of that name but another key signed it", sameName.Subject)
+	default:
+		names := make([]string, 0, len(cas))
+		for _, ca := range cas {
+			names = append(names, ca.Subject.String())
+		}
+		add("issuer", false, "wrong issuer: issued by %s, which is not among the trusted CAs (%s)", leaf.Issuer, strings.Join(names, "; "))
+	}
+
+	if issuer != nil {
+		chain, ok := chainOf(issuer, cas)
+		var expired *x509.Certificate
+		for _, ca := range chain {
+			if now.After(ca.NotAfter) || now.Before(ca.NotBefore) {
+				expired = ca
+				break
+			}
+		}
+		switch {
+		case expired != nil:
+			add("chain", false, "CA %s is outside its validity %s .. %s", expired.Subject, day(expired.NotBefore), day(expired.NotAfter))
+		case !ok:
+			add("chain", false, "chain ends at %s, which is not self-signed; OpenVPN's --ca file needs the root too", chain[len(chain)-1].Subject)
+		default:
+			add("chain", true, "%d CA certificate(s) up to %s", len(chain), chain[len(chain)-1].Subject)
+		}
+	}
+
+	switch {
+	case now.Before(leaf.NotBefore):
+		add("validity", false, "not yet valid: valid from %s", day(leaf.NotBefore))
+	case now.After(leaf.NotAfter):
+		add("validity", false, "expired on %s (%d days ago)", day(leaf.NotAfter), int(now.Sub(leaf.NotAfter).Hours()/24))
+	default:
+		add("validity", true, "valid until %s (%d days left)", day(leaf.NotAfter), int(leaf.NotAfter.Sub(now).Hours()/24))
+	}
+
+	if issuer != nil {
+		var crl *x509.RevocationList
+		for _, l := range crls {
+			if bytes.Equal(l.RawIssuer, leaf.RawIssuer) && l.CheckSignatureFrom(issuer) == nil {
+				crl = l
+				break
+			}
+		}
+		switch {
+		case crl == nil:
+			add("revocation", false, "no CRL signed by %s; with crl-verify OpenVPN cannot check revocation and rejects the certificate", issuer.Subject)
+		case now.After(crl.NextUpdate):
+			add("revocation", false, "the CRL of %s expired on %s; OpenVPN rejects every certificate of this CA until it is re-signed", issuer.Subject, day(crl.NextUpdate))
+		default:
+			add("revocation", true, "not on the CRL (next update %s)", day(crl.NextUpdate))
+			for _, e := range crl.RevokedCertificateEntries {
+				if e.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+					continue
+				}
+				ch := &out[len(out)-1]
+				ch.OK = false
+				switch reason := crlReason(e); reason {
+				case pki.ReasonCertificateHold:
+					ch.Detail = fmt.Sprintf("on hold since %s (certificateHold)", day(e.RevocationTime))
+				case "":
+					ch.Detail = fmt.Sprintf("revoked on %s", day(e.RevocationTime))
+				default:
+					ch.Detail = fmt.Sprintf("revoked on %s, reason %s", day(e.RevocationTime), reason)
+				}
+			}
+		}
+	}
+
+	want, wantName := x509.ExtKeyUsageClientAuth, "clientAuth"
+	if role == "server" {
+		want, wantName = x509.ExtKeyUsageServerAuth, "serverAuth"
+	}
+	if slices.Contains(leaf.ExtKeyUsage, want) {
+		add("eku", true, "has %s", wantName)
+	} else {
+		has := strings.Join(pki.ExtKeyUsageNames(leaf.ExtKeyUsage), ", ")
+		if has == "" {
+			has = "none"
+		}
+		add("eku", false, "wrong EKU: has %s; remote-cert-tls %s requires %s", has, role, wantName)
+	}
+
+	switch {
+	case leaf.KeyUsage == 0:
+		add("key_usage", false, "no key usage extension; remote-cert-tls %s requires one", role)
+	case leaf.KeyUsage&(x509.KeyUsageDigitalSignature|x509.KeyUsageKeyAgreement) == 0:
+		add("key_usage", false, "key usage %s lacks digitalSignature and keyAgreement", strings.Join(pki.KeyUsageNames(leaf.KeyUsage), ", "))
+	default:
+		add("key_usage", true, "%s", strings.Join(pki.KeyUsageNames(leaf.KeyUsage), ", "))
+	}
+
+	key := publicKeyName(leaf.PublicKey)
+	switch k := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			add("key", false, "%s is below the %d bits tls-cert-profile preferred requires", key, minRSABits)
+			return out
+		}
+	}
+	switch leaf.SignatureAlgorithm {
+	case x509.MD5WithRSA, x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+		add("key", false, "signed with %s, which tls-cert-profile preferred rejects", leaf.SignatureAlgorithm)
+	default:
+		add("key", true, "%s, signed with %s", key, leaf.SignatureAlgorithm)
+	}
+	return out
+}
+
+// issuerOf finds the CA that signed cert. Without one, sameName is a CA whose
+// subject matches cert's issuer but whose key did not sign it.
+func issuerOf(cert *x509.Certificate, cas []*x509.Certificate) (issuer, sameName *x509.Certificate) {
+	for _, ca := range cas {
+		if !bytes.Equal(ca.RawSubject, cert.RawIssuer) {
+			continue
+		}
+		if cert.CheckSignatureFrom(ca) == nil {
+			return ca, nil
+		}
+		sameName = ca
+	}
+	return nil, sameName
+}
+
+// chainOf walks from ca up through cas; ok reports that it ends at a
+// self-signed root.
+func chainOf(ca *x509.Certificate, cas []*x509.Certificate) (chain []*x509.Certificate, ok bool) {
+	for len(chain) <= len(cas) {
+		chain = append(chain, ca)
+		if bytes.Equal(ca.RawSubject, ca.RawIssuer) && ca.CheckSignatureFrom(ca) == nil {
+			return chain, true
+		}
+		next, _ := issuerOf(ca, cas)
+		if next == nil {
+			return chain, false
+		}
+		ca = next
+	}
+	return chain, false
+}
+
+func day(t time.Time) string { return t.UTC().Format(time.RFC3339) }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/x509/pkix"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+)
+
+const caUsage = `usage:
+  vpn-certd ca init   [--pki DIR] [--ca-id ID] --cn NAME [--key-type T] [--pathlen N] [--encrypt-pass SRC]
+  vpn-certd ca import [--pki DIR] [--ca-id ID] --cert FILE --root FILE`
+
+// runCA implements the offline "ca" subcommands; it never talks to a running daemon.
+func runCA(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, caUsage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "init":
+		err = caInit(args[1:])
+	case "import":
+		err = caImport(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, caUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ca "+args[0]+":", err)
+		return 1
+	}
+	return 0
+}
+
+func caFlags(name string, pkiDir, caID *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("ca "+name, flag.ExitOnError)
+	fs.StringVar(pkiDir, "pki", getenv(constants.EnvPKIDir, constants.DefaultPKIDir), "PKI directory")
+	fs.StringVar(caID, "ca-id", "", "install as an additional CA under <pki>/cas/<id> (rollover)")
+	return fs
+}
+
+func caInit(args []string) error {
+	var pkiDir, caID, cn, org, keyType, passSrc, out string
+	var pathLen int
+	var force bool
+	fs := caFlags("init", &pkiDir, &caID)
+	fs.StringVar(&cn, "cn", "", "subject CN of the intermediate")
+	fs.StringVar(&org, "org", "", "subject O (optional)")
+	fs.StringVar(&keyType, "key-type", "ecdsa-p384", "key type: rsa3072|rsa4096|ecdsa-p256|ecdsa-p384")
+	fs.IntVar(&pathLen, "pathlen", 0, "basicConstraints pathlen")
+	fs.StringVar(&passSrc, "encrypt-pass", "", "encrypt the key with the passphrase from credential:NAME|fd:N|file:PATH")
+	fs.StringVar(&out, "csr-out", "", "also write the CSR here (default: stdout)")
+	fs.BoolVar(&force, "force", false, "replace an existing int-ca.key")
+	_ = fs.Parse(args)
+
+	dir, err := pki.CADir(pkiDir, caID)
+	if err != nil {
+		return err
+	}
+	opts := pki.InitOptions{KeyType: keyType, Subject: pkix.Name{CommonName: cn}, PathLen: pathLen, Force: force}
+	if org != "" {
+		opts.Subject.Organization = []string{org}
+	}
+	if passSrc != "" {
+		if opts.Passphrase, err = security.ReadSecret(passSrc); err != nil {
+			return fmt.Errorf("passphrase: %w", err)
+		}
+		if len(opts.Passphrase) == 0 {
+			return fmt.Errorf("passphrase: empty")
+		}
+	}
+	csr, err := pki.InitIntermediate(dir, opts)
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		if err := os.WriteFile(out, csr, 0o644); err != nil {
+			return err
+		}
+	} else {
+		os.Stdout.Write(csr)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s and %s; sign the CSR on the offline root, then run 'vpn-certd ca import'\n",
+		filepath.Join(dir, "int-ca.key"), filepath.Join(dir, "int-ca.csr"))
+	return nil
+}
+
+func caImport(args []string) error {
+	var pkiDir, caID, certPath, rootPath string
+	fs := caFlags("import", &pkiDir, &caID)
+	fs.StringVar(&certPath, "cert", "", "signed intermediate certificate (PEM, may include further intermediates)")
+	fs.StringVar(&rootPath, "root", "", "offline root certificate (PEM)")
+	_ = fs.Parse(args)
+	if certPath == "" || rootPath == "" {
+		return fmt.Errorf("--cert and --root are required")
+	}
+
+	dir, err := pki.CADir(pkiDir, caID)
+	if err != nil {
+		return err
+	}
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return err
+	}
+	rootPEM, err := os.ReadFile(rootPath)
+	if err != nil {
+		return err
+	}
+	cert, err := pki.ImportIntermediate(dir, certPEM, rootPEM)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "installed %s (subject %q, expires %s)\n",
+		filepath.Join(dir, "int-ca.crt"), cert.Subject.String(), cert.NotAfter.UTC().Format(time.RFC3339))
+	return nil
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
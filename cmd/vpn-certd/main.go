@@ -2,68 +2,288 @@ package main
 
 import (
 	"context"
-	"io/ioutil"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
-	"regexp"
+	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/HarounAhmad/vpn-certd/internal/app"
+	"github.com/HarounAhmad/vpn-certd/internal/bundle"
 	"github.com/HarounAhmad/vpn-certd/internal/config"
 	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/hsm"
+	"github.com/HarounAhmad/vpn-certd/internal/idempotency"
 	"github.com/HarounAhmad/vpn-certd/internal/logging"
 	"github.com/HarounAhmad/vpn-certd/internal/pki"
 	"github.com/HarounAhmad/vpn-certd/internal/policy"
 	"github.com/HarounAhmad/vpn-certd/internal/security"
+	"github.com/HarounAhmad/vpn-certd/internal/tlscrypt"
 	"github.com/HarounAhmad/vpn-certd/pkg/version"
 )
 
 func main() {
-	cfg := config.Load()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "ca":
+			os.Exit(runCA(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		}
+	}
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		os.Exit(2)
+	}
 	log := logging.New(cfg.LogLevel)
 	log.Info("starting", "name", version.Name, "version", version.Version, "commit", version.Commit)
+	for _, w := range cfg.Warnings {
+		log.Warn("config", slog.String("warning", w))
+	}
 
 	if err := security.EnsureSocketDir(cfg.SocketPath); err != nil {
 		log.Error("socket_dir", slog.String("err", err.Error()))
 		os.Exit(2)
 	}
 
-	ca, err := pki.LoadCA(cfg.PKIDir, cfg.StateDir)
+	tenants, err := cfg.LoadTenants()
 	if err != nil {
-		log.Error("load_ca", slog.String("err", err.Error()))
+		log.Error("tenants", slog.String("err", err.Error()))
 		os.Exit(2)
 	}
 
-	pol, err := policy.Load(cfg.PolicyPath)
+	ctx, cancel := context.WithCancel(context.Background())
+	a := app.New(log)
+	a.Default = tenants.Default
+	a.Deadline = cfg.ReadWriteDeadline
+	tmpls, err := bundle.LoadTemplates(cfg.TemplateDir)
 	if err != nil {
-		log.Error("policy_load", slog.String("err", err.Error()))
+		log.Error("templates", slog.String("err", err.Error()))
 		os.Exit(2)
 	}
-	re, _ := regexp.Compile(pol.CNPattern)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	a := app.New(log)
-	a.CA = ca
-	a.Policy = pol
-	a.CRLOut = cfg.CRLOutPath
-	a.SetCNPattern(re)
-	ta := ""
-	if b, err := ioutil.ReadFile(cfg.TAPath); err == nil {
-		ta = string(b)
-	}
-	a.TAKey = ta
+	log.Info("templates_loaded", "dir", cfg.TemplateDir, "names", tmpls.Names())
+	pass := &startupPass{cfg: cfg}
+	for _, tc := range tenants.Tenants {
+		t, err := loadTenant(cfg, tc, tmpls)
+		if err != nil {
+			log.Error("load_tenant", slog.String("tenant", tc.Name), slog.String("err", err.Error()))
+			os.Exit(2)
+		}
+		if err := a.AddTenant(t); err != nil {
+			log.Error("load_tenant", slog.String("tenant", tc.Name), slog.String("err", err.Error()))
+			os.Exit(2)
+		}
+		for _, ca := range t.CAs.All() {
+			if ca.Locked() {
+				unlockAtStartup(t, pass, log)
+				break
+			}
+		}
+		t.Log.Info("ca_loaded", "active", t.CAs.Active().ID, "count", len(t.CAs.All()))
+		if len(t.CAs.Pending) > 0 {
+			t.Log.Info("ca_pending", "ids", t.CAs.Pending)
+		}
+		if t.TLSCrypt != nil {
+			t.Log.Info("tls_crypt_v2", "server_key", t.TLSCrypt.ServerKeyPath())
+			t.DeployTLSCryptRevoked(ctx)
+		}
+	}
+	if cfg.MetricsAddr != "" {
+		if err := a.StartMetrics(ctx, cfg.MetricsAddr); err != nil {
+			log.Error("start_metrics", slog.String("err", err.Error()))
+			os.Exit(2)
+		}
+	}
 	if err := a.StartServer(ctx, cfg.SocketPath); err != nil {
 		log.Error("start_server", slog.String("err", err.Error()))
 		os.Exit(2)
 	}
+	if cfg.HTTPSocket != "" {
+		if err := a.StartHTTP(ctx, cfg.HTTPSocket); err != nil {
+			log.Error("start_http", slog.String("err", err.Error()))
+			os.Exit(2)
+		}
+	}
+	if cfg.GRPCSocket != "" {
+		if err := a.StartGRPC(ctx, cfg.GRPCSocket); err != nil {
+			log.Error("start_grpc", slog.String("err", err.Error()))
+			os.Exit(2)
+		}
+	}
+	a.StartBackground(ctx)
 
 	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	<-sigs
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigs {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		tmpls, err := bundle.LoadTemplates(cfg.TemplateDir)
+		if err == nil {
+			err = a.SetTemplates(tmpls)
+		}
+		if err != nil {
+			log.Error("templates_reload", slog.String("err", err.Error()))
+		} else {
+			log.Info("templates_loaded", "dir", cfg.TemplateDir, "names", tmpls.Names())
+		}
+		if err := a.ReloadPolicies(); err != nil {
+			log.Error("policy_reload", slog.String("err", err.Error()))
+		}
+	}
 
 	cancel()
-	time.Sleep(constants.ShutdownTimeout)
+	time.Sleep(cfg.ShutdownTimeout)
 	log.Info("stopped")
 }
+
+func loadTenant(cfg config.Config, tc config.Tenant, tmpls *bundle.Templates) (*app.Tenant, error) {
+	cas, err := pki.LoadSet(tc.PKIDir, tc.StateDir, tc.ActiveCA, func(id, pkiDir, stateDir string) (*pki.CA, error) {
+		return loadCA(cfg, tc.Name, id, pkiDir, stateDir)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, ca := range cas.All() {
+		ca.CRLValidity = cfg.CRLValidity
+	}
+	pol, err := policy.Load(tc.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	t := &app.Tenant{
+		Name:        tc.Name,
+		CAs:         cas,
+		Policy:      pol,
+		PolicyPath:  tc.Policy,
+		CRLOut:      tc.CRLOut,
+		CRLMode:     tc.CRLMode,
+		PEMCacheDir: tc.PEMCache,
+		Templates:   tmpls,
+	}
+	if cfg.IdempotencyRetention > 0 {
+		t.Idempotency = idempotency.New(filepath.Join(tc.StateDir, "requests"), cfg.IdempotencyRetention)
+	}
+	if b, err := os.ReadFile(tc.TAPath); err == nil {
+		t.TAKey = string(b)
+	}
+	if tc.TLSCryptV2 {
+		if t.TLSCrypt, err = tlscrypt.Open(filepath.Join(tc.StateDir, constants.TLSCryptV2Dir), tc.Name); err != nil {
+			return nil, fmt.Errorf("tls-crypt-v2: %w", err)
+		}
+		t.TLSCryptRevokedOut = tc.TLSCryptV2RevokedOut
+	}
+	return t, nil
+}
+
+// runConfig implements "config print": it resolves flags, environment and config
+// file exactly as the daemon would and prints the result.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: vpn-certd config print [flags]")
+		return 2
+	}
+	cfg, err := config.Load(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		return 2
+	}
+	fmt.Printf("# config file: %s (%s)\n", cfg.ConfigPath, cfg.Source("config"))
+	for _, w := range cfg.Warnings {
+		fmt.Printf("# warning: %s\n", w)
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// loadCA loads one intermediate. On a token the key label is <pkcs11-key-label>,
+// suffixed with -<tenant> outside the default tenant and -<id> for rollover CAs.
+func loadCA(cfg config.Config, tenant, id, pkiDir, stateDir string) (*pki.CA, error) {
+	switch cfg.KeyBackend {
+	case constants.KeyBackendFile:
+		return pki.LoadCA(pkiDir, stateDir)
+	case constants.KeyBackendPKCS11:
+		cert, err := pki.ReadCACert(pkiDir)
+		if err != nil {
+			return nil, err
+		}
+		label := cfg.PKCS11KeyLabel
+		if tenant != config.DefaultTenant {
+			label += "-" + tenant
+		}
+		if id != pki.DefaultCAID {
+			label += "-" + id
+		}
+		pin, err := security.ReadSecret(cfg.PKCS11PIN)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs11 pin: %w", err)
+		}
+		signer, err := hsm.Open(hsm.Config{
+			Module:     cfg.PKCS11Module,
+			TokenLabel: cfg.PKCS11Token,
+			Slot:       cfg.PKCS11Slot,
+			KeyLabel:   label,
+			PIN:        pin,
+		}, cert.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return pki.LoadCAWithSigner(pkiDir, stateDir, signer)
+	default:
+		return nil, fmt.Errorf("unknown key backend %q", cfg.KeyBackend)
+	}
+}
+
+// unlockAtStartup tries the non-interactive passphrase sources in turn until the
+// active CA is unlocked. Otherwise the tenant stays locked until an UNLOCK
+// request arrives.
+func unlockAtStartup(t *app.Tenant, pass *startupPass, log *slog.Logger) {
+	for _, src := range pass.sources(log) {
+		if t.UnlockCA(context.Background(), src.pass, src.name) == nil {
+			return
+		}
+	}
+	t.Log.Warn("ca_locked", slog.String("hint", "send UNLOCK with the CA key passphrase"))
+}
+
+type passSource struct {
+	name string
+	pass []byte
+}
+
+// startupPass reads the credential and --ca-key-pass-fd once, on first use, so
+// every tenant can try them.
+type startupPass struct {
+	cfg  config.Config
+	once sync.Once
+	list []passSource
+}
+
+func (p *startupPass) sources(log *slog.Logger) []passSource {
+	p.once.Do(func() {
+		if pass, err := security.ReadCredential(p.cfg.CAKeyCred); err == nil {
+			p.list = append(p.list, passSource{"credential", pass})
+		} else if !errors.Is(err, security.ErrNoCredential) {
+			log.Warn("ca_credential", slog.String("err", err.Error()))
+		}
+		if p.cfg.CAKeyPassFD >= 0 {
+			if pass, err := security.ReadFD(p.cfg.CAKeyPassFD); err != nil {
+				log.Warn("ca_pass_fd", slog.String("err", err.Error()))
+			} else {
+				p.list = append(p.list, passSource{"fd", pass})
+			}
+		}
+	})
+	return p.list
+}
@@ -3,5 +3,16 @@ module github.com/HarounAhmad/vpn-certd
 go 1.24
 
 require (
+	github.com/miekg/pkcs11 v1.1.2
+	golang.org/x/term v0.32.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7
+	google.golang.org/grpc v1.75.1
+	google.golang.org/protobuf v1.36.6
 	gopkg.in/yaml.v3 v3.0.1
-)
\ No newline at end of file
+)
+
+require (
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+)
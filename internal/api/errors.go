@@ -0,0 +1,26 @@
+package api
+
+import "github.com/HarounAhmad/vpn-certd/internal/xerr"
+
+// Error is the machine-readable form of a failed request. Code is an xerr.Code
+// (bad_request, forbidden, not_found, conflict, internal_error,
+// not_implemented); Field names the request field at fault, if any; Retryable
+// says the same request may succeed later. Internal causes are never included.
+type Error struct {
+	Code      string `json:"code"`
+	Field     string `json:"field,omitempty"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+// ErrorOf is the client view of err.
+func ErrorOf(err error) *Error {
+	e := xerr.Public(err)
+	return &Error{Code: string(e.Code), Field: e.Field, Message: e.Msg, Retryable: e.Retry}
+}
+
+// Fail records err on r, in Err and as the "code: message" string in Error.
+func (r *Response) Fail(err error) {
+	r.Err = ErrorOf(err)
+	r.Error = xerr.Public(err).Error()
+}
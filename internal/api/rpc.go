@@ -0,0 +1,76 @@
+package api
+
+import "encoding/json"
+
+// JSONRPCVersion is the "jsonrpc" member of every protocol 2 message.
+const JSONRPCVersion = "2.0"
+
+// JSON-RPC 2.0 error codes. Daemon errors use RPCServerError, or
+// RPCInvalidParams for bad_request, with the daemon code in Data.
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCServerError    = -32000
+)
+
+// Protocol 2 methods besides the ops, which are called by name (e.g. "SIGN")
+// with the remaining Request fields as params.
+const (
+	// RPCCancel stops the in-flight call whose id is given in params,
+	// typically a SUBSCRIBE.
+	RPCCancel = "rpc.cancel"
+	// RPCEvent is the notification carrying one message of a streaming call.
+	RPCEvent = "event"
+)
+
+// RPCRequest is a JSON-RPC 2.0 call. A call without ID is a notification and
+// gets no response.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse answers an RPCRequest; exactly one of Result and Error is set.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  *Response       `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+type RPCError struct {
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    *RPCErrorData `json:"data,omitempty"`
+}
+
+// RPCErrorData carries the daemon error (see Error) and, when the operation was
+// committed before the error, the response it produced.
+type RPCErrorData struct {
+	Code      string    `json:"code"`
+	Field     string    `json:"field,omitempty"`
+	Retryable bool      `json:"retryable,omitempty"`
+	Response  *Response `json:"response,omitempty"`
+}
+
+// RPCNotification is a server-to-client message for a streaming call.
+type RPCNotification struct {
+	JSONRPC string     `json:"jsonrpc"`
+	Method  string     `json:"method"`
+	Params  RPCMessage `json:"params"`
+}
+
+// RPCMessage is one streamed value (an Event) for the call with ID.
+type RPCMessage struct {
+	ID    json.RawMessage `json:"id"`
+	Event any             `json:"event"`
+}
+
+// RPCCancelParams are the params of rpc.cancel.
+type RPCCancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
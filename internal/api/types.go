@@ -10,8 +10,56 @@ const (
 	OpGetCRL        Op = "GET_CRL"
 	OpListIssued    Op = "LIST_ISSUED"
 	OpBuildBundle   Op = "BUILD_BUNDLE"
+	OpExpiring      Op = "EXPIRING"
+	OpSubscribe     Op = "SUBSCRIBE"
+	OpStatus        Op = "STATUS"
+	OpUnlock        Op = "UNLOCK"
+	OpHello         Op = "HELLO"
+	OpGetCert       Op = "GET_CERT"
+	OpBatch         Op = "BATCH"
+	OpRevokeTLSKey  Op = "REVOKE_TLS_KEY"
 )
 
+// Ops lists every op the daemon understands, in HELLO order.
+var Ops = []Op{
+	OpHello, OpHealth, OpStatus, OpUnlock, OpSign, OpGenKeyAndSign, OpRevoke,
+	OpRevokeTLSKey, OpGetCert, OpGetCRL, OpListIssued, OpBuildBundle, OpBatch, OpExpiring, OpSubscribe,
+}
+
+// Known reports whether o is in Ops.
+func (o Op) Known() bool {
+	for _, k := range Ops {
+		if k == o {
+			return true
+		}
+	}
+	return false
+}
+
+// Wire protocol versions. Version 1 is one newline-terminated JSON request and
+// response per connection; version 2 is JSON-RPC 2.0 with any number of
+// pipelined calls per connection. Version 3 is the gRPC service, served on its
+// own socket.
+const (
+	ProtocolLegacy  = 1
+	ProtocolJSONRPC = 2
+	ProtocolGRPC    = 3
+)
+
+// Hello answers HELLO. Fields lists the request fields this daemon accepts;
+// requests with any other field are rejected, so clients can check before
+// relying on a newer one.
+type Hello struct {
+	Server    string   `json:"server"`
+	Protocol  int      `json:"protocol"`
+	Protocols []int    `json:"protocols"`
+	Ops       []Op     `json:"ops"`
+	Fields    []string `json:"fields"`
+	// Idempotency reports that request_id retries are answered from stored
+	// responses (idempotency_retention > 0).
+	Idempotency bool `json:"idempotency,omitempty"`
+}
+
 type Profile string
 
 const (
@@ -32,6 +80,139 @@ type IssuedMeta struct {
 	Profile  string `json:"profile"`
 	NotAfter string `json:"not_after"`
 	SHA256   string `json:"sha256"`
+	CAID     string `json:"ca_id,omitempty"`
+}
+
+// CertInfo answers GET_CERT: the issuance record of one serial and whether it
+// is revoked.
+type CertInfo struct {
+	IssuedMeta
+	Revoked          bool   `json:"revoked"`
+	RevocationReason string `json:"revocation_reason,omitempty"`
+	RevokedAt        string `json:"revoked_at,omitempty"`
+}
+
+type ExpiringMeta struct {
+	CAID       string `json:"ca_id,omitempty"`
+	Serial     string `json:"serial"`
+	CN         string `json:"cn"`
+	Profile    string `json:"profile"`
+	NotAfter   string `json:"not_after"`
+	DaysLeft   int    `json:"days_left"`
+	WindowDays int    `json:"window_days"`
+}
+
+type EventType string
+
+const (
+	EventIssued         EventType = "issued"
+	EventRevoked        EventType = "revoked"
+	EventCRLRegenerated EventType = "crl_regenerated"
+	EventPolicyReloaded EventType = "policy_reloaded"
+	EventCAExpiring     EventType = "ca_expiring"
+	EventHeartbeat      EventType = "heartbeat"
+)
+
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	Type     EventType `json:"type"`
+	Time     string    `json:"time"`
+	CN       string    `json:"cn,omitempty"`
+	Serial   string    `json:"serial,omitempty"`
+	CAID     string    `json:"ca_id,omitempty"`
+	Profile  string    `json:"profile,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	NotAfter string    `json:"not_after,omitempty"`
+	DaysLeft int       `json:"days_left,omitempty"`
+}
+
+type Verdict string
+
+const (
+	VerdictOK       Verdict = "ok"
+	VerdictDegraded Verdict = "degraded"
+	VerdictFailing  Verdict = "failing"
+)
+
+type CAStatus struct {
+	ID         string `json:"id"`
+	Active     bool   `json:"active"`
+	Subject    string `json:"subject"`
+	NotAfter   string `json:"not_after"`
+	DaysLeft   int    `json:"days_left"`
+	KeyMatches bool   `json:"key_matches"`
+	Locked     bool   `json:"locked"`
+}
+
+type CRLStatus struct {
+	ThisUpdate string `json:"this_update,omitempty"`
+	NextUpdate string `json:"next_update,omitempty"`
+	AgeSeconds int64  `json:"age_seconds"`
+	DeployPath string `json:"deploy_path,omitempty"`
+	Deployed   bool   `json:"deployed"`
+	InSync     bool   `json:"in_sync"`
+}
+
+type StorageStatus struct {
+	StateDir  string `json:"state_dir"`
+	Writable  bool   `json:"writable"`
+	FreeBytes uint64 `json:"free_bytes"`
+}
+
+type PolicyStatus struct {
+	Version string `json:"version,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+type StatusReport struct {
+	Verdict       Verdict        `json:"verdict"`
+	Tenant        string         `json:"tenant,omitempty"`
+	Problems      []string       `json:"problems,omitempty"`
+	Version       string         `json:"version"`
+	StartedAt     string         `json:"started_at"`
+	UptimeSeconds int64          `json:"uptime_seconds"`
+	CA            *CAStatus      `json:"ca,omitempty"`
+	CAs           []CAStatus     `json:"cas,omitempty"`
+	CRL           *CRLStatus     `json:"crl,omitempty"`
+	Storage       *StorageStatus `json:"storage,omitempty"`
+	TAKeyPresent  bool           `json:"ta_key_present"`
+	TLSCryptV2    bool           `json:"tls_crypt_v2,omitempty"`
+	Policy        PolicyStatus   `json:"policy"`
+}
+
+// CertPreview is the certificate a dry-run SIGN or GENKEY_AND_SIGN would issue.
+type CertPreview struct {
+	Subject      string   `json:"subject"`
+	Issuer       string   `json:"issuer"`
+	CAID         string   `json:"ca_id,omitempty"`
+	Profile      Profile  `json:"profile"`
+	KeyType      string   `json:"key_type,omitempty"`
+	SANs         []string `json:"sans,omitempty"`
+	KeyUsage     []string `json:"key_usage"`
+	ExtKeyUsage  []string `json:"ext_key_usage"`
+	IsCA         bool     `json:"is_ca"`
+	NotBefore    string   `json:"not_before"`
+	NotAfter     string   `json:"not_after"`
+	ValidityDays int      `json:"validity_days"`
+}
+
+// Revocation effects reported by a dry-run REVOKE.
+const (
+	EffectRevoke      = "revoke"       // serial is added to the CRL
+	EffectUpgradeHold = "upgrade_hold" // an existing hold becomes a permanent revocation
+	EffectNoop        = "noop"         // already revoked; only the CRL is re-signed
+)
+
+// RevokePreview is what a dry-run REVOKE would change.
+type RevokePreview struct {
+	Serial     string `json:"serial"`
+	CAID       string `json:"ca_id,omitempty"`
+	CN         string `json:"cn,omitempty"`
+	Known      bool   `json:"known"`
+	Current    string `json:"current_reason,omitempty"`
+	Reason     string `json:"reason"`
+	Effect     string `json:"effect"`
+	CRLEntries int    `json:"crl_entries"`
 }
 
 type BundleReq struct {
@@ -40,27 +221,94 @@ type BundleReq struct {
 	RemoteHost string `json:"remote_host"`
 	RemotePort int    `json:"remote_port"`
 	Proto      string `json:"proto"`
+	// Template names the profile template; empty uses the policy's choice for
+	// the certificate's profile, else the default.
+	Template string `json:"template,omitempty"`
 }
 
-type Request struct {
-	Op         Op         `json:"op"`
-	CN         string     `json:"cn,omitempty"`
+// BatchItem is one row of a BATCH: a GENKEY_AND_SIGN for CN and, when Bundle
+// is set, a BUILD_BUNDLE of the new certificate (Bundle.CN is ignored). Empty
+// Profile, KeyType and Passphrase fall back to the BATCH request's.
+type BatchItem struct {
+	CN         string     `json:"cn"`
 	Profile    Profile    `json:"profile,omitempty"`
 	KeyType    KeyType    `json:"key_type,omitempty"`
 	Passphrase string     `json:"passphrase,omitempty"`
-	CSRPEM     string     `json:"csr,omitempty"`
-	Serial     string     `json:"serial,omitempty"`
-	Reason     string     `json:"reason,omitempty"`
 	Bundle     *BundleReq `json:"bundle,omitempty"`
+	// RequestID is the row's idempotency key; empty derives
+	// "<request_id>:<cn>" from the BATCH request_id, if any.
+	RequestID string `json:"request_id,omitempty"`
 }
 
-type Response struct {
-	CertPEM   string       `json:"cert_pem,omitempty"`
-	KeyPEMEnc string       `json:"key_pem_encrypted,omitempty"`
-	CRLPEM    string       `json:"crl_pem,omitempty"`
+// BatchResult reports one BatchItem; results are in item order.
+type BatchResult struct {
+	CN        string       `json:"cn"`
+	RequestID string       `json:"request_id,omitempty"`
 	Serial    string       `json:"serial,omitempty"`
+	CAID      string       `json:"ca_id,omitempty"`
 	NotAfter  string       `json:"not_after,omitempty"`
-	Issued    []IssuedMeta `json:"issued,omitempty"`
+	CertPEM   string       `json:"cert_pem,omitempty"`
+	KeyPEMEnc string       `json:"key_pem_encrypted,omitempty"`
 	ZipB64    string       `json:"zip_b64,omitempty"`
-	Error     string       `json:"err,omitempty"`
+	Preview   *CertPreview `json:"preview,omitempty"`
+	Replayed  bool         `json:"replayed,omitempty"`
+	// HooksFailed is Response.HooksFailed of the row.
+	HooksFailed []string `json:"hooks_failed,omitempty"`
+	Err         *Error   `json:"error,omitempty"`
+}
+
+type Request struct {
+	Op         Op          `json:"op"`
+	Tenant     string      `json:"tenant,omitempty"`
+	CN         string      `json:"cn,omitempty"`
+	Profile    Profile     `json:"profile,omitempty"`
+	KeyType    KeyType     `json:"key_type,omitempty"`
+	Passphrase string      `json:"passphrase,omitempty"`
+	CSRPEM     string      `json:"csr,omitempty"`
+	Serial     string      `json:"serial,omitempty"`
+	Reason     string      `json:"reason,omitempty"`
+	Bundle     *BundleReq  `json:"bundle,omitempty"`
+	Days       int         `json:"days,omitempty"`
+	SinceSeq   uint64      `json:"since_seq,omitempty"`
+	CAID       string      `json:"ca_id,omitempty"`
+	DryRun     bool        `json:"dry_run,omitempty"`
+	Items      []BatchItem `json:"items,omitempty"`
+	// RequestID is an optional client-chosen idempotency key: a retried SIGN,
+	// GENKEY_AND_SIGN, REVOKE or REVOKE_TLS_KEY with the same ID
+	// returns the stored response instead of running again.
+	RequestID string `json:"request_id,omitempty"`
+	// Protocols are the versions a HELLO caller speaks; empty accepts any.
+	Protocols []int `json:"protocols,omitempty"`
+}
+
+type Response struct {
+	CertPEM   string         `json:"cert_pem,omitempty"`
+	ChainPEM  string         `json:"chain_pem,omitempty"`
+	KeyPEMEnc string         `json:"key_pem_encrypted,omitempty"`
+	CRLPEM    string         `json:"crl_pem,omitempty"`
+	Serial    string         `json:"serial,omitempty"`
+	CAID      string         `json:"ca_id,omitempty"`
+	NotAfter  string         `json:"not_after,omitempty"`
+	Issued    []IssuedMeta   `json:"issued,omitempty"`
+	Cert      *CertInfo      `json:"cert,omitempty"`
+	ZipB64    string         `json:"zip_b64,omitempty"`
+	TLSKeyID  string         `json:"tls_key_id,omitempty"`
+	Expiring  []ExpiringMeta `json:"expiring,omitempty"`
+	Batch     []BatchResult  `json:"batch,omitempty"`
+	Status    *StatusReport  `json:"status,omitempty"`
+	DryRun    bool           `json:"dry_run,omitempty"`
+	Preview   *CertPreview   `json:"preview,omitempty"`
+	Revoke    *RevokePreview `json:"revoke_preview,omitempty"`
+	Hello     *Hello         `json:"hello,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	// Replayed marks a response returned from the idempotency store. Keys are
+	// never stored, so a replayed GENKEY_AND_SIGN has no key_pem_encrypted.
+	Replayed bool `json:"replayed,omitempty"`
+	// HooksFailed lists the required hooks, as "<event>:<name>", that failed
+	// after the operation was committed; the operation itself succeeded.
+	HooksFailed []string `json:"hooks_failed,omitempty"`
+	// Err describes a failure; Error repeats it as "code: message" for
+	// clients that predate Err.
+	Err   *Error `json:"error,omitempty"`
+	Error string `json:"err,omitempty"`
 }
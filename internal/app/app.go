@@ -2,238 +2,1005 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"github.com/HarounAhmad/vpn-certd/internal/bundle"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/HarounAhmad/vpn-certd/internal/api"
 	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/expiry"
+	"github.com/HarounAhmad/vpn-certd/internal/hooks"
+	"github.com/HarounAhmad/vpn-certd/internal/idempotency"
+	"github.com/HarounAhmad/vpn-certd/internal/logging"
+	"github.com/HarounAhmad/vpn-certd/internal/metrics"
 	"github.com/HarounAhmad/vpn-certd/internal/pki"
 	"github.com/HarounAhmad/vpn-certd/internal/policy"
+	"github.com/HarounAhmad/vpn-certd/internal/renew"
 	"github.com/HarounAhmad/vpn-certd/internal/security"
+	"github.com/HarounAhmad/vpn-certd/internal/server/grpcapi"
+	"github.com/HarounAhmad/vpn-certd/internal/server/httpapi"
 	"github.com/HarounAhmad/vpn-certd/internal/server/unixjson"
+	"github.com/HarounAhmad/vpn-certd/internal/tlscrypt"
 	"github.com/HarounAhmad/vpn-certd/internal/validate"
 	"github.com/HarounAhmad/vpn-certd/internal/xerr"
 )
 
 type App struct {
-	Log       *slog.Logger
-	CA        *pki.CA
-	Policy    policy.Policy
-	cnPattern *regexp.Regexp
-	CRLOut    string
-	TAKey     string
+	Log     *slog.Logger
+	Metrics *metrics.Registry
+	// Default serves requests without a tenant; empty makes the field mandatory
+	// once several tenants are configured.
+	Default string
+	// Deadline bounds each socket read/write; zero keeps the server default.
+	Deadline time.Duration
+
+	tenants map[string]*Tenant
+	names   []string
+	bgCtx   context.Context
+	started time.Time
+}
+
+// Tenant is one OpenVPN instance served by the daemon: its CAs, policy, CRL
+// deployment, TA key, event stream and background workers.
+type Tenant struct {
+	Name       string
+	Log        *slog.Logger
+	CAs        *pki.Set
+	Policy     policy.Policy
+	PolicyPath string
+	cnPattern  *regexp.Regexp
+	CRLOut     string
+	CRLMode    string
+	TAKey      string
+	// TLSCrypt hands out per-certificate tls-crypt-v2 client keys; nil means
+	// bundles carry the shared TAKey.
+	TLSCrypt *tlscrypt.Store
+	// TLSCryptRevokedOut receives the refused client key list; empty disables.
+	TLSCryptRevokedOut string
+	// Templates render bundle profiles; nil leaves only the built-in one.
+	Templates *bundle.Templates
+	// PEMCacheDir holds issued PEMs for BUILD_BUNDLE; empty means <state>/.pemcache.
+	PEMCacheDir string
+	// Idempotency stores responses by request_id; nil disables replay.
+	Idempotency *idempotency.Store
+
+	mu       sync.RWMutex
+	bus      *EventBus
+	busOnce  sync.Once
+	bgCancel context.CancelFunc
+	started  time.Time
+	// renewals issued but not deployed, kept across policy reloads
+	renewPending renew.Pending
 }
 
-func New(log *slog.Logger) *App { return &App{Log: log} }
+func New(log *slog.Logger) *App {
+	return &App{Log: log, tenants: map[string]*Tenant{}, started: time.Now()}
+}
+
+// AddTenant registers t under t.Name.
+func (a *App) AddTenant(t *Tenant) error {
+	if err := validate.Tenant(t.Name); err != nil {
+		return fmt.Errorf("tenant %q: invalid name", t.Name)
+	}
+	if _, ok := a.tenants[t.Name]; ok {
+		return fmt.Errorf("tenant %q: duplicate", t.Name)
+	}
+	re, err := regexp.Compile(t.Policy.CNPattern)
+	if err != nil {
+		return fmt.Errorf("tenant %q: cn_pattern: %w", t.Name, err)
+	}
+	if err := checkTemplates(t.Templates, t.Policy); err != nil {
+		return fmt.Errorf("tenant %q: %w", t.Name, err)
+	}
+	t.cnPattern = re
+	t.Log = a.Log.With("tenant", t.Name)
+	t.started = a.started
+	a.tenants[t.Name] = t
+	a.names = append(a.names, t.Name)
+	return nil
+}
+
+func (a *App) Tenants() []*Tenant {
+	out := make([]*Tenant, 0, len(a.names))
+	for _, n := range a.names {
+		out = append(out, a.tenants[n])
+	}
+	return out
+}
 
-func (a *App) SetCNPattern(r *regexp.Regexp) { a.cnPattern = r }
+// tenant resolves the tenant named in a request.
+func (a *App) tenant(name string) (*Tenant, error) {
+	if name == "" {
+		name = a.Default
+		if name == "" && len(a.names) == 1 {
+			name = a.names[0]
+		}
+		if name == "" {
+			return nil, xerr.Invalid("tenant")
+		}
+	}
+	t, ok := a.tenants[name]
+	if !ok {
+		return nil, xerr.NotFoundErr("tenant")
+	}
+	return t, nil
+}
 
 func (a *App) Handler() unixjson.Handler { return a }
 
-func (a *App) Handle(_ context.Context, req api.Request) (api.Response, error) {
+func (a *App) Handle(ctx context.Context, req api.Request) (api.Response, error) {
+	return a.serve(ctx, req, func(ctx context.Context, t *Tenant) (api.Response, error) {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return t.idempotent(ctx, req)
+	})
+}
+
+// serve gives run what every request gets: a validated or generated
+// request_id, the tenant, the request metric and the request log line.
+func (a *App) serve(ctx context.Context, req api.Request, run func(context.Context, *Tenant) (api.Response, error)) (api.Response, error) {
+	start := time.Now()
+	var resp api.Response
+	var err error
+	id := req.RequestID
+	if id != "" && validate.RequestID(id) != nil {
+		err, id = xerr.Invalid("request_id"), ""
+	}
+	if id == "" {
+		id = logging.NewRequestID()
+	}
+	ctx = logging.WithRequestID(ctx, id)
+	log := logging.With(ctx, a.Log)
+
+	var t *Tenant
+	if err == nil {
+		t, err = a.tenant(req.Tenant)
+	}
+	if err == nil {
+		log = logging.With(ctx, t.Log)
+		resp, err = run(ctx, t)
+	}
+	resp.RequestID = id
+	if a.Metrics != nil {
+		a.Metrics.ObserveRequest(req.Op, err, time.Since(start))
+	}
+	lvl := slog.LevelDebug
+	if idempotency.Applies(req.Op) {
+		lvl = slog.LevelInfo
+	}
+	attrs := []any{"op", string(req.Op), "code", string(xerr.CodeOf(err)),
+		"replayed", resp.Replayed, "duration_ms", time.Since(start).Milliseconds()}
+	if err != nil {
+		// the full cause is logged here; clients only get xerr.Public
+		attrs = append(attrs, "err", xerr.Detail(err))
+		if xerr.CodeOf(err) == xerr.Internal {
+			lvl = slog.LevelError
+		}
+	}
+	log.Log(ctx, lvl, "request", attrs...)
+	return resp, err
+}
+
+// idempotent runs req through the tenant's idempotency store when it carries a
+// client request_id: a retry gets the stored response of the first attempt, and
+// a retry arriving while the first attempt still runs waits for it.
+func (t *Tenant) idempotent(ctx context.Context, req api.Request) (api.Response, error) {
+	if t.Idempotency == nil || req.RequestID == "" || req.DryRun || !idempotency.Applies(req.Op) {
+		return t.handle(ctx, req)
+	}
+	log := logging.With(ctx, t.Log)
+	unlock := t.Idempotency.Lock(req.RequestID)
+	defer unlock()
+
+	rec, err := t.Idempotency.Get(req.RequestID, idempotency.Fingerprint(req), time.Now())
+	if errors.Is(err, idempotency.ErrReused) {
+		return api.Response{}, xerr.ConflictErr("request_id_reused")
+	}
+	if err != nil {
+		return api.Response{}, xerr.Wrap("idempotency_store", err)
+	}
+	if rec != nil {
+		log.Info("request_replayed", "op", string(req.Op), "first_seen", rec.Created.Format(time.RFC3339))
+		resp := rec.Response
+		resp.Replayed = true
+		return resp, rec.Err()
+	}
+
+	resp, err := t.handle(ctx, req)
+	// only committed operations are remembered; a request that failed before
+	// issuing or revoking anything may simply run again
+	if resp.Serial != "" || resp.CRLPEM != "" {
+		if perr := t.Idempotency.Put(req.RequestID, req, resp, err, time.Now()); perr != nil {
+			log.Warn("idempotency_store_failed", "err", perr.Error())
+		}
+	}
+	return resp, err
+}
+
+func (t *Tenant) handle(ctx context.Context, req api.Request) (api.Response, error) {
+	if needsKey(req.Op) && t.CAs != nil && t.CAs.Active().Locked() {
+		return api.Response{}, xerr.E{Code: xerr.Forbidden, Msg: "ca_locked", Retry: true}
+	}
 	switch req.Op {
 	case api.OpHealth:
 		return api.Response{Serial: "ok", NotAfter: time.Now().UTC().Format(time.RFC3339)}, nil
 
+	case api.OpStatus:
+		return api.Response{Status: t.status()}, nil
+
+	case api.OpUnlock:
+		if t.CAs == nil {
+			return api.Response{}, xerr.InternalErr("ca_not_loaded")
+		}
+		if err := t.UnlockCA(ctx, []byte(req.Passphrase), "socket"); err != nil {
+			time.Sleep(constants.UnlockFailDelay)
+			return api.Response{}, xerr.ForbiddenErr("unlock_failed")
+		}
+		return api.Response{Status: t.status()}, nil
+
 	case api.OpGenKeyAndSign:
 		if err := validate.CN(req.CN); err != nil {
-			return api.Response{}, xerr.Bad("cn")
-		}
-		if err := a.ensureCN(req.CN); err != nil {
-			return api.Response{}, err
+			return api.Response{}, xerr.Invalid("cn")
 		}
 		if err := validate.Profile(req.Profile); err != nil {
-			return api.Response{}, xerr.Bad("profile")
+			return api.Response{}, xerr.Invalid("profile")
 		}
 		if err := validate.KeyType(req.KeyType); err != nil {
-			return api.Response{}, xerr.Bad("key_type")
+			return api.Response{}, xerr.Invalid("key_type")
 		}
 		if err := validate.Passphrase(req.Passphrase); err != nil {
-			return api.Response{}, xerr.Bad("passphrase")
+			return api.Response{}, xerr.Invalid("passphrase")
 		}
-		if a.CA == nil {
+		if t.CAs == nil {
 			return api.Response{}, xerr.InternalErr("ca_not_loaded")
 		}
-		days := a.Policy.ClientDays
+		days := t.Policy.ClientDays
 		if req.Profile == api.ProfileServer {
-			days = a.Policy.ServerDays
+			days = t.Policy.ServerDays
+		}
+		ca := t.CAs.Active()
+		if req.DryRun {
+			if err := t.ensureCN(req.CN); err != nil {
+				return api.Response{}, err
+			}
+			p, err := pki.Preview(ca, req.CN, req.Profile, days)
+			if err != nil {
+				return api.Response{}, xerr.Wrap("preview_failed", err)
+			}
+			p.KeyType = string(req.KeyType)
+			return api.Response{DryRun: true, Preview: p, CAID: ca.ID}, nil
 		}
-		res, err := pki.GenKeyAndSign(a.CA, req.CN, req.KeyType, req.Profile, days, req.Passphrase)
+		res, err := t.issue(ca, req.CN, req.Profile, "issue_failed", func() (*pki.SignResult, error) {
+			return pki.GenKeyAndSign(ca, req.CN, req.KeyType, req.Profile, days, req.Passphrase)
+		})
 		if err != nil {
-			return api.Response{}, xerr.InternalErr(err.Error())
+			return api.Response{}, err
 		}
-		_ = a.CA.AppendIssued(req.CN, string(req.Profile), res.Serial, res.NotAfter.UTC().Format(time.RFC3339), res.CertPEM)
-		a.writePEMCache(req.CN, res.CertPEM, res.KeyPEM)
+		t.writePEMCache(req.CN, res.CertPEM, res.KeyPEM)
 
 		return api.Response{
-			CertPEM:   res.CertPEM,
-			KeyPEMEnc: res.KeyPEM,
-			NotAfter:  res.NotAfter.UTC().Format(time.RFC3339),
-			Serial:    res.Serial,
+			CertPEM:     res.CertPEM,
+			ChainPEM:    ca.ChainPEM(),
+			KeyPEMEnc:   res.KeyPEM,
+			NotAfter:    res.NotAfter.UTC().Format(time.RFC3339),
+			Serial:      res.Serial,
+			CAID:        ca.ID,
+			HooksFailed: t.issued(ctx, hooks.EventIssue, ca.ID, req.CN, req.Profile, res),
 		}, nil
 
 	case api.OpSign:
 		if err := validate.CN(req.CN); err != nil {
-			return api.Response{}, xerr.Bad("cn")
-		}
-		if err := a.ensureCN(req.CN); err != nil {
-			return api.Response{}, err
+			return api.Response{}, xerr.Invalid("cn")
 		}
 		if err := validate.Profile(req.Profile); err != nil {
-			return api.Response{}, xerr.Bad("profile")
+			return api.Response{}, xerr.Invalid("profile")
 		}
 		if err := validate.CSR(req.CSRPEM); err != nil {
-			return api.Response{}, xerr.Bad("csr_pem")
+			return api.Response{}, xerr.Invalid("csr_pem")
 		}
-		if a.CA == nil {
+		if t.CAs == nil {
 			return api.Response{}, xerr.InternalErr("ca_not_loaded")
 		}
-		days := a.Policy.ClientDays
+		days := t.Policy.ClientDays
 		if req.Profile == api.ProfileServer {
-			days = a.Policy.ServerDays
+			days = t.Policy.ServerDays
 		}
-		res, err := pki.SignCSR(a.CA, req.CSRPEM, req.Profile, days)
+		ca := t.CAs.Active()
+		if req.DryRun {
+			if err := t.ensureCN(req.CN); err != nil {
+				return api.Response{}, err
+			}
+			p, err := pki.PreviewCSR(ca, req.CSRPEM, req.Profile, days)
+			if err != nil {
+				return api.Response{}, xerr.Wrap("preview_failed", err)
+			}
+			return api.Response{DryRun: true, Preview: p, CAID: ca.ID}, nil
+		}
+		res, err := t.issue(ca, req.CN, req.Profile, "sign_failed", func() (*pki.SignResult, error) {
+			return pki.SignCSR(ca, req.CSRPEM, req.Profile, days)
+		})
 		if err != nil {
-			return api.Response{}, xerr.InternalErr(err.Error())
+			return api.Response{}, err
 		}
-		_ = a.CA.AppendIssued(req.CN, string(req.Profile), res.Serial, res.NotAfter.UTC().Format(time.RFC3339), res.CertPEM)
-		a.writePEMCache(req.CN, res.CertPEM, "")
+		t.writePEMCache(req.CN, res.CertPEM, "")
 		return api.Response{
-			CertPEM:  res.CertPEM,
-			NotAfter: res.NotAfter.UTC().Format(time.RFC3339),
-			Serial:   res.Serial,
+			CertPEM:     res.CertPEM,
+			ChainPEM:    ca.ChainPEM(),
+			NotAfter:    res.NotAfter.UTC().Format(time.RFC3339),
+			Serial:      res.Serial,
+			CAID:        ca.ID,
+			HooksFailed: t.issued(ctx, hooks.EventIssue, ca.ID, req.CN, req.Profile, res),
 		}, nil
 
 	case api.OpRevoke:
 		if err := validate.SerialDec(req.Serial); err != nil {
-			return api.Response{}, xerr.Bad("serial")
+			return api.Response{}, xerr.Invalid("serial")
 		}
 		if err := validate.Reason(req.Reason); err != nil {
-			return api.Response{}, xerr.Bad("reason")
+			return api.Response{}, xerr.Invalid("reason")
 		}
-		if a.CA == nil {
+		if t.CAs == nil {
 			return api.Response{}, xerr.InternalErr("ca_not_loaded")
 		}
-		crl, err := a.CA.RevokeAndWriteCRL(req.Serial, req.Reason)
+		ca, err := t.locate(req.Serial, req.CAID)
 		if err != nil {
-			return api.Response{}, xerr.InternalErr(err.Error())
+			return api.Response{}, err
 		}
-		if a.CRLOut != "" {
-			if err := security.AtomicWrite(a.CRLOut, []byte(crl), 0o644); err != nil {
-				a.Log.Warn("crl_deploy_failed", "path", a.CRLOut, "err", err.Error())
+		if req.DryRun {
+			p, err := ca.PreviewRevoke(req.Serial, req.Reason)
+			if err != nil {
+				return api.Response{}, xerr.Wrap("preview_failed", err)
 			}
+			return api.Response{DryRun: true, Revoke: p, CAID: ca.ID}, nil
+		}
+		crl, err := ca.RevokeAndWriteCRL(req.Serial, req.Reason)
+		if err != nil {
+			return api.Response{}, xerr.Wrap("revoke_failed", err)
+		}
+		p := hooks.Payload{Event: hooks.EventRevoke, Serial: req.Serial, Reason: req.Reason}
+		// serials without an issuance record can be revoked too; their hooks get no CN
+		if meta, _, err := t.CAs.Issued(req.Serial, ca.ID); err == nil {
+			p.CN, p.Profile, p.NotAfter = meta.CN, meta.Profile, meta.NotAfter
+		}
+		t.publish(api.Event{Type: api.EventRevoked, CN: p.CN, Serial: req.Serial, CAID: ca.ID, Profile: p.Profile, Reason: req.Reason})
+		failed := t.fireHooks(ctx, p)
+		failed = append(failed, t.deployCRL(ctx)...)
+		return api.Response{CRLPEM: crl, CAID: ca.ID, HooksFailed: failed}, nil
+
+	case api.OpRevokeTLSKey:
+		if err := validate.SerialDec(req.Serial); err != nil {
+			return api.Response{}, xerr.Invalid("serial")
+		}
+		if t.TLSCrypt == nil {
+			return api.Response{}, xerr.NotImplemented("tls_crypt_v2_disabled")
+		}
+		if t.CAs == nil {
+			return api.Response{}, xerr.InternalErr("ca_not_loaded")
+		}
+		_, ca, err := t.issuedCert(req.Serial, req.CAID)
+		if err != nil {
+			return api.Response{}, err
+		}
+		k, err := t.TLSCrypt.Revoke(ca.ID, req.Serial, time.Now())
+		if errors.Is(err, tlscrypt.ErrNoKey) {
+			return api.Response{}, xerr.NotFoundErr("tls_key")
+		}
+		if err != nil {
+			return api.Response{}, xerr.Wrap("tls_key_revoke_failed", err)
 		}
-		return api.Response{CRLPEM: crl}, nil
+		t.DeployTLSCryptRevoked(ctx)
+		return api.Response{Serial: req.Serial, CAID: ca.ID, TLSKeyID: k.ID}, nil
 
 	case api.OpGetCRL:
-		if a.CA == nil {
+		if t.CAs == nil {
+			return api.Response{}, xerr.InternalErr("ca_not_loaded")
+		}
+		if req.CAID != "" {
+			ca := t.CAs.Get(req.CAID)
+			if ca == nil {
+				return api.Response{}, xerr.Invalid("ca_id")
+			}
+			crl, err := ca.EnsureCRL()
+			if err != nil {
+				return api.Response{}, xerr.Wrap("crl_failed", err)
+			}
+			return api.Response{CRLPEM: crl, ChainPEM: ca.ChainPEM(), CAID: ca.ID}, nil
+		}
+		crls, err := t.CAs.CRLs()
+		if err != nil {
+			return api.Response{}, xerr.Wrap("crl_failed", err)
+		}
+		return api.Response{CRLPEM: pki.ConcatCRLs(crls), ChainPEM: t.CAs.TrustPEM()}, nil
+
+	case api.OpGetCert:
+		if err := validate.SerialDec(req.Serial); err != nil {
+			return api.Response{}, xerr.Invalid("serial")
+		}
+		if t.CAs == nil {
 			return api.Response{}, xerr.InternalErr("ca_not_loaded")
 		}
-		crl, err := a.CA.ReadCRL()
+		meta, ca, err := t.issuedCert(req.Serial, req.CAID)
 		if err != nil {
-			return api.Response{}, xerr.InternalErr(err.Error())
+			return api.Response{}, err
+		}
+		revoked, err := ca.RevokedSerials()
+		if err != nil {
+			return api.Response{}, xerr.Wrap("crl_failed", err)
+		}
+		info := &api.CertInfo{IssuedMeta: meta}
+		if e, ok := revoked[req.Serial]; ok {
+			info.Revoked, info.RevocationReason = true, e.Reason
+			info.RevokedAt = time.Unix(e.RevokedAtUnix, 0).UTC().Format(time.RFC3339)
 		}
-		return api.Response{CRLPEM: crl}, nil
+		return api.Response{Cert: info, CertPEM: t.cachedCert(meta), Serial: meta.Serial, CAID: ca.ID}, nil
 
 	case api.OpListIssued:
-		if a.CA == nil {
+		if t.CAs == nil {
 			return api.Response{}, xerr.InternalErr("ca_not_loaded")
 		}
-		list, err := a.CA.ListIssued(200)
+		list, err := t.CAs.ListIssued(constants.ListIssuedMax)
 		if err != nil {
-			return api.Response{}, xerr.InternalErr(err.Error())
+			return api.Response{}, xerr.Wrap("index_failed", err)
 		}
 		return api.Response{Issued: list}, nil
 
 	case api.OpBuildBundle:
 		if req.Bundle == nil {
-			return api.Response{}, xerr.Bad("bundle")
+			return api.Response{}, xerr.Invalid("bundle")
 		}
 		if err := validate.CN(req.Bundle.CN); err != nil {
-			return api.Response{}, xerr.Bad("cn")
+			return api.Response{}, xerr.Invalid("cn")
 		}
-		if req.Bundle.RemoteHost == "" || req.Bundle.RemotePort <= 0 {
-			return api.Response{}, xerr.Bad("remote")
+		if err := checkBundle(req.Bundle); err != nil {
+			return api.Response{}, err
 		}
-		if a.CA == nil {
+		if t.CAs == nil {
 			return api.Response{}, xerr.InternalErr("ca_not_loaded")
 		}
-		certPEM, keyPEM, err := a.lookupIssuedPEMs(req.Bundle.CN, req.Bundle.IncludeKey)
+		zip, err := t.buildBundle(*req.Bundle)
 		if err != nil {
-			return api.Response{}, xerr.InternalErr(err.Error())
-		}
-		in := bundle.Inputs{
-			CN:         req.Bundle.CN,
-			CAPEM:      a.CA.CertPEM(),
-			TaKey:      a.TAKey,
-			CertPEM:    certPEM,
-			KeyPEMOpt:  keyPEM,
-			RemoteHost: req.Bundle.RemoteHost,
-			RemotePort: req.Bundle.RemotePort,
-			Proto:      req.Bundle.Proto,
-		}
-		out, err := bundle.Build(in)
+			return api.Response{}, err
+		}
+		return api.Response{ZipB64: zip}, nil
+
+	case api.OpBatch:
+		return t.batch(ctx, req)
+
+	case api.OpExpiring:
+		if req.Days < 0 {
+			return api.Response{}, xerr.Invalid("days")
+		}
+		if t.CAs == nil {
+			return api.Response{}, xerr.InternalErr("ca_not_loaded")
+		}
+		days := req.Days
+		if days == 0 {
+			days = expiry.MaxWindow(t.Policy.Expiry.WindowsDays)
+		}
+		list, err := expiry.List(t.CAs, t.Policy.Expiry.WindowsDays, days, time.Now())
 		if err != nil {
-			return api.Response{}, xerr.InternalErr(err.Error())
+			return api.Response{}, xerr.Wrap("index_failed", err)
 		}
-		return api.Response{ZipB64: base64.StdEncoding.EncodeToString(out.ZipBytes)}, nil
+		return api.Response{Expiring: list}, nil
 
 	default:
-		return api.Response{}, xerr.Bad("unknown_op")
+		return api.Response{}, xerr.E{Code: xerr.BadRequest, Msg: "unknown_op", Field: "op"}
+	}
+}
+
+func checkBundle(b *api.BundleReq) error {
+	if b.RemoteHost == "" || b.RemotePort <= 0 {
+		return xerr.E{Code: xerr.BadRequest, Msg: "remote", Field: "remote_host"}
+	}
+	if b.Template != "" && validate.Template(b.Template) != nil {
+		return xerr.Invalid("template")
 	}
+	return nil
 }
 
-func (a *App) ensureCN(cn string) error {
-	if a.cnPattern != nil && !a.cnPattern.MatchString(cn) {
-		return xerr.Bad("cn_policy")
+// buildBundle returns the base64 bundle zip for the latest certificate of b.CN.
+func (t *Tenant) buildBundle(b api.BundleReq) (string, error) {
+	certPEM, keyPEM, err := t.lookupIssuedPEMs(b.CN, b.IncludeKey)
+	if err != nil {
+		return "", xerr.E{Code: xerr.NotFound, Msg: "cert_missing", Field: "cn", Cause: err}
 	}
-	if !a.Policy.AllowDuplicateCN {
-		exists, err := a.CA.ExistsCNActive(cn)
+	blk, _ := pem.Decode([]byte(certPEM))
+	if blk == nil {
+		return "", xerr.InternalErr("cert_cache_corrupt")
+	}
+	cert, err := x509.ParseCertificate(blk.Bytes)
+	if err != nil {
+		return "", xerr.Wrap("cert_cache_corrupt", err)
+	}
+	tmpl, err := t.template(b.Template, cert)
+	if err != nil {
+		return "", err
+	}
+	in := bundle.Inputs{
+		CN:         b.CN,
+		CAPEM:      t.CAs.TrustPEM(),
+		TaKey:      t.TAKey,
+		CertPEM:    certPEM,
+		KeyPEMOpt:  keyPEM,
+		RemoteHost: b.RemoteHost,
+		RemotePort: b.RemotePort,
+		Proto:      b.Proto,
+		Template:   tmpl,
+	}
+	if t.TLSCrypt != nil {
+		if in.TLSCryptV2Key, err = t.tlsCryptKey(b.CN, cert); err != nil {
+			return "", xerr.Wrap("tls_crypt_v2_failed", err)
+		}
+	}
+	out, err := bundle.Build(in)
+	if err != nil {
+		return "", xerr.Wrap("bundle_failed", err)
+	}
+	return base64.StdEncoding.EncodeToString(out.ZipBytes), nil
+}
+
+// template picks the bundle template: the requested one, else the policy's
+// choice for the certificate's profile, else the default.
+func (t *Tenant) template(name string, cert *x509.Certificate) (*template.Template, error) {
+	if name == "" {
+		profile := api.ProfileClient
+		if slices.Contains(cert.ExtKeyUsage, x509.ExtKeyUsageServerAuth) {
+			profile = api.ProfileServer
+		}
+		name = t.Policy.BundleTemplates[profile]
+	}
+	if t.Templates == nil {
+		return nil, nil
+	}
+	tmpl, ok := t.Templates.Get(name)
+	if !ok {
+		return nil, xerr.E{Code: xerr.NotFound, Msg: "template", Field: "template"}
+	}
+	return tmpl, nil
+}
+
+// checkTemplates reports a template pol names that ts lacks.
+func checkTemplates(ts *bundle.Templates, pol policy.Policy) error {
+	if ts == nil {
+		return nil
+	}
+	for profile, name := range pol.BundleTemplates {
+		if _, ok := ts.Get(name); !ok {
+			return fmt.Errorf("bundle_templates.%s: no template %q (have %s)", profile, name, strings.Join(ts.Names(), ", "))
+		}
+	}
+	return nil
+}
+
+// SetTemplates swaps in freshly loaded bundle templates for every tenant. It
+// keeps the current ones if a tenant's policy names a template ts lacks.
+func (a *App) SetTemplates(ts *bundle.Templates) error {
+	for _, t := range a.Tenants() {
+		t.mu.RLock()
+		err := checkTemplates(ts, t.Policy)
+		t.mu.RUnlock()
 		if err != nil {
-			return xerr.InternalErr(err.Error())
+			return fmt.Errorf("tenant %s: %w", t.Name, err)
+		}
+	}
+	for _, t := range a.Tenants() {
+		t.mu.Lock()
+		t.Templates = ts
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+func (t *Tenant) issued(ctx context.Context, ev hooks.Event, caID, cn string, profile api.Profile, res *pki.SignResult) []string {
+	notAfter := res.NotAfter.UTC().Format(time.RFC3339)
+	t.publish(api.Event{
+		Type:     api.EventIssued,
+		CN:       cn,
+		Serial:   res.Serial,
+		CAID:     caID,
+		Profile:  string(profile),
+		NotAfter: notAfter,
+	})
+	return t.fireHooks(ctx, hooks.Payload{
+		Event:    ev,
+		CN:       cn,
+		Serial:   res.Serial,
+		Profile:  string(profile),
+		NotAfter: notAfter,
+	})
+}
+
+// issuedCert looks up the issuance record of serial for GET_CERT and
+// REVOKE_TLS_KEY.
+func (t *Tenant) issuedCert(serial, caID string) (api.IssuedMeta, *pki.CA, error) {
+	meta, ca, err := t.CAs.Issued(serial, caID)
+	switch {
+	case errors.Is(err, pki.ErrNotIssued):
+		return meta, nil, xerr.NotFoundErr("serial")
+	case errors.Is(err, pki.ErrUnknownCA):
+		return meta, nil, xerr.Invalid("ca_id")
+	case errors.Is(err, pki.ErrAmbiguousSerial):
+		return meta, nil, xerr.ConflictErr("serial_ambiguous")
+	case err != nil:
+		return meta, nil, xerr.Wrap("index_failed", err)
+	}
+	return meta, ca, nil
+}
+
+// locate resolves the CA that owns serial for REVOKE.
+func (t *Tenant) locate(serial, caID string) (*pki.CA, error) {
+	ca, err := t.CAs.Locate(serial, caID)
+	switch {
+	case errors.Is(err, pki.ErrUnknownCA):
+		return nil, xerr.Invalid("ca_id")
+	case errors.Is(err, pki.ErrAmbiguousSerial):
+		return nil, xerr.ConflictErr("serial_ambiguous")
+	case err != nil:
+		return nil, xerr.Wrap("index_failed", err)
+	case ca.Locked():
+		return nil, xerr.E{Code: xerr.Forbidden, Msg: "ca_locked", Retry: true}
+	}
+	return ca, nil
+}
+
+func (t *Tenant) deployCRL(ctx context.Context) []string {
+	t.publish(api.Event{Type: api.EventCRLRegenerated})
+	if t.CRLOut != "" {
+		if err := t.writeCRLOut(); err != nil {
+			logging.With(ctx, t.Log).Warn("crl_deploy_failed", "path", t.CRLOut, "err", err.Error())
+		}
+	}
+	t.DeployTLSCryptRevoked(ctx)
+	return t.fireHooks(ctx, hooks.Payload{Event: hooks.EventCRL})
+}
+
+// DeployedCRLs maps each CRL output path to the content it should hold for the
+// configured --crl-mode.
+func (t *Tenant) DeployedCRLs() (map[string]string, error) {
+	crls, err := t.CAs.CRLs()
+	if err != nil {
+		return nil, err
+	}
+	return t.crlFiles(crls), nil
+}
+
+// crlFiles maps CRLOut (and the per-CA paths beside it) to their contents.
+func (t *Tenant) crlFiles(crls []pki.CRLFile) map[string]string {
+	if t.CRLMode != constants.CRLModePerFile {
+		return map[string]string{t.CRLOut: pki.ConcatCRLs(crls)}
+	}
+	out := make(map[string]string, len(crls))
+	for _, f := range crls {
+		path := t.CRLOut
+		if f.CAID != pki.DefaultCAID {
+			ext := filepath.Ext(t.CRLOut)
+			path = strings.TrimSuffix(t.CRLOut, ext) + "-" + f.CAID + ext
+		}
+		out[path] = f.PEM
+	}
+	return out
+}
+
+func (t *Tenant) writeCRLOut() error {
+	files, err := t.DeployedCRLs()
+	if err != nil {
+		return err
+	}
+	for path, pemStr := range files {
+		if err := security.AtomicWrite(path, []byte(pemStr), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fireHooks runs the configured hooks for an event and returns the required
+// hooks that failed as "<event>:<name>". The primary operation has already been
+// committed at this point, so they are reported in Response.HooksFailed, not as
+// an error.
+func (t *Tenant) fireHooks(ctx context.Context, p hooks.Payload) []string {
+	p.Tenant = t.Name
+	r := hooks.Runner{Hooks: t.Policy.Hooks, Log: logging.With(ctx, t.Log).With("component", "hooks")}
+	failed := r.Fire(ctx, p)
+	for i, name := range failed {
+		failed[i] = string(p.Event) + ":" + name
+	}
+	return failed
+}
+
+// ReloadPolicies reloads every tenant's policy file. A tenant whose policy fails
+// to load keeps its previous one.
+func (a *App) ReloadPolicies() error {
+	var errs []error
+	for _, t := range a.Tenants() {
+		if err := t.ReloadPolicy(); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", t.Name, err))
+			continue
+		}
+		if a.bgCtx != nil {
+			t.StartBackground(a.bgCtx)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReloadPolicy swaps in a freshly loaded policy from PolicyPath.
+func (t *Tenant) ReloadPolicy() error {
+	pol, err := policy.Load(t.PolicyPath)
+	if err != nil {
+		return err
+	}
+	re, err := regexp.Compile(pol.CNPattern)
+	if err != nil {
+		return err
+	}
+	if err := checkTemplates(t.Templates, pol); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.Policy = pol
+	t.cnPattern = re
+	t.mu.Unlock()
+
+	t.publish(api.Event{Type: api.EventPolicyReloaded})
+	t.Log.Info("policy_reloaded", "path", t.PolicyPath)
+	return nil
+}
+
+// StartBackground (re)starts every tenant's expiry scanner and renewal loop under ctx.
+func (a *App) StartBackground(ctx context.Context) {
+	a.bgCtx = ctx
+	for _, t := range a.Tenants() {
+		t.StartBackground(ctx)
+	}
+}
+
+func (t *Tenant) StartBackground(ctx context.Context) {
+	if t.bgCancel != nil {
+		t.bgCancel()
+	}
+	bg, cancel := context.WithCancel(ctx)
+	t.bgCancel = cancel
+	t.startExpiryScanner(bg)
+	t.startRenewer(bg)
+	t.startIdempotencyPurge(bg)
+}
+
+// needsKey lists the ops refused while the active CA key is locked. REVOKE is
+// checked against the CA that owns the serial instead.
+func needsKey(op api.Op) bool {
+	switch op {
+	case api.OpSign, api.OpGenKeyAndSign, api.OpBatch:
+		return true
+	}
+	return false
+}
+
+// UnlockCA tries passphrase on every locked CA. It fails while the active CA,
+// which every issuance needs, is still locked afterwards.
+func (t *Tenant) UnlockCA(ctx context.Context, passphrase []byte, source string) error {
+	log := logging.With(ctx, t.Log)
+	var errs []error
+	locked := 0
+	for _, ca := range t.CAs.All() {
+		if !ca.Locked() {
+			continue
+		}
+		locked++
+		if err := ca.Unlock(passphrase); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Info("ca_unlocked", "ca_id", ca.ID, "source", source)
+	}
+	// other CAs taking the passphrase do not count while the active one stays locked
+	if t.CAs.Active().Locked() {
+		err := fmt.Errorf("active ca %s still locked: %w", t.CAs.Active().ID, errors.Join(errs...))
+		log.Warn("ca_unlock_failed", "source", source, "err", err.Error())
+		return err
+	}
+	if len(errs) > 0 {
+		log.Warn("ca_unlock_partial", "source", source, "still_locked", len(errs), "tried", locked)
+	}
+	return nil
+}
+
+// issue runs sign under ca's issuance lock, between the CN check and the
+// issued.jsonl append, so concurrent requests can neither share a serial nor
+// both pass the duplicate-CN check.
+func (t *Tenant) issue(ca *pki.CA, cn string, profile api.Profile, failMsg string, sign func() (*pki.SignResult, error)) (*pki.SignResult, error) {
+	defer ca.Issuing()()
+	if err := t.ensureCN(cn); err != nil {
+		return nil, err
+	}
+	res, err := sign()
+	if err != nil {
+		return nil, xerr.Wrap(failMsg, err)
+	}
+	_ = ca.AppendIssued(cn, string(profile), res.Serial, res.NotAfter.UTC().Format(time.RFC3339), res.CertPEM)
+	return res, nil
+}
+
+func (t *Tenant) ensureCN(cn string) error {
+	if t.cnPattern != nil && !t.cnPattern.MatchString(cn) {
+		return xerr.E{Code: xerr.BadRequest, Msg: "cn_policy", Field: "cn"}
+	}
+	if !t.Policy.AllowDuplicateCN {
+		exists, err := t.CAs.ExistsCNActive(cn)
+		if err != nil {
+			return xerr.Wrap("index_failed", err)
 		}
 		if exists {
-			return xerr.ConflictErr("cn_exists_active")
+			return xerr.E{Code: xerr.Conflict, Msg: "cn_exists_active", Field: "cn"}
 		}
 	}
 	return nil
 }
 
-func (a *App) pemCacheDir() string { return filepath.Join(a.CA.State, ".pemcache") }
+func (t *Tenant) pemCacheDir() string {
+	if t.PEMCacheDir != "" {
+		return t.PEMCacheDir
+	}
+	return filepath.Join(t.CAs.State, ".pemcache")
+}
 
-func (a *App) writePEMCache(cn, certPEM, keyPEM string) {
-	_ = os.MkdirAll(a.pemCacheDir(), 0o700)
-	_ = os.WriteFile(filepath.Join(a.pemCacheDir(), cn+".crt"), []byte(certPEM), 0o600)
+func (t *Tenant) writePEMCache(cn, certPEM, keyPEM string) {
+	_ = os.MkdirAll(t.pemCacheDir(), 0o700)
+	_ = os.WriteFile(filepath.Join(t.pemCacheDir(), cn+".crt"), []byte(certPEM), 0o600)
 	if keyPEM != "" {
-		_ = os.WriteFile(filepath.Join(a.pemCacheDir(), cn+".key"), []byte(keyPEM), 0o600)
+		_ = os.WriteFile(filepath.Join(t.pemCacheDir(), cn+".key"), []byte(keyPEM), 0o600)
 	}
 }
 
-func (a *App) lookupIssuedPEMs(cn string, includeKey bool) (string, string, error) {
-	certB, err := os.ReadFile(filepath.Join(a.pemCacheDir(), cn+".crt"))
+// cachedCert returns the PEM of meta from the bundle cache if the cache still
+// holds that certificate and not a later one for the same CN.
+func (t *Tenant) cachedCert(meta api.IssuedMeta) string {
+	b, err := os.ReadFile(filepath.Join(t.pemCacheDir(), meta.CN+".crt"))
+	if err != nil {
+		return ""
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return ""
+	}
+	sum := sha256.Sum256(block.Bytes)
+	if hex.EncodeToString(sum[:]) != meta.SHA256 {
+		return ""
+	}
+	return string(b)
+}
+
+func (t *Tenant) lookupIssuedPEMs(cn string, includeKey bool) (string, string, error) {
+	certB, err := os.ReadFile(filepath.Join(t.pemCacheDir(), cn+".crt"))
 	if err != nil {
 		return "", "", fmt.Errorf("cert_missing")
 	}
 	var key string
 	if includeKey {
-		if b, err := os.ReadFile(filepath.Join(a.pemCacheDir(), cn+".key")); err == nil {
+		if b, err := os.ReadFile(filepath.Join(t.pemCacheDir(), cn+".key")); err == nil {
 			key = string(b)
 		}
 	}
 	return string(certB), key, nil
 }
 
+func (t *Tenant) startExpiryScanner(ctx context.Context) {
+	if t.CAs == nil {
+		return
+	}
+	log := t.Log.With("component", "expiry")
+	n := expiry.Multi{expiry.LogNotifier{Log: log}, busNotifier{t}}
+	if cmd := t.Policy.Expiry.Notify.Exec; len(cmd) > 0 {
+		n = append(n, expiry.ExecNotifier{Command: cmd, Timeout: t.Policy.Expiry.Notify.ExecTimeout, Log: log})
+	}
+	if dir := t.Policy.Expiry.Notify.DropDir; dir != "" {
+		n = append(n, expiry.FileNotifier{Dir: dir})
+	}
+	s := &expiry.Scanner{
+		CAs:      t.CAs,
+		Windows:  t.Policy.Expiry.WindowsDays,
+		Interval: t.Policy.Expiry.ScanInterval,
+		Notifier: n,
+		Log:      log,
+	}
+	go s.Run(ctx)
+}
+
+func (t *Tenant) startRenewer(ctx context.Context) {
+	if t.CAs == nil || len(t.Policy.Renewal.Targets) == 0 {
+		return
+	}
+	r := &renew.Renewer{
+		CAs:      t.CAs,
+		Targets:  t.Policy.Renewal.Targets,
+		Interval: t.Policy.Renewal.CheckInterval,
+		Days:     t.Policy.ServerDays,
+		Log:      t.Log.With("component", "renew"),
+		Pending:  &t.renewPending,
+		OnRenew: func(rt policy.RenewTarget, res *pki.SignResult) {
+			t.mu.RLock()
+			defer t.mu.RUnlock()
+			_ = t.issued(ctx, hooks.EventRenew, t.CAs.Active().ID, rt.CN, api.ProfileServer, res)
+		},
+	}
+	go r.Run(ctx)
+}
+
+func (t *Tenant) startIdempotencyPurge(ctx context.Context) {
+	if t.Idempotency == nil {
+		return
+	}
+	go func() {
+		tk := time.NewTicker(constants.IdempotencyPurgeInterval)
+		defer tk.Stop()
+		for {
+			if n, err := t.Idempotency.Purge(time.Now()); err != nil {
+				t.Log.Warn("idempotency_purge_failed", "err", err.Error())
+			} else if n > 0 {
+				t.Log.Debug("idempotency_purged", "count", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-tk.C:
+			}
+		}
+	}()
+}
+
 func (a *App) StartServer(ctx context.Context, socket string) error {
 	s := &unixjson.Server{
-		Socket: socket,
-		Log:    a.Log.With("component", constants.AppName),
-		H:      a,
+		Socket:     socket,
+		Log:        a.Log.With("component", constants.AppName),
+		H:          a,
+		Deadline:   a.Deadline,
+		Idempotent: a.idempotent(),
+	}
+	return s.Start(ctx)
+}
+
+// idempotent reports whether every tenant keeps request_id records.
+func (a *App) idempotent() bool {
+	for _, t := range a.Tenants() {
+		if t.Idempotency == nil {
+			return false
+		}
+	}
+	return len(a.names) > 0
+}
+
+// StartGRPC serves the gRPC service on its own socket.
+func (a *App) StartGRPC(ctx context.Context, socket string) error {
+	s := &grpcapi.Server{
+		Socket:   socket,
+		Log:      a.Log.With("component", "grpc"),
+		H:        a,
+		Deadline: a.Deadline,
+	}
+	return s.Start(ctx)
+}
+
+// StartHTTP serves the REST API on its own socket.
+func (a *App) StartHTTP(ctx context.Context, socket string) error {
+	s := &httpapi.Server{
+		Socket:   socket,
+		Log:      a.Log.With("component", "http"),
+		H:        a,
+		Deadline: a.Deadline,
 	}
 	return s.Start(ctx)
 }
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+	"github.com/HarounAhmad/vpn-certd/internal/policy"
+)
+
+// startTenant serves a single tenant with a fresh self-signed CA over
+// JSON-RPC and returns its socket.
+func startTenant(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "certd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	pkiDir, stateDir := filepath.Join(dir, "pki"), filepath.Join(dir, "state")
+	if err := os.MkdirAll(pkiDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kder, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(pkiDir, "int-ca.crt"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(pkiDir, "int-ca.key"), "EC PRIVATE KEY", kder)
+
+	cas, err := pki.LoadSet(pkiDir, stateDir, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := a.AddTenant(&Tenant{Name: "default", CAs: cas, Policy: policy.Default(), PEMCacheDir: filepath.Join(dir, "cache")}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	socket := filepath.Join(dir, "s.sock")
+	if err := a.StartServer(ctx, socket); err != nil {
+		t.Fatal(err)
+	}
+	return socket
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func csrPEM(t *testing.T, cn string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+// pipelineSign writes one SIGN call per cn on a single connection in one
+// write, and returns the responses in arrival order.
+func pipelineSign(t *testing.T, socket string, cns []string) []api.RPCResponse {
+	t.Helper()
+	c, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i, cn := range cns {
+		params, _ := json.Marshal(api.Request{CN: cn, Profile: api.ProfileClient, CSRPEM: csrPEM(t, cn)})
+		id, _ := json.Marshal(i)
+		if err := enc.Encode(api.RPCRequest{JSONRPC: api.JSONRPCVersion, ID: id, Method: string(api.OpSign), Params: params}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bufio.NewReader(c))
+	out := make([]api.RPCResponse, len(cns))
+	for i := range out {
+		if err := dec.Decode(&out[i]); err != nil {
+			t.Fatalf("response %d: %v", i, err)
+		}
+	}
+	return out
+}
+
+func TestPipelinedSignUniqueSerials(t *testing.T) {
+	socket := startTenant(t)
+	cns := make([]string, 48)
+	for i := range cns {
+		cns[i] = fmt.Sprintf("client-%02d", i)
+	}
+	seen := map[string]string{}
+	for _, r := range pipelineSign(t, socket, cns) {
+		if r.Error != nil {
+			t.Fatalf("id %s: %s", r.ID, r.Error.Message)
+		}
+		if prev, ok := seen[r.Result.Serial]; ok {
+			t.Fatalf("serial %s issued to ids %s and %s", r.Result.Serial, prev, r.ID)
+		}
+		seen[r.Result.Serial] = string(r.ID)
+	}
+}
+
+func TestPipelinedSignDuplicateCN(t *testing.T) {
+	socket := startTenant(t)
+	cns := make([]string, 16)
+	for i := range cns {
+		cns[i] = "same-client"
+	}
+	ok := 0
+	for _, r := range pipelineSign(t, socket, cns) {
+		switch {
+		case r.Error == nil:
+			ok++
+		case r.Error.Message != "cn_exists_active":
+			t.Fatalf("id %s: unexpected error %+v", r.ID, r.Error)
+		}
+	}
+	if ok != 1 {
+		t.Fatalf("%d SIGNs of the same CN succeeded, want 1", ok)
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/logging"
+	"github.com/HarounAhmad/vpn-certd/internal/validate"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+)
+
+// batch answers BATCH. Each item is a GENKEY_AND_SIGN of its own, replayed
+// from the idempotency store under its row request_id, followed by a
+// BUILD_BUNDLE when asked for. A failed item does not stop the others; items
+// not started before ctx ends fail as retryable, so the caller can resend them.
+func (t *Tenant) batch(ctx context.Context, req api.Request) (api.Response, error) {
+	if len(req.Items) == 0 || len(req.Items) > constants.MaxBatchItems {
+		return api.Response{}, xerr.Invalid("items")
+	}
+	log := logging.With(ctx, t.Log)
+	out := make([]api.BatchResult, len(req.Items))
+	for i, it := range req.Items {
+		res, err := t.batchItem(ctx, req, it)
+		attrs := []any{"index", i, "cn", it.CN, "serial", res.Serial, "replayed", res.Replayed,
+			"code", string(xerr.CodeOf(err))}
+		lvl := slog.LevelInfo
+		if err != nil {
+			res.Err = api.ErrorOf(err)
+			attrs = append(attrs, "err", xerr.Detail(err))
+			lvl = slog.LevelWarn
+		}
+		log.Log(ctx, lvl, "batch_item", attrs...)
+		out[i] = res
+	}
+	return api.Response{Batch: out, DryRun: req.DryRun}, nil
+}
+
+func (t *Tenant) batchItem(ctx context.Context, req api.Request, it api.BatchItem) (api.BatchResult, error) {
+	res := api.BatchResult{CN: it.CN, RequestID: it.RequestID}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if err := validate.CN(it.CN); err != nil {
+		return res, xerr.Invalid("cn")
+	}
+	if res.RequestID == "" && req.RequestID != "" && !req.DryRun {
+		res.RequestID = req.RequestID + ":" + it.CN
+	}
+	if res.RequestID != "" && validate.RequestID(res.RequestID) != nil {
+		return res, xerr.Invalid("request_id")
+	}
+	// a bundle that cannot be built must not leave an issued certificate
+	// behind that blocks the retry with cn_exists_active
+	if it.Bundle != nil {
+		if err := checkBundle(it.Bundle); err != nil {
+			return res, err
+		}
+	}
+	sub := api.Request{
+		Op:         api.OpGenKeyAndSign,
+		CN:         it.CN,
+		Profile:    or(it.Profile, req.Profile),
+		KeyType:    or(it.KeyType, req.KeyType),
+		Passphrase: or(it.Passphrase, req.Passphrase),
+		DryRun:     req.DryRun,
+		RequestID:  res.RequestID,
+	}
+	resp, err := t.idempotent(ctx, sub)
+	res.Serial, res.CAID, res.NotAfter = resp.Serial, resp.CAID, resp.NotAfter
+	res.CertPEM, res.KeyPEMEnc = resp.CertPEM, resp.KeyPEMEnc
+	res.Preview, res.Replayed, res.HooksFailed = resp.Preview, resp.Replayed, resp.HooksFailed
+	if err != nil || resp.Serial == "" || it.Bundle == nil {
+		return res, err
+	}
+	b := *it.Bundle
+	b.CN = it.CN
+	zip, err := t.buildBundle(b)
+	res.ZipB64 = zip
+	return res, err
+}
+
+func or[T ~string](v, def T) T {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/expiry"
+	"github.com/HarounAhmad/vpn-certd/internal/logging"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+)
+
+const fileEventSeq = "events.seq"
+
+// EventBus fans lifecycle events out to SUBSCRIBE clients. The last
+// constants.EventBufferSize events are kept for replay and the sequence
+// counter is persisted so it keeps increasing across restarts.
+type EventBus struct {
+	mu      sync.Mutex
+	log     *slog.Logger
+	seqPath string
+	seq     uint64
+	ring    []api.Event
+	subs    map[chan api.Event]struct{}
+}
+
+func NewEventBus(stateDir string, log *slog.Logger) *EventBus {
+	b := &EventBus{
+		log:  log,
+		subs: map[chan api.Event]struct{}{},
+	}
+	if stateDir != "" {
+		b.seqPath = filepath.Join(stateDir, fileEventSeq)
+		if raw, err := os.ReadFile(b.seqPath); err == nil {
+			b.seq, _ = strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+		}
+	}
+	return b
+}
+
+func (b *EventBus) Publish(ev api.Event) api.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	ev.Seq = b.seq
+	if ev.Time == "" {
+		ev.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	if b.seqPath != "" {
+		if err := security.AtomicWrite(b.seqPath, []byte(strconv.FormatUint(b.seq, 10)), 0o600); err != nil {
+			b.log.Warn("event_seq_persist_failed", "err", err.Error())
+		}
+	}
+	b.ring = append(b.ring, ev)
+	if len(b.ring) > constants.EventBufferSize {
+		b.ring = b.ring[len(b.ring)-constants.EventBufferSize:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			// slow consumer: drop it, the client resumes with since_seq
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+	return ev
+}
+
+// Subscribe returns the retained events after since (none when since is 0) and a
+// channel of live events. It fails if events after since are no longer retained.
+func (b *EventBus) Subscribe(since uint64) ([]api.Event, <-chan api.Event, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []api.Event
+	if since > 0 && since < b.seq {
+		if len(b.ring) == 0 || b.ring[0].Seq > since+1 {
+			return nil, nil, nil, xerr.E{Code: xerr.Conflict, Msg: "since_seq_expired", Field: "since_seq"}
+		}
+		for _, ev := range b.ring {
+			if ev.Seq > since {
+				replay = append(replay, ev)
+			}
+		}
+	}
+	ch := make(chan api.Event, constants.SubscriberBacklog)
+	b.subs[ch] = struct{}{}
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+	return replay, ch, cancel, nil
+}
+
+func (b *EventBus) Seq() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq
+}
+
+func (t *Tenant) events() *EventBus {
+	t.busOnce.Do(func() {
+		dir := ""
+		if t.CAs != nil {
+			dir = t.CAs.State
+		}
+		t.bus = NewEventBus(dir, t.Log.With("component", "events"))
+	})
+	return t.bus
+}
+
+func (t *Tenant) publish(ev api.Event) {
+	ev = t.events().Publish(ev)
+	t.Log.Debug("event", "seq", ev.Seq, "type", string(ev.Type), "cn", ev.CN, "serial", ev.Serial)
+}
+
+func (a *App) IsStream(op api.Op) bool { return op == api.OpSubscribe }
+
+// Stream serves SUBSCRIBE. It also serves LIST_ISSUED for transports that can
+// stream a list (gRPC): every record is sent, not just the latest
+// constants.ListIssuedMax that Handle returns.
+func (a *App) Stream(ctx context.Context, req api.Request, send func(any) error) error {
+	switch req.Op {
+	case api.OpSubscribe:
+	case api.OpListIssued:
+		// a finite list, so it is logged and counted like any other request
+		_, err := a.serve(ctx, req, func(ctx context.Context, t *Tenant) (api.Response, error) {
+			return api.Response{}, t.streamIssued(ctx, send)
+		})
+		return err
+	default:
+		return xerr.E{Code: xerr.BadRequest, Msg: "unknown_op", Field: "op"}
+	}
+	t, err := a.tenant(req.Tenant)
+	if err != nil {
+		return err
+	}
+	bus := t.events()
+	replay, ch, cancel, err := bus.Subscribe(req.SinceSeq)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	for _, ev := range replay {
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+	hb := time.NewTicker(constants.EventHeartbeat)
+	defer hb.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-ch:
+			if !ok {
+				return xerr.E{Code: xerr.Conflict, Msg: "subscriber_overflow", Retry: true}
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		case now := <-hb.C:
+			if err := send(api.Event{Seq: bus.Seq(), Type: api.EventHeartbeat, Time: now.UTC().Format(time.RFC3339)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// streamIssued sends every issuance record. issued.jsonl is append-only, so it
+// is read without holding the tenant lock while a slow client drains it.
+func (t *Tenant) streamIssued(ctx context.Context, send func(any) error) error {
+	t.mu.RLock()
+	cas := t.CAs
+	t.mu.RUnlock()
+	if cas == nil {
+		return xerr.InternalErr("ca_not_loaded")
+	}
+	var sendErr error
+	err := cas.EachIssued(func(m api.IssuedMeta) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sendErr = send(m)
+		return sendErr
+	})
+	if err != nil && sendErr == nil && ctx.Err() == nil {
+		logging.With(ctx, t.Log).Error("list_issued_failed", "err", err.Error())
+		return xerr.Wrap("index_failed", err)
+	}
+	return err
+}
+
+// busNotifier forwards CA expiry notices from the scanner to the event bus.
+type busNotifier struct{ t *Tenant }
+
+func (n busNotifier) Notify(_ context.Context, ev expiry.Event) error {
+	if ev.Kind != expiry.KindCAExpiring {
+		return nil
+	}
+	n.t.publish(api.Event{
+		Type:     api.EventCAExpiring,
+		CN:       ev.CN,
+		Serial:   ev.Serial,
+		NotAfter: ev.NotAfter,
+		DaysLeft: ev.DaysLeft,
+	})
+	return nil
+}
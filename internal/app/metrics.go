@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/metrics"
+)
+
+// StartMetrics serves request metrics and per-tenant CA/CRL/state gauges on addr.
+func (a *App) StartMetrics(ctx context.Context, addr string) error {
+	if a.Metrics == nil {
+		a.Metrics = metrics.New()
+	}
+	for _, t := range a.Tenants() {
+		t.registerGauges(a.Metrics)
+	}
+	return a.Metrics.Serve(ctx, addr, a.Log.With("component", "metrics"))
+}
+
+func (t *Tenant) registerGauges(reg *metrics.Registry) {
+	m := tenantGauges{reg, map[string]string{"tenant": t.Name}}
+	m.AddGauge(metrics.Gauge{
+		Name: "certs_active",
+		Help: "Issued certificates that are neither expired nor revoked.",
+		Collect: func() (float64, bool) {
+			if t.CAs == nil {
+				return 0, false
+			}
+			list, err := t.CAs.ActiveIssued(time.Now())
+			return float64(len(list)), err == nil
+		},
+	})
+	m.AddGauge(metrics.Gauge{
+		Name: "certs_revoked",
+		Help: "Serials on the revocation list.",
+		Collect: func() (float64, bool) {
+			if t.CAs == nil {
+				return 0, false
+			}
+			n, err := t.CAs.RevokedCount()
+			return float64(n), err == nil
+		},
+	})
+	m.AddGauge(metrics.Gauge{
+		Name: "crl_age_seconds",
+		Help: "Seconds since the oldest current CRL's thisUpdate.",
+		Collect: func() (float64, bool) {
+			if t.CAs == nil {
+				return 0, false
+			}
+			this, _, err := t.crlTimes()
+			return time.Since(this).Seconds(), err == nil
+		},
+	})
+	m.AddGauge(metrics.Gauge{
+		Name: "crl_next_update_seconds",
+		Help: "Seconds until the earliest CRL nextUpdate (negative when stale).",
+		Collect: func() (float64, bool) {
+			if t.CAs == nil {
+				return 0, false
+			}
+			_, next, err := t.crlTimes()
+			return time.Until(next).Seconds(), err == nil
+		},
+	})
+	m.AddGauge(metrics.Gauge{
+		Name: "ca_expiry_days",
+		Help: "Days until the active intermediate CA certificate expires.",
+		Collect: func() (float64, bool) {
+			if t.CAs == nil {
+				return 0, false
+			}
+			return time.Until(t.CAs.Active().Cert.NotAfter).Hours() / 24, true
+		},
+	})
+	m.AddGauge(metrics.Gauge{
+		Name: "pem_cache_files",
+		Help: "Files in the bundle PEM cache.",
+		Collect: func() (float64, bool) {
+			n, _, ok := t.pemCacheStats()
+			return float64(n), ok
+		},
+	})
+	m.AddGauge(metrics.Gauge{
+		Name: "pem_cache_bytes",
+		Help: "Total size of the bundle PEM cache.",
+		Collect: func() (float64, bool) {
+			_, size, ok := t.pemCacheStats()
+			return float64(size), ok
+		},
+	})
+}
+
+func (t *Tenant) pemCacheStats() (int, int64, bool) {
+	if t.CAs == nil {
+		return 0, 0, false
+	}
+	entries, err := os.ReadDir(t.pemCacheDir())
+	if err != nil {
+		return 0, 0, os.IsNotExist(err)
+	}
+	var size int64
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+	}
+	return len(entries), size, true
+}
+
+// tenantGauges labels every gauge it registers with the tenant name.
+type tenantGauges struct {
+	reg    *metrics.Registry
+	labels map[string]string
+}
+
+func (m tenantGauges) AddGauge(g metrics.Gauge) {
+	g.Labels = m.labels
+	m.reg.AddGauge(g)
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/expiry"
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+	"github.com/HarounAhmad/vpn-certd/pkg/version"
+)
+
+type statusBuilder struct {
+	r api.StatusReport
+}
+
+func (b *statusBuilder) fail(format string, args ...any) {
+	b.r.Verdict = api.VerdictFailing
+	b.r.Problems = append(b.r.Problems, fmt.Sprintf(format, args...))
+}
+
+func (b *statusBuilder) degrade(format string, args ...any) {
+	if b.r.Verdict == api.VerdictOK {
+		b.r.Verdict = api.VerdictDegraded
+	}
+	b.r.Problems = append(b.r.Problems, fmt.Sprintf(format, args...))
+}
+
+// status inspects the CA, CRL, state directory and deployment targets. Anything
+// that stops issuance or revocation is failing; anything that will soon, or that
+// leaves OpenVPN with stale data, is degraded.
+func (t *Tenant) status() *api.StatusReport {
+	now := time.Now()
+	b := &statusBuilder{r: api.StatusReport{
+		Verdict:       api.VerdictOK,
+		Tenant:        t.Name,
+		Version:       version.Version + "+" + version.Commit,
+		StartedAt:     t.started.UTC().Format(time.RFC3339),
+		UptimeSeconds: int64(now.Sub(t.started).Seconds()),
+		TAKeyPresent:  t.TAKey != "",
+		TLSCryptV2:    t.TLSCrypt != nil,
+		Policy:        api.PolicyStatus{Version: t.Policy.Version, Digest: t.Policy.Digest},
+	}}
+	if !b.r.TAKeyPresent && !b.r.TLSCryptV2 {
+		b.degrade("ta_key_missing")
+	}
+	if t.CAs == nil {
+		b.fail("ca_not_loaded")
+		return &b.r
+	}
+
+	for _, c := range t.CAs.All() {
+		ca := caStatus(c, now)
+		ca.Active = c == t.CAs.Active()
+		b.r.CAs = append(b.r.CAs, *ca)
+		if !ca.Active {
+			if !ca.Locked && !ca.KeyMatches {
+				b.fail("ca_key_mismatch: %s", c.ID)
+			}
+			continue
+		}
+		b.r.CA = ca
+		switch {
+		case ca.Locked:
+			b.degrade("ca_locked")
+		case !ca.KeyMatches:
+			b.fail("ca_key_mismatch")
+		case !now.Before(c.Cert.NotAfter):
+			b.fail("ca_expired")
+		case ca.DaysLeft < expiry.MaxWindow(t.Policy.Expiry.WindowsDays):
+			b.degrade("ca_expiring: %d days left", ca.DaysLeft)
+		}
+	}
+
+	crl := &api.CRLStatus{DeployPath: t.CRLOut}
+	// STATUS only reads: a missing CRL is reported, not signed
+	if this, next, err := t.crlTimes(); errors.Is(err, os.ErrNotExist) {
+		b.degrade("crl_missing")
+	} else if err != nil {
+		b.degrade("crl_unavailable")
+	} else {
+		crl.ThisUpdate = this.UTC().Format(time.RFC3339)
+		crl.NextUpdate = next.UTC().Format(time.RFC3339)
+		crl.AgeSeconds = int64(now.Sub(this).Seconds())
+		if !now.Before(next) {
+			b.degrade("crl_stale")
+		}
+	}
+	if t.CRLOut != "" {
+		if crls, err := t.CAs.ReadCRLs(); err == nil {
+			crl.Deployed, crl.InSync = true, true
+			for path, current := range t.crlFiles(crls) {
+				deployed, err := os.ReadFile(path)
+				if err != nil {
+					crl.Deployed, crl.InSync = false, false
+					break
+				}
+				crl.InSync = crl.InSync && bytes.Equal(deployed, []byte(current))
+			}
+		}
+		if crl.ThisUpdate != "" && !crl.InSync {
+			b.degrade("crl_not_deployed")
+		}
+	}
+	b.r.CRL = crl
+	if t.TLSCrypt != nil && t.TLSCryptRevokedOut != "" {
+		want, err := t.TLSCryptRevokedList()
+		deployed, derr := os.ReadFile(t.TLSCryptRevokedOut)
+		if err == nil && (derr != nil || !bytes.Equal(deployed, want)) {
+			b.degrade("tls_crypt_v2_revoked_not_deployed")
+		}
+	}
+
+	st := &api.StorageStatus{StateDir: t.CAs.State}
+	writable, free, err := security.DirStatus(t.CAs.State)
+	st.Writable, st.FreeBytes = writable, free
+	switch {
+	case err != nil:
+		b.fail("state_dir: %v", err)
+	case !writable:
+		b.fail("state_dir_not_writable")
+	case free < constants.MinFreeBytes:
+		b.degrade("state_dir_low_space")
+	}
+	b.r.Storage = st
+	return &b.r
+}
+
+func caStatus(c *pki.CA, now time.Time) *api.CAStatus {
+	ca := &api.CAStatus{
+		ID:       c.ID,
+		Subject:  c.Cert.Subject.String(),
+		NotAfter: c.Cert.NotAfter.UTC().Format(time.RFC3339),
+		DaysLeft: int(c.Cert.NotAfter.Sub(now) / (24 * time.Hour)),
+	}
+	if key, err := c.Signer(); err != nil {
+		ca.Locked = true
+	} else if pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); ok {
+		ca.KeyMatches = pub.Equal(c.Cert.PublicKey)
+	}
+	return ca
+}
+
+// crlTimes reports the oldest thisUpdate and earliest nextUpdate across all CAs,
+// so one stale CRL is enough to show up.
+func (t *Tenant) crlTimes() (this, next time.Time, err error) {
+	for _, ca := range t.CAs.All() {
+		t, n, err := ca.CRLTimes()
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if this.IsZero() || t.Before(this) {
+			this = t
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return this, next, nil
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/HarounAhmad/vpn-certd/internal/logging"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+	"github.com/HarounAhmad/vpn-certd/internal/tlscrypt"
+)
+
+// tlsCryptKey returns the tls-crypt-v2 client key of cert, wrapping one on the
+// first bundle built for it.
+func (t *Tenant) tlsCryptKey(cn string, cert *x509.Certificate) (string, error) {
+	for _, ca := range t.CAs.All() {
+		if cert.CheckSignatureFrom(ca.Cert) == nil {
+			k, err := t.TLSCrypt.ClientKey(ca.ID, cert.SerialNumber.String(), cn)
+			return k.KeyPEM, err
+		}
+	}
+	return "", fmt.Errorf("no CA of tenant %s signed serial %s", t.Name, cert.SerialNumber)
+}
+
+// TLSCryptRevokedList renders the refused client key list: retired keys and
+// keys of revoked or held certificates.
+func (t *Tenant) TLSCryptRevokedList() ([]byte, error) {
+	keys, err := t.TLSCrypt.Keys()
+	if err != nil {
+		return nil, err
+	}
+	revoked := map[string]map[string]bool{}
+	for _, ca := range t.CAs.All() {
+		m, err := ca.RevokedSerials()
+		if err != nil {
+			return nil, err
+		}
+		revoked[ca.ID] = map[string]bool{}
+		for serial := range m {
+			revoked[ca.ID][serial] = true
+		}
+	}
+	return tlscrypt.RevokedList(keys, func(caID, serial string) bool { return revoked[caID][serial] }), nil
+}
+
+// DeployTLSCryptRevoked writes the refused client key list to
+// TLSCryptRevokedOut. Failures are logged; the list is rewritten after every
+// revocation.
+func (t *Tenant) DeployTLSCryptRevoked(ctx context.Context) {
+	if t.TLSCrypt == nil || t.TLSCryptRevokedOut == "" {
+		return
+	}
+	b, err := t.TLSCryptRevokedList()
+	if err == nil {
+		err = security.AtomicWrite(t.TLSCryptRevokedOut, b, 0o644)
+	}
+	if err != nil {
+		logging.With(ctx, t.Log).Warn("tls_crypt_v2_deploy_failed", "path", t.TLSCryptRevokedOut, "err", err.Error())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"text/template"
 	"time"
 )
 
@@ -22,6 +23,11 @@ type Inputs struct {
 	RemoteHost string
 	RemotePort int
 	Proto      string
+	// TLSCryptV2Key is the client's own tls-crypt-v2 key; when set it
+	// replaces the shared TaKey.
+	TLSCryptV2Key string
+	// Template renders the profiles; nil is the built-in default.
+	Template *template.Template
 }
 
 type Outputs struct {
@@ -46,8 +52,33 @@ func Build(inputs Inputs) (Outputs, error) {
 		inputs.Proto = "udp"
 	}
 
-	refOvpn := renderRefOvpn(inputs)
-	inlineOvpn := renderInlineOvpn(inputs)
+	tmpl := inputs.Template
+	if tmpl == nil {
+		tmpl = builtin
+	}
+	p := Profile{
+		CN:         inputs.CN,
+		Proto:      inputs.Proto,
+		RemoteHost: inputs.RemoteHost,
+		RemotePort: inputs.RemotePort,
+		CA:         trim(inputs.CAPEM),
+		Cert:       trim(inputs.CertPEM),
+		Key:        trim(inputs.KeyPEMOpt),
+	}
+	if inputs.TLSCryptV2Key != "" {
+		p.TLSCryptV2 = trim(inputs.TLSCryptV2Key)
+	} else {
+		p.TLSCrypt = trim(inputs.TaKey)
+	}
+	refOvpn, err := render(tmpl, p)
+	if err != nil {
+		return Outputs{}, err
+	}
+	p.Inline = true
+	inlineOvpn, err := render(tmpl, p)
+	if err != nil {
+		return Outputs{}, err
+	}
 
 	var buf bytes.Buffer
 	zw := zip.NewWriter(&buf)
@@ -65,7 +96,11 @@ func Build(inputs Inputs) (Outputs, error) {
 	if err := add(filepath.Join(base, "ca.crt"), []byte(inputs.CAPEM)); err != nil {
 		return Outputs{}, err
 	}
-	if err := add(filepath.Join(base, "ta.key"), []byte(inputs.TaKey)); err != nil {
+	if inputs.TLSCryptV2Key != "" {
+		if err := add(filepath.Join(base, "tls-crypt-v2.key"), []byte(inputs.TLSCryptV2Key)); err != nil {
+			return Outputs{}, err
+		}
+	} else if err := add(filepath.Join(base, "ta.key"), []byte(inputs.TaKey)); err != nil {
 		return Outputs{}, err
 	}
 	if err := add(filepath.Join(base, inputs.CN+".crt"), []byte(inputs.CertPEM)); err != nil {
@@ -84,8 +119,9 @@ func Build(inputs Inputs) (Outputs, error) {
 	}
 
 	meta := fmt.Sprintf(
-		"cn=%s\ngenerated_at_utc=%s\nproto=%s\nremote=%s:%d\ncapem_sha256_b64=%s\n",
+		"cn=%s\ntemplate=%s\ngenerated_at_utc=%s\nproto=%s\nremote=%s:%d\ncapem_sha256_b64=%s\n",
 		inputs.CN,
+		tmpl.Name(),
 		time.Now().UTC().Format(time.RFC3339),
 		inputs.Proto,
 		inputs.RemoteHost,
@@ -100,68 +136,6 @@ func Build(inputs Inputs) (Outputs, error) {
 	return Outputs{ZipBytes: buf.Bytes()}, nil
 }
 
-func renderRefOvpn(in Inputs) string {
-	return fmt.Sprintf(`client
-dev tun
-proto %s
-remote %s %d
-resolv-retry infinite
-nobind
-persist-key
-persist-tun
-cipher AES-256-GCM
-auth SHA512
-remote-cert-tls server
-verb 3
-key-direction 1
-
-ca ca.crt
-cert %s.crt
-tls-crypt ta.key
-%s
-`, in.Proto, in.RemoteHost, in.RemotePort, in.CN, keyRefLine(in))
-}
-
-func keyRefLine(in Inputs) string {
-	if in.KeyPEMOpt == "" {
-		return fmt.Sprintf("# key %s.key", in.CN)
-	}
-	return fmt.Sprintf("key %s.key", in.CN)
-}
-
-func renderInlineOvpn(in Inputs) string {
-	var keySection string
-	if in.KeyPEMOpt != "" {
-		keySection = fmt.Sprintf("<key>\n%s\n</key>\n", trim(in.KeyPEMOpt))
-	} else {
-		keySection = "# <key>\n# provide your private key or use file-referencing profile\n# </key>\n"
-	}
-	return fmt.Sprintf(`client
-dev tun
-proto %s
-remote %s %d
-resolv-retry infinite
-nobind
-persist-key
-persist-tun
-cipher AES-256-GCM
-auth SHA512
-remote-cert-tls server
-verb 3
-key-direction 1
-
-<ca>
-%s
-</ca>
-<cert>
-%s
-</cert>
-<tls-crypt>
-%s
-</tls-crypt>
-%s`, in.Proto, in.RemoteHost, in.RemotePort, trim(in.CAPEM), trim(in.CertPEM), trim(in.TaKey), keySection)
-}
-
 func trim(s string) string {
 	return string(bytes.TrimSpace([]byte(s)))
 }
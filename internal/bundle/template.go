@@ -0,0 +1,215 @@
+package bundle
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/HarounAhmad/vpn-certd/internal/validate"
+)
+
+//go:embed default.ovpn.tmpl
+var defaultTemplate string
+
+var builtin = template.Must(ParseTemplate(DefaultTemplate, defaultTemplate))
+
+// DefaultTemplate names the built-in template; a default.ovpn.tmpl in the
+// template directory replaces it.
+const DefaultTemplate = "default"
+
+// TemplateExt is the suffix of template files; the rest of the name is the
+// template name.
+const TemplateExt = ".ovpn.tmpl"
+
+// Profile is what a template renders: once with Inline false for client.ovpn,
+// which references the files beside it, and once with Inline true for
+// client-inline.ovpn.
+type Profile struct {
+	CN         string
+	Proto      string
+	RemoteHost string
+	RemotePort int
+	Inline     bool
+	// PEM contents, trimmed. Key is empty when the bundle carries no private
+	// key; at most one of TLSCrypt and TLSCryptV2 is set.
+	CA         string
+	Cert       string
+	Key        string
+	TLSCrypt   string
+	TLSCryptV2 string
+}
+
+// Templates are the profile templates by name.
+type Templates struct {
+	byName map[string]*template.Template
+}
+
+// LoadTemplates parses every <name>.ovpn.tmpl in dir on top of the built-in
+// default. A missing dir leaves just the default. Each template is rendered
+// for every kind of bundle and must only produce allowed directives.
+func LoadTemplates(dir string) (*Templates, error) {
+	ts := &Templates{byName: map[string]*template.Template{DefaultTemplate: builtin}}
+	if dir == "" {
+		return ts, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), TemplateExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if err := validate.Template(name); err != nil {
+			return nil, fmt.Errorf("%s: template names are lowercase letters, digits and ._-", path)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if ts.byName[name], err = ParseTemplate(name, string(b)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return ts, nil
+}
+
+// Get returns the template called name, or the default for "".
+func (ts *Templates) Get(name string) (*template.Template, bool) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	t, ok := ts.byName[name]
+	return t, ok
+}
+
+// Names lists the loaded templates.
+func (ts *Templates) Names() []string {
+	out := make([]string, 0, len(ts.byName))
+	for n := range ts.byName {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ParseTemplate parses a profile template and checks its output for every
+// combination of inline, private key and tls-crypt version.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, inline := range []bool{false, true} {
+		for _, key := range []string{"", samplePEM("PRIVATE KEY")} {
+			for _, v2 := range []bool{false, true} {
+				p := Profile{CN: "sample", Proto: "udp", RemoteHost: "vpn.example.com", RemotePort: 1194, Inline: inline,
+					CA: samplePEM("CERTIFICATE"), Cert: samplePEM("CERTIFICATE"), Key: key}
+				if v2 {
+					p.TLSCryptV2 = samplePEM("OpenVPN tls-crypt-v2 client key")
+				} else {
+					p.TLSCrypt = samplePEM("OpenVPN Static key V1")
+				}
+				if _, err := render(t, p); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+func samplePEM(typ string) string {
+	return "-----BEGIN " + typ + "-----\nAAAA\n-----END " + typ + "-----"
+}
+
+func render(t *template.Template, p Profile) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, p); err != nil {
+		return "", err
+	}
+	mode := "file-referencing"
+	if p.Inline {
+		mode = "inline"
+	}
+	if err := Validate(b.String()); err != nil {
+		return "", fmt.Errorf("%s profile: %w", mode, err)
+	}
+	return b.String(), nil
+}
+
+// allowedDirectives are the client options a profile may use: connection,
+// routing and crypto settings, but nothing that runs commands, writes files,
+// loads plugins or opens a management interface.
+var allowedDirectives = func() map[string]bool {
+	m := map[string]bool{}
+	for _, d := range strings.Fields(`
+		client tls-client pull dev dev-type proto remote remote-random remote-random-hostname
+		resolv-retry nobind persist-key persist-tun float connect-retry connect-retry-max
+		connect-timeout server-poll-timeout explicit-exit-notify keepalive ping ping-restart
+		ping-exit inactive hand-window reneg-sec tun-mtu mssfix fragment sndbuf rcvbuf
+		remote-cert-tls verify-x509-name ca cert key tls-crypt tls-crypt-v2 tls-auth key-direction
+		data-ciphers data-ciphers-fallback cipher auth tls-version-min tls-version-max tls-cipher
+		tls-ciphersuites tls-groups tls-cert-profile auth-nocache auth-user-pass auth-retry
+		route-nopull redirect-gateway route route-ipv6 route-delay dhcp-option block-outside-dns
+		pull-filter allow-compression verb mute mute-replay-warnings`) {
+		m[d] = true
+	}
+	return m
+}()
+
+// allowedBlocks are the inline file blocks a profile may embed.
+var allowedBlocks = map[string]bool{
+	"ca": true, "cert": true, "key": true, "extra-certs": true,
+	"tls-crypt": true, "tls-crypt-v2": true, "tls-auth": true,
+}
+
+// Validate checks that conf only holds allowed directives and inline blocks,
+// that every block is closed, and that key-direction only comes with
+// tls-auth (tls-crypt keys have no direction).
+func Validate(conf string) error {
+	seen := map[string]bool{}
+	var block string
+	for i, line := range strings.Split(conf, "\n") {
+		l := strings.TrimSpace(line)
+		if block != "" {
+			if l == "</"+block+">" {
+				block = ""
+			}
+			continue
+		}
+		switch {
+		case l == "" || l[0] == '#' || l[0] == ';':
+		case l[0] == '<':
+			name, ok := strings.CutSuffix(l[1:], ">")
+			if !ok || !allowedBlocks[name] {
+				return fmt.Errorf("line %d: block %s not allowed", i+1, l)
+			}
+			block, seen[name] = name, true
+		default:
+			d := strings.Fields(l)[0]
+			if !allowedDirectives[d] {
+				return fmt.Errorf("line %d: directive %q not allowed", i+1, d)
+			}
+			seen[d] = true
+		}
+	}
+	if block != "" {
+		return fmt.Errorf("<%s> not closed", block)
+	}
+	if seen["key-direction"] && !seen["tls-auth"] {
+		return errors.New("key-direction without tls-auth; tls-crypt keys have no direction")
+	}
+	return nil
+}
@@ -1,48 +1,388 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/HarounAhmad/vpn-certd/internal/constants"
 )
 
+// Config is the daemon configuration. Every field is settable from a flag, an
+// environment variable and the config file; see options for the names.
 type Config struct {
-	SocketPath string
-	PKIDir     string
-	StateDir   string
-	LogLevel   string
-	PolicyPath string
-	CRLOutPath string
-	TAPath     string
-}
-
-func Load() Config {
-	var c Config
-
-	socket := getenvDefault(constants.EnvSocket, constants.DefaultSocketPath)
-	pki := getenvDefault(constants.EnvPKIDir, constants.DefaultPKIDir)
-	state := getenvDefault(constants.EnvStateDir, constants.DefaultStateDir)
-	level := getenvDefault(constants.EnvLogLevel, constants.DefaultLogLevel)
-	policy := getenvDefault(constants.EnvPolicyPath, constants.DefaultPolicy)
-	crlout := getenvDefault(constants.EnvCRLOutPath, constants.DefaultCRLOut)
-	ta := getenvDefault(constants.EnvTAPath, constants.DefaultTAPath)
-
-	flag.StringVar(&c.SocketPath, "socket", socket, "UNIX socket path")
-	flag.StringVar(&c.PKIDir, "pki", pki, "PKI directory (intermediate CA)")
-	flag.StringVar(&c.StateDir, "state", state, "State directory")
-	flag.StringVar(&c.LogLevel, "log-level", level, "log level: debug|info|warn|error")
-	flag.StringVar(&c.PolicyPath, "policy", policy, "policy YAML file path")
-	flag.StringVar(&c.CRLOutPath, "crl-out", crlout, "CRL deployment path for OpenVPN")
-	flag.StringVar(&c.TAPath, "ta", ta, "path to tls-crypt ta.key")
-	flag.Parse()
-
-	return c
-}
-
-func getenvDefault(k, def string) string {
-	if v, ok := os.LookupEnv(k); ok && v != "" {
-		return v
-	}
-	return def
+	ConfigPath  string
+	SocketPath  string
+	HTTPSocket  string
+	GRPCSocket  string
+	PKIDir      string
+	StateDir    string
+	LogLevel    string
+	PolicyPath  string
+	CRLOutPath  string
+	CRLMode     string
+	CRLValidity time.Duration
+	ActiveCA    string
+	TenantsPath string
+	TAPath      string
+	PEMCacheDir string
+	MetricsAddr string
+	CAKeyCred   string
+	CAKeyPassFD int
+
+	TLSCryptV2           bool
+	TLSCryptV2RevokedOut string
+	TemplateDir          string
+
+	KeyBackend     string
+	PKCS11Module   string
+	PKCS11Token    string
+	PKCS11Slot     int
+	PKCS11KeyLabel string
+	PKCS11PIN      string
+
+	ReadWriteDeadline    time.Duration
+	ShutdownTimeout      time.Duration
+	IdempotencyRetention time.Duration
+
+	// Warnings collects non-fatal notes from Load, e.g. deprecated env names.
+	Warnings []string
+
+	sources map[string]string
+}
+
+func defaults() Config {
+	return Config{
+		ConfigPath:           constants.DefaultConfigPath,
+		SocketPath:           constants.DefaultSocketPath,
+		PKIDir:               constants.DefaultPKIDir,
+		StateDir:             constants.DefaultStateDir,
+		LogLevel:             constants.DefaultLogLevel,
+		PolicyPath:           constants.DefaultPolicy,
+		CRLOutPath:           constants.DefaultCRLOut,
+		CRLMode:              constants.CRLModeConcat,
+		CRLValidity:          constants.DefaultCRLValidity,
+		TAPath:               constants.DefaultTAPath,
+		TLSCryptV2RevokedOut: constants.DefaultTLSCryptV2RevokedOut,
+		TemplateDir:          constants.DefaultTemplateDir,
+		CAKeyCred:            constants.DefaultCAKeyCredential,
+		CAKeyPassFD:          -1,
+		KeyBackend:           constants.KeyBackendFile,
+		PKCS11KeyLabel:       "int-ca",
+		PKCS11PIN:            "credential:vpn-certd-pkcs11-pin",
+		ReadWriteDeadline:    constants.ReadWriteDeadline,
+		ShutdownTimeout:      constants.ShutdownTimeout,
+		IdempotencyRetention: constants.DefaultIdempotencyRetention,
+	}
+}
+
+// option binds one setting to its config key, flag (the key with '-' for '_')
+// and VPN_CERTD_<KEY> environment variable.
+type option struct {
+	key    string
+	legacy string // pre-unification env name, still honoured with a warning
+	help   string
+	field  func(c *Config) any
+}
+
+var options = []option{
+	{"config", "", "daemon config file (YAML)", func(c *Config) any { return &c.ConfigPath }},
+	{"socket", "", "UNIX socket path", func(c *Config) any { return &c.SocketPath }},
+	{"http_socket", "", "UNIX socket for the REST API (empty disables)", func(c *Config) any { return &c.HTTPSocket }},
+	{"grpc_socket", "", "UNIX socket for the gRPC service (empty disables)", func(c *Config) any { return &c.GRPCSocket }},
+	{"pki_dir", "", "PKI directory (intermediate CA)", func(c *Config) any { return &c.PKIDir }},
+	{"state_dir", "", "state directory", func(c *Config) any { return &c.StateDir }},
+	{"log_level", "", "log level: debug|info|warn|error", func(c *Config) any { return &c.LogLevel }},
+	{"policy", "VPNCERTD_POLICY", "policy YAML file path", func(c *Config) any { return &c.PolicyPath }},
+	{"crl_out", "VPNCERTD_CRL_OUT", "CRL deployment path for OpenVPN", func(c *Config) any { return &c.CRLOutPath }},
+	{"crl_mode", "VPNCERTD_CRL_MODE", "CRL deployment with several CAs: concat (one file) | per-file (crl-<id>.pem beside crl_out)", func(c *Config) any { return &c.CRLMode }},
+	{"crl_validity", "", "nextUpdate distance of generated CRLs", func(c *Config) any { return &c.CRLValidity }},
+	{"active_ca", "", `ID of the CA that issues new certificates (default: the only CA, or "default")`, func(c *Config) any { return &c.ActiveCA }},
+	{"tenants", "", "tenants YAML file; when set, pki_dir/state_dir/policy/crl_out/crl_mode/ta_key/tls_crypt_v2/tls_crypt_v2_revoked_out/active_ca/pem_cache_dir come from it", func(c *Config) any { return &c.TenantsPath }},
+	{"ta_key", "VPNCERTD_TAKEY", "path to tls-crypt ta.key", func(c *Config) any { return &c.TAPath }},
+	{"tls_crypt_v2", "", "give every certificate its own tls-crypt-v2 client key (server key in <state_dir>/tls-crypt-v2) instead of the shared ta_key", func(c *Config) any { return &c.TLSCryptV2 }},
+	{"tls_crypt_v2_revoked_out", "", "deployment path of the refused tls-crypt-v2 client key list read by the verify script (empty disables)", func(c *Config) any { return &c.TLSCryptV2RevokedOut }},
+	{"template_dir", "", "directory of <name>.ovpn.tmpl bundle profile templates, shared by all tenants; reloaded on SIGHUP", func(c *Config) any { return &c.TemplateDir }},
+	{"pem_cache_dir", "", "bundle PEM cache directory (default: <state_dir>/.pemcache)", func(c *Config) any { return &c.PEMCacheDir }},
+	{"metrics_listen", "", "Prometheus metrics listener: unix:/path or loopback host:port (empty disables)", func(c *Config) any { return &c.MetricsAddr }},
+	{"ca_key_credential", "", "systemd credential holding the CA key passphrase", func(c *Config) any { return &c.CAKeyCred }},
+	{"ca_key_pass_fd", "", "read the CA key passphrase from this file descriptor", func(c *Config) any { return &c.CAKeyPassFD }},
+	{"key_backend", "", "CA key backend: file|pkcs11", func(c *Config) any { return &c.KeyBackend }},
+	{"pkcs11_module", "", "PKCS#11 module path (e.g. /usr/lib/softhsm/libsofthsm2.so)", func(c *Config) any { return &c.PKCS11Module }},
+	{"pkcs11_token", "", "PKCS#11 token label (overrides pkcs11_slot)", func(c *Config) any { return &c.PKCS11Token }},
+	{"pkcs11_slot", "", "PKCS#11 slot ID", func(c *Config) any { return &c.PKCS11Slot }},
+	{"pkcs11_key_label", "", "label of the CA private key on the token", func(c *Config) any { return &c.PKCS11KeyLabel }},
+	{"pkcs11_pin", "", "PIN source: credential:NAME|fd:N|file:PATH", func(c *Config) any { return &c.PKCS11PIN }},
+	{"read_write_deadline", "", "per-connection read/write deadline on the socket", func(c *Config) any { return &c.ReadWriteDeadline }},
+	{"shutdown_timeout", "", "grace period for in-flight requests on shutdown", func(c *Config) any { return &c.ShutdownTimeout }},
+	{"idempotency_retention", "", "how long responses are kept for replay by request_id (0 disables)", func(c *Config) any { return &c.IdempotencyRetention }},
+}
+
+func (o option) flagName() string { return strings.ReplaceAll(o.key, "_", "-") }
+func (o option) env() string      { return constants.EnvPrefix + strings.ToUpper(o.key) }
+
+// oldFlags are flag spellings from before the config file; they stay accepted.
+var oldFlags = map[string]string{"pki": "pki_dir", "state": "state_dir", "ta": "ta_key"}
+
+func set(p any, v string) error {
+	switch p := p.(type) {
+	case *string:
+		*p = v
+	case *bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("want true or false, got %q", v)
+		}
+		*p = b
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("want an integer, got %q", v)
+		}
+		*p = n
+	case *time.Duration:
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("want a duration like 30s or 168h, got %q", v)
+		}
+		*p = d
+	}
+	return nil
+}
+
+func format(p any) string {
+	switch p := p.(type) {
+	case *string:
+		return strconv.Quote(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *int:
+		return strconv.Itoa(*p)
+	case *time.Duration:
+		return p.String()
+	}
+	return ""
+}
+
+type flagValue struct {
+	def    string
+	raw    *string
+	seen   *bool
+	isBool bool
+}
+
+func (f flagValue) String() string {
+	if f.raw == nil {
+		return ""
+	}
+	return f.def
+}
+func (f flagValue) Set(v string) error { *f.raw, *f.seen = v, true; return nil }
+func (f flagValue) IsBoolFlag() bool   { return f.isBool }
+
+// Load resolves the configuration from args, the environment, the config file and
+// built-in defaults, in that order of precedence, and validates the result.
+func Load(args []string) (Config, error) {
+	c := defaults()
+	c.sources = map[string]string{}
+
+	fs := flag.NewFlagSet(constants.AppName, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	raw := make([]string, len(options))
+	seen := make([]bool, len(options))
+	def := defaults()
+	for i, o := range options {
+		_, isBool := o.field(&def).(*bool)
+		v := flagValue{def: strings.Trim(format(o.field(&def)), `"`), raw: &raw[i], seen: &seen[i], isBool: isBool}
+		fs.Var(v, o.flagName(), o.help)
+		for old, key := range oldFlags {
+			if key == o.key {
+				fs.Var(v, old, "alias of --"+o.flagName())
+			}
+		}
+	}
+	fs.Usage = func() {
+		fs.SetOutput(os.Stderr)
+		fmt.Fprintf(os.Stderr, "usage: %s [flags]\n       %s ca init|import ...\n       %s config print [flags]\n\n", constants.AppName, constants.AppName, constants.AppName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.Usage()
+		}
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	env := map[string]string{}
+	for _, o := range options {
+		if v, ok := os.LookupEnv(o.env()); ok && v != "" {
+			env[o.key] = o.env()
+		} else if o.legacy != "" {
+			if v, ok := os.LookupEnv(o.legacy); ok && v != "" {
+				env[o.key] = o.legacy
+				c.Warnings = append(c.Warnings, fmt.Sprintf("%s is deprecated, use %s", o.legacy, o.env()))
+			}
+		}
+	}
+
+	// The config path itself is resolved first: flag > env > default.
+	explicit := true
+	switch {
+	case seen[0]:
+		c.ConfigPath = raw[0]
+		c.sources["config"] = "flag --config"
+	case env["config"] != "":
+		c.ConfigPath = os.Getenv(env["config"])
+		c.sources["config"] = "env " + env["config"]
+	default:
+		explicit = false
+		c.sources["config"] = "default"
+	}
+	if c.ConfigPath != "" {
+		if err := c.loadFile(explicit); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for i, o := range options[1:] {
+		i++
+		if name, ok := env[o.key]; ok {
+			if err := set(o.field(&c), os.Getenv(name)); err != nil {
+				return Config{}, fmt.Errorf("%s (env %s): %w", o.key, name, err)
+			}
+			c.sources[o.key] = "env " + name
+		}
+		if seen[i] {
+			if err := set(o.field(&c), raw[i]); err != nil {
+				return Config{}, fmt.Errorf("%s (flag --%s): %w", o.key, o.flagName(), err)
+			}
+			c.sources[o.key] = "flag --" + o.flagName()
+		}
+	}
+	if err := c.validate(); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// loadFile applies the YAML config file. A missing file is only an error when
+// its path was given explicitly.
+func (c *Config) loadFile(explicit bool) error {
+	b, err := os.ReadFile(c.ConfigPath)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("config file %s: %w", c.ConfigPath, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	m := doc.Content[0]
+	if m.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s: top level must be a mapping", c.ConfigPath)
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+		o, ok := lookup(k.Value)
+		if !ok || o.key == "config" {
+			return fmt.Errorf("config file %s:%d: unknown key %q", c.ConfigPath, k.Line, k.Value)
+		}
+		if v.Kind != yaml.ScalarNode {
+			return fmt.Errorf("config file %s:%d: %s: want a scalar value", c.ConfigPath, v.Line, k.Value)
+		}
+		if err := set(o.field(c), v.Value); err != nil {
+			return fmt.Errorf("config file %s:%d: %s: %w", c.ConfigPath, v.Line, k.Value, err)
+		}
+		c.sources[o.key] = "file"
+	}
+	return nil
+}
+
+func lookup(key string) (option, bool) {
+	for _, o := range options {
+		if o.key == key {
+			return o, true
+		}
+	}
+	return option{}, false
+}
+
+func (c *Config) validate() error {
+	bad := func(key, msg string) error {
+		return fmt.Errorf("%s (%s): %s", key, c.Source(key), msg)
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return bad("log_level", "must be debug, info, warn or error")
+	}
+	switch c.CRLMode {
+	case constants.CRLModeConcat, constants.CRLModePerFile:
+	default:
+		return bad("crl_mode", "must be concat or per-file")
+	}
+	switch c.KeyBackend {
+	case constants.KeyBackendFile:
+	case constants.KeyBackendPKCS11:
+		if c.PKCS11Module == "" {
+			return bad("pkcs11_module", "required with key_backend pkcs11")
+		}
+	default:
+		return bad("key_backend", "must be file or pkcs11")
+	}
+	switch {
+	case c.SocketPath == "":
+		return bad("socket", "must not be empty")
+	case c.HTTPSocket != "" && c.HTTPSocket == c.SocketPath:
+		return bad("http_socket", "must differ from socket")
+	case c.GRPCSocket != "" && (c.GRPCSocket == c.SocketPath || c.GRPCSocket == c.HTTPSocket):
+		return bad("grpc_socket", "must differ from socket and http_socket")
+	case c.TenantsPath == "" && (c.PKIDir == "" || c.StateDir == ""):
+		return errors.New("pki_dir and state_dir must not be empty")
+	case c.CAKeyPassFD < -1:
+		return bad("ca_key_pass_fd", "must be a file descriptor or -1")
+	case c.CRLValidity < time.Hour:
+		return bad("crl_validity", "must be at least 1h")
+	case c.ReadWriteDeadline <= 0:
+		return bad("read_write_deadline", "must be positive")
+	case c.ShutdownTimeout < 0:
+		return bad("shutdown_timeout", "must not be negative")
+	case c.IdempotencyRetention < 0:
+		return bad("idempotency_retention", "must not be negative")
+	}
+	return nil
+}
+
+// Source reports where the effective value of key came from.
+func (c Config) Source(key string) string {
+	if s, ok := c.sources[key]; ok {
+		return s
+	}
+	return "default"
+}
+
+// Print writes the effective configuration as a config file, each line annotated
+// with the source of its value.
+func (c Config) Print(w io.Writer) error {
+	for _, o := range options[1:] {
+		if _, err := fmt.Fprintf(w, "%s: %s # %s\n", o.key, format(o.field(&c)), c.Source(o.key)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
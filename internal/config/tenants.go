@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/validate"
+)
+
+// Tenant is one OpenVPN instance served by the daemon.
+type Tenant struct {
+	Name     string `yaml:"name"`
+	PKIDir   string `yaml:"pki_dir"`
+	StateDir string `yaml:"state_dir"`
+	Policy   string `yaml:"policy"`
+	CRLOut   string `yaml:"crl_out"`
+	CRLMode  string `yaml:"crl_mode"`
+	TAPath   string `yaml:"ta_key"`
+	ActiveCA string `yaml:"active_ca"`
+	PEMCache string `yaml:"pem_cache_dir"`
+
+	// TLSCryptV2 replaces the shared TA key with per-certificate client keys;
+	// TLSCryptV2RevokedOut receives the list of keys the verify script refuses.
+	TLSCryptV2           bool   `yaml:"tls_crypt_v2"`
+	TLSCryptV2RevokedOut string `yaml:"tls_crypt_v2_revoked_out"`
+}
+
+// Tenants is the --tenants file.
+type Tenants struct {
+	Default string   `yaml:"default"`
+	Tenants []Tenant `yaml:"tenants"`
+}
+
+// DefaultTenant is the name of the single tenant built from flags when no
+// --tenants file is given.
+const DefaultTenant = "default"
+
+// LoadTenants returns the tenants from c.TenantsPath, or a single tenant built from
+// the per-instance settings (pki_dir, state_dir, policy, ...) when no file is configured.
+func (c Config) LoadTenants() (Tenants, error) {
+	if c.TenantsPath == "" {
+		return Tenants{Tenants: []Tenant{{
+			Name:     DefaultTenant,
+			PKIDir:   c.PKIDir,
+			StateDir: c.StateDir,
+			Policy:   c.PolicyPath,
+			CRLOut:   c.CRLOutPath,
+			CRLMode:  c.CRLMode,
+			TAPath:   c.TAPath,
+			ActiveCA: c.ActiveCA,
+			PEMCache: c.PEMCacheDir,
+
+			TLSCryptV2:           c.TLSCryptV2,
+			TLSCryptV2RevokedOut: c.TLSCryptV2RevokedOut,
+		}}}, nil
+	}
+	b, err := os.ReadFile(c.TenantsPath)
+	if err != nil {
+		return Tenants{}, err
+	}
+	var ts Tenants
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&ts); err != nil {
+		return Tenants{}, fmt.Errorf("tenants file %s: %w", c.TenantsPath, err)
+	}
+	if len(ts.Tenants) == 0 {
+		return Tenants{}, errors.New("no tenants")
+	}
+	names := map[string]bool{}
+	states := map[string]string{}
+	for i := range ts.Tenants {
+		t := &ts.Tenants[i]
+		if err := validate.Tenant(t.Name); err != nil {
+			return Tenants{}, fmt.Errorf("tenant %q: invalid name", t.Name)
+		}
+		if names[t.Name] {
+			return Tenants{}, fmt.Errorf("tenant %q: duplicate", t.Name)
+		}
+		names[t.Name] = true
+		if t.PKIDir == "" || t.StateDir == "" {
+			return Tenants{}, fmt.Errorf("tenant %s: pki_dir and state_dir required", t.Name)
+		}
+		state := filepath.Clean(t.StateDir)
+		if other, ok := states[state]; ok {
+			return Tenants{}, fmt.Errorf("tenant %s: state_dir shared with %s", t.Name, other)
+		}
+		states[state] = t.Name
+		switch t.CRLMode {
+		case "":
+			t.CRLMode = constants.CRLModeConcat
+		case constants.CRLModeConcat, constants.CRLModePerFile:
+		default:
+			return Tenants{}, fmt.Errorf("tenant %s: crl_mode must be concat or per-file", t.Name)
+		}
+	}
+	if ts.Default != "" && !names[ts.Default] {
+		return Tenants{}, fmt.Errorf("default tenant %q not listed", ts.Default)
+	}
+	return ts, nil
+}
@@ -5,12 +5,11 @@ import (
 )
 
 const (
-	AppName     = "vpn-certd"
-	EnvSocket   = "VPN_CERTD_SOCKET"
-	EnvPKIDir   = "VPN_CERTD_PKI_DIR"
-	EnvStateDir = "VPN_CERTD_STATE_DIR"
-	EnvLogLevel = "VPN_CERTD_LOG_LEVEL"
+	AppName   = "vpn-certd"
+	EnvPrefix = "VPN_CERTD_"
+	EnvPKIDir = "VPN_CERTD_PKI_DIR"
 
+	DefaultConfigPath = "/etc/vpn-certd/vpn-certd.yaml"
 	DefaultSocketPath = "/run/vpn-certd.sock"
 	DefaultPKIDir     = "/etc/vpn-certd/pki"
 	DefaultStateDir   = "/var/lib/vpn-certd"
@@ -18,24 +17,65 @@ const (
 )
 
 const (
-	ShutdownTimeout   = 5 * time.Second
-	ReadWriteDeadline = 30 * time.Second
+	ShutdownTimeout    = 5 * time.Second
+	ReadWriteDeadline  = 30 * time.Second
+	DefaultCRLValidity = 7 * 24 * time.Hour
+	UnlockFailDelay    = time.Second
+
+	DefaultIdempotencyRetention = 24 * time.Hour
+	IdempotencyPurgeInterval    = time.Hour
+
+	// RPCIdleTimeout closes a JSON-RPC connection with nothing in flight and no
+	// subscription after this long without a request.
+	RPCIdleTimeout = 5 * time.Minute
+	// RPCMaxInFlight bounds concurrently running calls per JSON-RPC connection;
+	// reading pauses until one finishes.
+	RPCMaxInFlight = 16
+
+	// MaxBatchItems bounds the rows of one BATCH request.
+	MaxBatchItems = 100
+	// ListIssuedMax is how many of the latest records LIST_ISSUED returns in
+	// one response; gRPC ListIssued streams all of them.
+	ListIssuedMax = 200
 )
 
 const (
 	DirPerm0700    = 0o700
 	FilePerm0600   = 0o600
 	SocketPerm0600 = 0o600
+
+	MetricsSocketPerm = 0o660
+
+	MinFreeBytes = 64 << 20
 )
 
 const (
-	EnvPolicyPath = "VPNCERTD_POLICY"
-	EnvCRLOutPath = "VPNCERTD_CRL_OUT"
 	DefaultPolicy = "/etc/vpn-certd/policy.yaml"
 	DefaultCRLOut = "/etc/openvpn/crl.pem"
+	DefaultTAPath = "/etc/openvpn/ta.key"
+	// DefaultTemplateDir holds <name>.ovpn.tmpl bundle profile templates.
+	DefaultTemplateDir = "/etc/vpn-certd/templates"
+
+	DefaultTLSCryptV2RevokedOut = "/etc/openvpn/tls-crypt-v2-revoked.txt"
+	// TLSCryptV2Dir holds the tls-crypt-v2 server key and client keys under a
+	// tenant's state directory.
+	TLSCryptV2Dir = "tls-crypt-v2"
+
+	CRLModeConcat  = "concat"
+	CRLModePerFile = "per-file"
 )
 
 const (
-	EnvTAPath     = "VPNCERTD_TAKEY"
-	DefaultTAPath = "/etc/openvpn/ta.key"
+	EventBufferSize   = 1024
+	EventHeartbeat    = 30 * time.Second
+	SubscriberBacklog = 64
+)
+
+const (
+	DefaultCAKeyCredential = "vpn-certd-ca-key"
+)
+
+const (
+	KeyBackendFile   = "file"
+	KeyBackendPKCS11 = "pkcs11"
 )
@@ -0,0 +1,107 @@
+package expiry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/hooks"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+)
+
+const (
+	KindCertExpiring = "cert_expiring"
+	KindCAExpiring   = "ca_expiring"
+)
+
+type Event struct {
+	Kind       string `json:"kind"`
+	CAID       string `json:"ca_id,omitempty"`
+	Serial     string `json:"serial"`
+	CN         string `json:"cn"`
+	Profile    string `json:"profile,omitempty"`
+	NotAfter   string `json:"not_after"`
+	DaysLeft   int    `json:"days_left"`
+	WindowDays int    `json:"window_days"`
+	At         string `json:"at"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, ev Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type LogNotifier struct {
+	Log *slog.Logger
+}
+
+func (n LogNotifier) Notify(_ context.Context, ev Event) error {
+	n.Log.Warn(ev.Kind,
+		"ca_id", ev.CAID,
+		"cn", ev.CN,
+		"serial", ev.Serial,
+		"not_after", ev.NotAfter,
+		"days_left", ev.DaysLeft,
+		"window_days", ev.WindowDays,
+	)
+	return nil
+}
+
+type ExecNotifier struct {
+	Command []string
+	Timeout time.Duration
+	Log     *slog.Logger
+}
+
+func (n ExecNotifier) Notify(ctx context.Context, ev Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	env := map[string]string{
+		"VPN_CERTD_EVENT":       ev.Kind,
+		"VPN_CERTD_CA_ID":       ev.CAID,
+		"VPN_CERTD_CN":          ev.CN,
+		"VPN_CERTD_SERIAL":      ev.Serial,
+		"VPN_CERTD_PROFILE":     ev.Profile,
+		"VPN_CERTD_NOT_AFTER":   ev.NotAfter,
+		"VPN_CERTD_DAYS_LEFT":   strconv.Itoa(ev.DaysLeft),
+		"VPN_CERTD_WINDOW_DAYS": strconv.Itoa(ev.WindowDays),
+	}
+	res, err := hooks.Run(ctx, n.Command, n.Timeout, env, append(b, '\n'))
+	if err != nil {
+		n.Log.Warn("expiry_exec_failed", "cmd", n.Command[0], "serial", ev.Serial, "exit", res.ExitCode, "output", res.Output, "err", err.Error())
+		return err
+	}
+	n.Log.Debug("expiry_exec_ok", "cmd", n.Command[0], "serial", ev.Serial, "duration_ms", res.Duration.Milliseconds())
+	return nil
+}
+
+type FileNotifier struct {
+	Dir string
+}
+
+func (n FileNotifier) Notify(_ context.Context, ev Event) error {
+	b, err := json.MarshalIndent(ev, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%s-%dd.json", ev.Kind, ev.CAID, ev.Serial, ev.WindowDays)
+	return security.AtomicWrite(filepath.Join(n.Dir, name), append(b, '\n'), 0o640)
+}
@@ -0,0 +1,211 @@
+package expiry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+)
+
+const fileNotified = "expiry-notified.json"
+
+const day = 24 * time.Hour
+
+type Scanner struct {
+	CAs      *pki.Set
+	Windows  []int
+	Interval time.Duration
+	Notifier Notifier
+	Log      *slog.Logger
+	mu       sync.Mutex
+}
+
+type notifiedDB struct {
+	// Notified maps a serial (prefixed "<ca id>:" outside the default CA, or
+	// "ca:<serial>" for a CA certificate) to the tightest window already reported.
+	Notified map[string]int `json:"notified"`
+}
+
+func (s *Scanner) Run(ctx context.Context) {
+	if err := s.Scan(ctx, time.Now()); err != nil {
+		s.Log.Warn("expiry_scan_failed", "err", err.Error())
+	}
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if err := s.Scan(ctx, now); err != nil {
+				s.Log.Warn("expiry_scan_failed", "err", err.Error())
+			}
+		}
+	}
+}
+
+func (s *Scanner) Scan(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := s.load()
+	if err != nil {
+		return err
+	}
+	list, err := List(s.CAs, s.Windows, MaxWindow(s.Windows), now)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(list)+1)
+	var errs []error
+	for _, it := range list {
+		key := it.Serial
+		if it.CAID != pki.DefaultCAID {
+			key = it.CAID + ":" + it.Serial
+		}
+		seen[key] = true
+		ev := Event{
+			CAID:       it.CAID,
+			Kind:       KindCertExpiring,
+			Serial:     it.Serial,
+			CN:         it.CN,
+			Profile:    it.Profile,
+			NotAfter:   it.NotAfter,
+			DaysLeft:   it.DaysLeft,
+			WindowDays: it.WindowDays,
+		}
+		errs = append(errs, s.emit(ctx, db, key, ev, now))
+	}
+
+	for _, c := range s.CAs.All() {
+		ca := c.Cert
+		key := "ca:" + ca.SerialNumber.String()
+		if w, left := Window(s.Windows, ca.NotAfter.Sub(now)); w > 0 && left >= 0 {
+			seen[key] = true
+			ev := Event{
+				Kind:       KindCAExpiring,
+				CAID:       c.ID,
+				Serial:     ca.SerialNumber.String(),
+				CN:         ca.Subject.CommonName,
+				NotAfter:   ca.NotAfter.UTC().Format(time.RFC3339),
+				DaysLeft:   left,
+				WindowDays: w,
+			}
+			errs = append(errs, s.emit(ctx, db, key, ev, now))
+		}
+	}
+
+	for k := range db.Notified {
+		if !seen[k] {
+			delete(db.Notified, k)
+		}
+	}
+	if err := s.save(db); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scanner) emit(ctx context.Context, db *notifiedDB, key string, ev Event, now time.Time) error {
+	if prev, ok := db.Notified[key]; ok && prev <= ev.WindowDays {
+		return nil
+	}
+	ev.At = now.UTC().Format(time.RFC3339)
+	if err := s.Notifier.Notify(ctx, ev); err != nil {
+		return err
+	}
+	db.Notified[key] = ev.WindowDays
+	return nil
+}
+
+func (s *Scanner) path() string { return filepath.Join(s.CAs.State, fileNotified) }
+
+func (s *Scanner) load() (*notifiedDB, error) {
+	db := &notifiedDB{Notified: map[string]int{}}
+	b, err := os.ReadFile(s.path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return db, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, db); err != nil {
+		return nil, err
+	}
+	if db.Notified == nil {
+		db.Notified = map[string]int{}
+	}
+	return db, nil
+}
+
+func (s *Scanner) save(db *notifiedDB) error {
+	b, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+	return security.AtomicWrite(s.path(), b, 0o600)
+}
+
+// List returns active, non-revoked certificates expiring within the given number of days,
+// soonest first. WindowDays is the tightest configured window the certificate falls into.
+func List(cas *pki.Set, windows []int, withinDays int, now time.Time) ([]api.ExpiringMeta, error) {
+	active, err := cas.ActiveIssued(now)
+	if err != nil {
+		return nil, err
+	}
+	var out []api.ExpiringMeta
+	for _, it := range active {
+		na, err := time.Parse(time.RFC3339, it.NotAfter)
+		if err != nil {
+			continue
+		}
+		remaining := na.Sub(now)
+		if remaining > time.Duration(withinDays)*day {
+			continue
+		}
+		w, left := Window(windows, remaining)
+		out = append(out, api.ExpiringMeta{
+			CAID:       it.CAID,
+			Serial:     it.Serial,
+			CN:         it.CN,
+			Profile:    it.Profile,
+			NotAfter:   it.NotAfter,
+			DaysLeft:   left,
+			WindowDays: w,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].NotAfter < out[j].NotAfter })
+	return out, nil
+}
+
+// Window returns the smallest window (in days) containing remaining, or 0 if none does,
+// together with the whole days left.
+func Window(windows []int, remaining time.Duration) (int, int) {
+	w := 0
+	for _, d := range windows {
+		if remaining <= time.Duration(d)*day && (w == 0 || d < w) {
+			w = d
+		}
+	}
+	return w, int(remaining / day)
+}
+
+func MaxWindow(windows []int) int {
+	m := 0
+	for _, d := range windows {
+		if d > m {
+			m = d
+		}
+	}
+	return m
+}
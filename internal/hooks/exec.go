@@ -0,0 +1,68 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+)
+
+const maxOutput = 16 * 1024
+
+type Result struct {
+	ExitCode int
+	Output   string
+	Duration time.Duration
+}
+
+func Run(ctx context.Context, argv []string, timeout time.Duration, env map[string]string, stdin []byte) (Result, error) {
+	if len(argv) == 0 || argv[0] == "" {
+		return Result{}, errors.New("hook: empty command")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+env[k])
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	res := Result{Duration: time.Since(start), Output: truncate(out.Bytes())}
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return res, fmt.Errorf("hook %s: timeout after %s", argv[0], timeout)
+		}
+		return res, fmt.Errorf("hook %s: %w", argv[0], err)
+	}
+	return res, nil
+}
+
+func truncate(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if len(b) > maxOutput {
+		b = b[:maxOutput]
+	}
+	return string(b)
+}
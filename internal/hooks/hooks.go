@@ -0,0 +1,137 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type Event string
+
+const (
+	EventIssue  Event = "issue"
+	EventRevoke Event = "revoke"
+	EventRenew  Event = "renew"
+	EventCRL    Event = "crl"
+)
+
+// Hook is a configured executable run on lifecycle events. Hooks marked Required
+// run synchronously and their failure is reported to the caller next to the
+// committed result; all others run in the background and only log failures.
+type Hook struct {
+	Name     string        `yaml:"name"`
+	On       []Event       `yaml:"on"`
+	Command  []string      `yaml:"command"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Required bool          `yaml:"required"`
+}
+
+type Payload struct {
+	Event    Event  `json:"event"`
+	Tenant   string `json:"tenant,omitempty"`
+	CN       string `json:"cn,omitempty"`
+	Serial   string `json:"serial,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	NotAfter string `json:"not_after,omitempty"`
+	Time     string `json:"time"`
+}
+
+func (h *Hook) Validate() error {
+	if h.Name == "" {
+		return errors.New("name required")
+	}
+	if len(h.Command) == 0 || h.Command[0] == "" {
+		return errors.New("command required")
+	}
+	if len(h.On) == 0 {
+		return errors.New("on required")
+	}
+	for _, e := range h.On {
+		switch e {
+		case EventIssue, EventRevoke, EventRenew, EventCRL:
+		default:
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = 30 * time.Second
+	}
+	return nil
+}
+
+func (h Hook) handles(e Event) bool {
+	for _, x := range h.On {
+		if x == e {
+			return true
+		}
+	}
+	return false
+}
+
+type Runner struct {
+	Hooks []Hook
+	Log   *slog.Logger
+}
+
+// Fire runs every hook subscribed to p.Event and returns the names of the
+// required ones that failed; the failures themselves are logged.
+func (r Runner) Fire(ctx context.Context, p Payload) (failed []string) {
+	if p.Time == "" {
+		p.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	stdin, _ := json.Marshal(p)
+	stdin = append(stdin, '\n')
+	env := map[string]string{
+		"VPN_CERTD_EVENT":     string(p.Event),
+		"VPN_CERTD_TENANT":    p.Tenant,
+		"VPN_CERTD_CN":        p.CN,
+		"VPN_CERTD_SERIAL":    p.Serial,
+		"VPN_CERTD_PROFILE":   p.Profile,
+		"VPN_CERTD_REASON":    p.Reason,
+		"VPN_CERTD_NOT_AFTER": p.NotAfter,
+	}
+
+	for _, h := range r.Hooks {
+		if !h.handles(p.Event) {
+			continue
+		}
+		if h.Required {
+			if err := r.run(ctx, h, p, env, stdin); err != nil {
+				failed = append(failed, h.Name)
+			}
+			continue
+		}
+		go func(h Hook) {
+			_ = r.run(context.Background(), h, p, env, stdin)
+		}(h)
+	}
+	return failed
+}
+
+func (r Runner) run(ctx context.Context, h Hook, p Payload, env map[string]string, stdin []byte) error {
+	res, err := Run(ctx, h.Command, h.Timeout, env, stdin)
+	if err != nil {
+		r.Log.Warn("hook_failed",
+			"hook", h.Name,
+			"event", string(p.Event),
+			"serial", p.Serial,
+			"required", h.Required,
+			"exit", res.ExitCode,
+			"output", res.Output,
+			"err", err.Error(),
+		)
+		return err
+	}
+	r.Log.Info("hook_ok",
+		"hook", h.Name,
+		"event", string(p.Event),
+		"serial", p.Serial,
+		"duration_ms", res.Duration.Milliseconds(),
+		"output", res.Output,
+	)
+	return nil
+}
@@ -0,0 +1,34 @@
+package hsm
+
+import (
+	"crypto"
+	"errors"
+)
+
+// Config selects a private key object on a PKCS#11 token. Exactly one of
+// TokenLabel or Slot identifies the token; Slot is ignored when TokenLabel is set.
+type Config struct {
+	Module     string
+	TokenLabel string
+	Slot       int
+	KeyLabel   string
+	PIN        []byte
+}
+
+// Signer is a crypto.Signer whose private key never leaves the token.
+type Signer interface {
+	crypto.Signer
+	Close() error
+}
+
+var ErrUnsupported = errors.New("pkcs11: this binary was built without cgo; rebuild with CGO_ENABLED=1")
+
+func (c Config) validate() error {
+	if c.Module == "" {
+		return errors.New("pkcs11: module path required")
+	}
+	if c.KeyLabel == "" {
+		return errors.New("pkcs11: key label required")
+	}
+	return nil
+}
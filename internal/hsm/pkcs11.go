@@ -0,0 +1,288 @@
+//go:build cgo
+
+package hsm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// modules holds one initialized context per module path. C_Initialize may run
+// only once per process and C_Finalize ends every session of the module, so
+// each CA key gets its own session on the shared context instead.
+var modules = struct {
+	sync.Mutex
+	m map[string]*module
+}{m: map[string]*module{}}
+
+type module struct {
+	ctx  *pkcs11.Ctx
+	refs int
+}
+
+func acquire(path string) (*pkcs11.Ctx, error) {
+	modules.Lock()
+	defer modules.Unlock()
+	if m, ok := modules.m[path]; ok {
+		m.refs++
+		return m.ctx, nil
+	}
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("pkcs11: cannot load module %s", path)
+	}
+	if err := ctx.Initialize(); err != nil && !isErr(err, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("pkcs11: initialize: %w", err)
+	}
+	modules.m[path] = &module{ctx: ctx, refs: 1}
+	return ctx, nil
+}
+
+// release drops one reference and finalizes the module with the last one.
+func release(path string) {
+	modules.Lock()
+	defer modules.Unlock()
+	m, ok := modules.m[path]
+	if !ok {
+		return
+	}
+	if m.refs--; m.refs > 0 {
+		return
+	}
+	delete(modules.m, path)
+	_ = m.ctx.Finalize()
+	m.ctx.Destroy()
+}
+
+func isErr(err error, code uint) bool {
+	var e pkcs11.Error
+	return errors.As(err, &e) && uint(e) == code
+}
+
+type signer struct {
+	mu      sync.Mutex
+	module  string
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	pub     crypto.PublicKey
+}
+
+// Open logs into the token and locates the private key labelled cfg.KeyLabel.
+// The public half is taken from pub (the CA certificate's key), since that is
+// what relying parties verify against.
+func Open(cfg Config, pub crypto.PublicKey) (Signer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("pkcs11: unsupported CA key type %T", pub)
+	}
+	ctx, err := acquire(cfg.Module)
+	if err != nil {
+		return nil, err
+	}
+	s := &signer{module: cfg.Module, ctx: ctx, pub: pub}
+	if err := s.open(cfg); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	if err := selfTest(s); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// selfTest signs a random digest and verifies it against the certificate's key,
+// so a label pointing at the wrong key fails at startup rather than at issuance.
+func selfTest(s *signer) error {
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		return err
+	}
+	digest := sha256.Sum256(msg)
+	sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	switch pub := s.pub.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			err = errors.New("bad signature")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("pkcs11: token key does not match CA certificate: %w", err)
+	}
+	return nil
+}
+
+func (s *signer) open(cfg Config) error {
+	slot, err := findSlot(s.ctx, cfg)
+	if err != nil {
+		return err
+	}
+	s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("pkcs11: open session: %w", err)
+	}
+	// the login belongs to the token, so a second CA on it is already logged in
+	if err := s.ctx.Login(s.session, pkcs11.CKU_USER, string(cfg.PIN)); err != nil && !isErr(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		return fmt.Errorf("pkcs11: login: %w", err)
+	}
+	tpl := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, cfg.KeyLabel),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, tpl); err != nil {
+		return fmt.Errorf("pkcs11: find key: %w", err)
+	}
+	objs, _, err := s.ctx.FindObjects(s.session, 2)
+	_ = s.ctx.FindObjectsFinal(s.session)
+	if err != nil {
+		return fmt.Errorf("pkcs11: find key: %w", err)
+	}
+	switch len(objs) {
+	case 0:
+		return fmt.Errorf("pkcs11: no private key labelled %q", cfg.KeyLabel)
+	case 1:
+		s.key = objs[0]
+		return nil
+	default:
+		return fmt.Errorf("pkcs11: several private keys labelled %q", cfg.KeyLabel)
+	}
+}
+
+func findSlot(ctx *pkcs11.Ctx, cfg Config) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("pkcs11: slot list: %w", err)
+	}
+	for _, slot := range slots {
+		if cfg.TokenLabel == "" {
+			if slot == uint(cfg.Slot) {
+				return slot, nil
+			}
+			continue
+		}
+		info, err := ctx.GetTokenInfo(slot)
+		if err == nil && info.Label == cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+	if cfg.TokenLabel != "" {
+		return 0, fmt.Errorf("pkcs11: no token labelled %q", cfg.TokenLabel)
+	}
+	return 0, fmt.Errorf("pkcs11: no token in slot %d", cfg.Slot)
+}
+
+func (s *signer) Public() crypto.PublicKey { return s.pub }
+
+func (s *signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mech *pkcs11.Mechanism
+	data := digest
+	switch s.pub.(type) {
+	case *rsa.PublicKey:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			m, err := pssMechanism(pss, opts.HashFunc())
+			if err != nil {
+				return nil, err
+			}
+			mech = m
+			break
+		}
+		prefix, ok := digestInfoPrefix[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("pkcs11: unsupported hash %v", opts.HashFunc())
+		}
+		mech = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)
+		data = append(append([]byte{}, prefix...), digest...)
+	case *ecdsa.PublicKey:
+		mech = pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{mech}, s.key); err != nil {
+		return nil, fmt.Errorf("pkcs11: sign init: %w", err)
+	}
+	sig, err := s.ctx.Sign(s.session, data)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: sign: %w", err)
+	}
+	if _, ok := s.pub.(*ecdsa.PublicKey); ok {
+		return ecdsaDER(sig)
+	}
+	return sig, nil
+}
+
+func (s *signer) Close() error {
+	if s.ctx == nil {
+		return nil
+	}
+	// no C_Logout: it would log out the token's other sessions too; closing
+	// the last session ends the login anyway
+	if s.session != 0 {
+		_ = s.ctx.CloseSession(s.session)
+	}
+	release(s.module)
+	s.ctx = nil
+	return nil
+}
+
+// ecdsaDER converts the raw r||s signature returned by CKM_ECDSA into the ASN.1
+// form expected by crypto/x509.
+func ecdsaDER(raw []byte) ([]byte, error) {
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return nil, errors.New("pkcs11: malformed ECDSA signature")
+	}
+	n := len(raw) / 2
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		R: new(big.Int).SetBytes(raw[:n]),
+		S: new(big.Int).SetBytes(raw[n:]),
+	})
+}
+
+func pssMechanism(opts *rsa.PSSOptions, h crypto.Hash) (*pkcs11.Mechanism, error) {
+	var hashMech, mgf uint
+	switch h {
+	case crypto.SHA256:
+		hashMech, mgf = pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256
+	case crypto.SHA384:
+		hashMech, mgf = pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384
+	case crypto.SHA512:
+		hashMech, mgf = pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512
+	default:
+		return nil, fmt.Errorf("pkcs11: unsupported PSS hash %v", h)
+	}
+	salt := opts.SaltLength
+	if salt == rsa.PSSSaltLengthEqualsHash || salt == rsa.PSSSaltLengthAuto {
+		salt = h.Size()
+	}
+	return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(hashMech, mgf, uint(salt))), nil
+}
+
+// DER prefixes of DigestInfo for PKCS#1 v1.5, as in crypto/rsa.
+var digestInfoPrefix = map[crypto.Hash][]byte{
+	crypto.SHA1:   {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
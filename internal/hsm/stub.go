@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package hsm
+
+import "crypto"
+
+func Open(cfg Config, _ crypto.PublicKey) (Signer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return nil, ErrUnsupported
+}
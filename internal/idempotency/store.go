@@ -0,0 +1,187 @@
+// Package idempotency remembers the responses of mutating requests by their
+// client-supplied request_id so that a retry gets the original answer instead
+// of issuing or revoking a second time.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+)
+
+// ErrReused is returned when a request_id comes back with a different request.
+var ErrReused = errors.New("request_id reused for a different request")
+
+// Record is one stored result. Response never holds a private key.
+type Record struct {
+	RequestID   string       `json:"request_id"`
+	Op          api.Op       `json:"op"`
+	Fingerprint string       `json:"fingerprint"`
+	Created     time.Time    `json:"created"`
+	Response    api.Response `json:"response"`
+	ErrCode     xerr.Code    `json:"err_code,omitempty"`
+	ErrMsg      string       `json:"err_msg,omitempty"`
+	ErrField    string       `json:"err_field,omitempty"`
+}
+
+// Err rebuilds the error the original request returned alongside its response.
+func (r *Record) Err() error {
+	if r.ErrCode == "" {
+		return nil
+	}
+	return xerr.E{Code: r.ErrCode, Msg: r.ErrMsg, Field: r.ErrField}
+}
+
+// Store keeps one JSON file per request_id in Dir for Retention.
+type Store struct {
+	Dir       string
+	Retention time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+func New(dir string, retention time.Duration) *Store {
+	return &Store{Dir: dir, Retention: retention, locks: map[string]*keyLock{}}
+}
+
+// Applies reports whether op is replayed from the store.
+func Applies(op api.Op) bool {
+	switch op {
+	case api.OpSign, api.OpGenKeyAndSign, api.OpRevoke, api.OpRevokeTLSKey:
+		return true
+	}
+	return false
+}
+
+// Fingerprint identifies what a request asks for. The passphrase is left out so
+// that nothing derived from it is written to disk.
+func Fingerprint(req api.Request) string {
+	b, _ := json.Marshal([]string{
+		string(req.Op), req.CN, string(req.Profile), string(req.KeyType),
+		req.CSRPEM, req.Serial, req.Reason, req.CAID,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Lock serialises requests carrying the same ID, so a retry that arrives while
+// the original is still running waits for its result.
+func (s *Store) Lock(id string) (unlock func()) {
+	s.mu.Lock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &keyLock{}
+		s.locks[id] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Get returns the live record for id, or nil. A record stored for a different
+// request yields ErrReused.
+func (s *Store) Get(id, fingerprint string, now time.Time) (*Record, error) {
+	b, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Record
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	if now.Sub(r.Created) > s.Retention {
+		return nil, nil
+	}
+	if r.Fingerprint != fingerprint {
+		return nil, ErrReused
+	}
+	return &r, nil
+}
+
+// Put stores resp and err under id. KeyPEMEnc is dropped.
+func (s *Store) Put(id string, req api.Request, resp api.Response, err error, now time.Time) error {
+	resp.KeyPEMEnc = ""
+	r := Record{
+		RequestID:   id,
+		Op:          req.Op,
+		Fingerprint: Fingerprint(req),
+		Created:     now.UTC(),
+		Response:    resp,
+	}
+	if err != nil {
+		e := xerr.Public(err)
+		r.ErrCode, r.ErrMsg, r.ErrField = e.Code, e.Msg, e.Field
+	}
+	b, mErr := json.Marshal(&r)
+	if mErr != nil {
+		return mErr
+	}
+	if mErr := os.MkdirAll(s.Dir, 0o700); mErr != nil {
+		return mErr
+	}
+	return security.AtomicWrite(s.path(id), b, 0o600)
+}
+
+// Purge removes records older than Retention and returns how many it removed.
+func (s *Store) Purge(now time.Time) (int, error) {
+	ents, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range ents {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.Dir, e.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var r Record
+		if json.Unmarshal(b, &r) == nil && now.Sub(r.Created) <= s.Retention {
+			continue
+		}
+		if os.Remove(path) == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// path hashes id so that arbitrary client IDs map to safe file names.
+func (s *Store) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:16])+".json")
+}
@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+)
+
+const namespace = "vpn_certd"
+
+// opUnknown labels requests whose op is not in api.Ops.
+const opUnknown api.Op = "unknown"
+
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type reqKey struct {
+	op   api.Op
+	code string
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Gauge is sampled on every scrape. Collect returns false when the value is
+// currently unknown, in which case the series is omitted. Gauges sharing a Name
+// must differ in Labels.
+type Gauge struct {
+	Name    string
+	Help    string
+	Labels  map[string]string
+	Collect func() (float64, bool)
+}
+
+// Registry holds request counters and latency histograms keyed by op and
+// xerr.Code, plus scrape-time gauges, and renders them in the Prometheus text format.
+type Registry struct {
+	mu        sync.Mutex
+	requests  map[reqKey]uint64
+	durations map[api.Op]*histogram
+	gauges    []Gauge
+}
+
+func New() *Registry {
+	return &Registry{
+		requests:  map[reqKey]uint64{},
+		durations: map[api.Op]*histogram{},
+	}
+}
+
+// ObserveRequest counts one request. Ops outside api.Ops are counted as
+// "unknown" so clients cannot grow the label set.
+func (r *Registry) ObserveRequest(op api.Op, err error, d time.Duration) {
+	if !op.Known() {
+		op = opUnknown
+	}
+	code := "ok"
+	if err != nil {
+		code = string(xerr.CodeOf(err))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[reqKey{op: op, code: code}]++
+	h := r.durations[op]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		r.durations[op] = h
+	}
+	sec := d.Seconds()
+	for i, b := range durationBuckets {
+		if sec <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += sec
+	h.count++
+}
+
+func (r *Registry) AddGauge(g Gauge) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gauges = append(r.gauges, g)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.Write(w)
+}
+
+func (r *Registry) Write(out io.Writer) error {
+	r.mu.Lock()
+	reqKeys := make([]reqKey, 0, len(r.requests))
+	for k := range r.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		if reqKeys[i].op != reqKeys[j].op {
+			return reqKeys[i].op < reqKeys[j].op
+		}
+		return reqKeys[i].code < reqKeys[j].code
+	})
+	requests := make([]uint64, len(reqKeys))
+	for i, k := range reqKeys {
+		requests[i] = r.requests[k]
+	}
+	ops := make([]api.Op, 0, len(r.durations))
+	for op := range r.durations {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i] < ops[j] })
+	hists := make([]histogram, len(ops))
+	for i, op := range ops {
+		h := r.durations[op]
+		hists[i] = histogram{counts: append([]uint64(nil), h.counts...), sum: h.sum, count: h.count}
+	}
+	gauges := append([]Gauge(nil), r.gauges...)
+	r.mu.Unlock()
+
+	w := bufio.NewWriter(out)
+	name := namespace + "_requests_total"
+	header(w, name, "Requests handled, by op and result code.", "counter")
+	for i, k := range reqKeys {
+		fmt.Fprintf(w, "%s{op=%q,code=%q} %d\n", name, string(k.op), k.code, requests[i])
+	}
+
+	name = namespace + "_request_duration_seconds"
+	header(w, name, "Request handling latency, by op.", "histogram")
+	for i, op := range ops {
+		h := hists[i]
+		for j, b := range durationBuckets {
+			fmt.Fprintf(w, "%s_bucket{op=%q,le=%q} %d\n", name, string(op), formatFloat(b), h.counts[j])
+		}
+		fmt.Fprintf(w, "%s_bucket{op=%q,le=\"+Inf\"} %d\n", name, string(op), h.count)
+		fmt.Fprintf(w, "%s_sum{op=%q} %s\n", name, string(op), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{op=%q} %d\n", name, string(op), h.count)
+	}
+
+	sort.SliceStable(gauges, func(i, j int) bool { return gauges[i].Name < gauges[j].Name })
+	last := ""
+	for _, g := range gauges {
+		v, ok := g.Collect()
+		if !ok {
+			continue
+		}
+		name := namespace + "_" + g.Name
+		if name != last {
+			header(w, name, g.Help, "gauge")
+			last = name
+		}
+		fmt.Fprintf(w, "%s%s %s\n", name, labels(g.Labels), formatFloat(v))
+	}
+	return w.Flush()
+}
+
+func labels(m map[string]string) string {
+	if len(m) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%q", k, m[k])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func header(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, strings.ReplaceAll(help, "\n", " "), name, typ)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+)
+
+// Listen opens the metrics listener. addr is either "unix:/path/to.sock" or a
+// loopback "host:port"; non-loopback TCP addresses are refused.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		_ = os.Remove(path)
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("listen: %w", err)
+		}
+		if err := os.Chmod(path, constants.MetricsSocketPerm); err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("chmod socket: %w", err)
+		}
+		return l, nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics address: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, errors.New("metrics address must be loopback or unix:")
+	}
+	return net.Listen("tcp", addr)
+}
+
+func (r *Registry) Serve(ctx context.Context, addr string, log *slog.Logger) error {
+	l, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      constants.ReadWriteDeadline,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("metrics_serve", "err", err.Error())
+		}
+	}()
+	log.Info("metrics_listening", "addr", addr)
+	return nil
+}
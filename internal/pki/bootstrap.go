@@ -0,0 +1,224 @@
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+)
+
+const (
+	fileIntCACSR = "int-ca.csr"
+	fileChain    = "chain.pem"
+)
+
+var (
+	oidBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+)
+
+// CADir is where the CA with the given ID keeps its files under pkiDir.
+func CADir(pkiDir, id string) (string, error) {
+	if id == "" || id == DefaultCAID {
+		return pkiDir, nil
+	}
+	if !reCAID.MatchString(id) {
+		return "", fmt.Errorf("ca %q: invalid id", id)
+	}
+	return filepath.Join(pkiDir, dirCAs, id), nil
+}
+
+// InitOptions describes a new intermediate key and CSR.
+type InitOptions struct {
+	KeyType    string // rsa3072|rsa4096|ecdsa-p256|ecdsa-p384
+	Subject    pkix.Name
+	PathLen    int
+	Passphrase []byte // optional; encrypts the key as PBES2 PKCS#8
+	Force      bool
+}
+
+// InitIntermediate writes int-ca.key and int-ca.csr into dir and returns the CSR PEM.
+// The CSR requests a critical CA:true basicConstraints with PathLen and a critical
+// keyCertSign|cRLSign keyUsage; the offline root should copy both.
+func InitIntermediate(dir string, o InitOptions) ([]byte, error) {
+	keyPath := filepath.Join(dir, fileIntCAKey)
+	if _, err := os.Stat(keyPath); err == nil && !o.Force {
+		return nil, fmt.Errorf("%s exists; use --force to replace it", keyPath)
+	}
+	if o.Subject.CommonName == "" {
+		return nil, errors.New("empty subject CN")
+	}
+	if o.PathLen < 0 {
+		return nil, errors.New("negative pathlen")
+	}
+	key, err := generateCAKey(o.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	if len(o.Passphrase) > 0 {
+		if block, err = EncryptPKCS8(der, o.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	bc, err := asn1.Marshal(struct {
+		IsCA       bool `asn1:"optional"`
+		MaxPathLen int  `asn1:"optional,default:-1"`
+	}{true, o.PathLen})
+	if err != nil {
+		return nil, err
+	}
+	ku, err := asn1.Marshal(asn1.BitString{Bytes: []byte{0x06}, BitLength: 7}) // keyCertSign, cRLSign
+	if err != nil {
+		return nil, err
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: o.Subject,
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidBasicConstraints, Critical: true, Value: bc},
+			{Id: oidKeyUsage, Critical: true, Value: ku},
+		},
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("create csr: %w", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := security.AtomicWrite(keyPath, pem.EncodeToMemory(block), 0o600); err != nil {
+		return nil, err
+	}
+	if err := security.AtomicWrite(filepath.Join(dir, fileIntCACSR), csrPEM, 0o644); err != nil {
+		return nil, err
+	}
+	return csrPEM, nil
+}
+
+func generateCAKey(kt string) (crypto.Signer, error) {
+	switch kt {
+	case "rsa3072":
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case "rsa4096":
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case "ecdsa-p256":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ecdsa-p384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported CA key type %q", kt)
+	}
+}
+
+// ImportIntermediate checks that certPEM was issued for the pending int-ca.csr in dir,
+// is a usable CA certificate and chains to one of the roots in rootPEM (plus any
+// intermediates between them). It then installs int-ca.crt and chain.pem
+// (intermediate first, root last) and removes the CSR.
+func ImportIntermediate(dir string, certPEM, rootPEM []byte) (*x509.Certificate, error) {
+	csrPEM, err := os.ReadFile(filepath.Join(dir, fileIntCACSR))
+	if err != nil {
+		return nil, fmt.Errorf("read csr: %w", err)
+	}
+	cb, _ := pem.Decode(csrPEM)
+	if cb == nil {
+		return nil, errors.New("invalid CSR PEM")
+	}
+	csr, err := x509.ParseCertificateRequest(cb.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse csr: %w", err)
+	}
+
+	certs, err := parseCerts(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("cert: %w", err)
+	}
+	cert := certs[0]
+	pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(csr.PublicKey) {
+		return nil, errors.New("certificate does not match the pending CSR key")
+	}
+	switch {
+	case !cert.BasicConstraintsValid || !cert.IsCA:
+		return nil, errors.New("certificate is not a CA (basicConstraints CA:true missing)")
+	case cert.KeyUsage&x509.KeyUsageCertSign == 0 || cert.KeyUsage&x509.KeyUsageCRLSign == 0:
+		return nil, errors.New("certificate lacks keyCertSign/cRLSign key usage")
+	case time.Now().After(cert.NotAfter):
+		return nil, errors.New("certificate has expired")
+	}
+
+	rootList, err := parseCerts(rootPEM)
+	if err != nil {
+		return nil, fmt.Errorf("root: %w", err)
+	}
+	roots, inter := x509.NewCertPool(), x509.NewCertPool()
+	for _, r := range rootList {
+		roots.AddCert(r)
+	}
+	for _, c := range certs[1:] {
+		inter.AddCert(c)
+	}
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: inter,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify against root: %w", err)
+	}
+
+	var chain bytes.Buffer
+	for _, c := range chains[0] {
+		chain.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}))
+	}
+	crt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := security.AtomicWrite(filepath.Join(dir, fileIntCACert), crt, 0o644); err != nil {
+		return nil, err
+	}
+	if err := security.AtomicWrite(filepath.Join(dir, fileChain), chain.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(filepath.Join(dir, fileIntCACSR))
+	return cert, nil
+}
+
+func parseCerts(b []byte) ([]*x509.Certificate, error) {
+	var out []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no certificate in PEM")
+	}
+	return out, nil
+}
@@ -3,7 +3,6 @@ package pki
 import (
 	"crypto/rand"
 	"crypto/x509"
-	"crypto/x509/pkix"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
@@ -19,6 +18,38 @@ const (
 	fileCRL     = "crl.pem"
 )
 
+const ReasonCertificateHold = "certificateHold"
+
+// reasonCodes are the RFC 5280 CRLReason values of the reasons REVOKE accepts.
+var reasonCodes = []string{
+	0: "unspecified",
+	1: "keyCompromise",
+	2: "caCompromise",
+	3: "affiliationChanged",
+	4: "superseded",
+	5: "cessationOfOperation",
+	6: ReasonCertificateHold,
+	9: "privilegeWithdrawn",
+}
+
+// ReasonCode is the CRLReason of reason; unknown and empty reasons are 0.
+func ReasonCode(reason string) int {
+	for code, name := range reasonCodes {
+		if name != "" && name == reason {
+			return code
+		}
+	}
+	return 0
+}
+
+// ReasonName is the name of a CRLReason code.
+func ReasonName(code int) string {
+	if code >= 0 && code < len(reasonCodes) && reasonCodes[code] != "" {
+		return reasonCodes[code]
+	}
+	return fmt.Sprintf("reason(%d)", code)
+}
+
 type RevokedEntry struct {
 	Serial        string `json:"serial"`
 	Reason        string `json:"reason"`
@@ -69,8 +100,14 @@ func (c *CA) RevokeAndWriteCRL(serialDec string, reason string) (string, error)
 	if err != nil {
 		return "", fmt.Errorf("load revoked: %w", err)
 	}
-	for _, e := range db.Entries {
+	for i, e := range db.Entries {
 		if e.Serial == serialDec {
+			if e.Reason == ReasonCertificateHold && reason != ReasonCertificateHold {
+				db.Entries[i].Reason = reason
+				if err := c.saveRevoked(db); err != nil {
+					return "", fmt.Errorf("save revoked: %w", err)
+				}
+			}
 			return c.writeCRL(db) // idempotent
 		}
 	}
@@ -86,26 +123,36 @@ func (c *CA) RevokeAndWriteCRL(serialDec string, reason string) (string, error)
 }
 
 func (c *CA) writeCRL(db *revokedDB) (string, error) {
-	revoked := make([]pkix.RevokedCertificate, 0, len(db.Entries))
+	key, err := c.Signer()
+	if err != nil {
+		return "", err
+	}
+	revoked := make([]x509.RevocationListEntry, 0, len(db.Entries))
 	for _, e := range db.Entries {
 		n, err := parseSerialDec(e.Serial)
 		if err != nil {
 			return "", err
 		}
-		revoked = append(revoked, pkix.RevokedCertificate{
+		// unspecified (0) leaves the reasonCode extension out, as RFC 5280 asks
+		revoked = append(revoked, x509.RevocationListEntry{
 			SerialNumber:   n,
 			RevocationTime: time.Unix(e.RevokedAtUnix, 0).UTC(),
+			ReasonCode:     ReasonCode(e.Reason),
 		})
 	}
 
+	validity := c.CRLValidity
+	if validity <= 0 {
+		validity = 7 * 24 * time.Hour
+	}
 	now := time.Now().UTC()
 	crlBytes, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
-		SignatureAlgorithm:  c.Cert.SignatureAlgorithm,
-		RevokedCertificates: revoked,
-		Number:              big.NewInt(now.Unix()),
-		ThisUpdate:          now,
-		NextUpdate:          now.Add(7 * 24 * time.Hour),
-	}, c.Cert, c.Key)
+		SignatureAlgorithm:        c.Cert.SignatureAlgorithm,
+		RevokedCertificateEntries: revoked,
+		Number:                    big.NewInt(now.Unix()),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(validity),
+	}, c.Cert, key)
 	if err != nil {
 		return "", fmt.Errorf("create crl: %w", err)
 	}
@@ -137,3 +184,48 @@ func (c *CA) isSerialRevoked(serialDec string) (bool, error) {
 	}
 	return false, nil
 }
+
+func (c *CA) RevokedSerials() (map[string]RevokedEntry, error) {
+	db, err := c.loadRevoked()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]RevokedEntry, len(db.Entries))
+	for _, e := range db.Entries {
+		out[e.Serial] = e
+	}
+	return out, nil
+}
+
+// CRLTimes returns ThisUpdate and NextUpdate of the current CRL on disk.
+func (c *CA) CRLTimes() (time.Time, time.Time, error) {
+	b, err := os.ReadFile(c.crlPath())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return time.Time{}, time.Time{}, errors.New("bad crl pem")
+	}
+	rl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return rl.ThisUpdate, rl.NextUpdate, nil
+}
+
+// EnsureCRL returns the current CRL, signing an empty one if none was written yet.
+func (c *CA) EnsureCRL() (string, error) {
+	crl, err := c.ReadCRL()
+	if err == nil {
+		return crl, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	db, err := c.loadRevoked()
+	if err != nil {
+		return "", fmt.Errorf("load revoked: %w", err)
+	}
+	return c.writeCRL(db)
+}
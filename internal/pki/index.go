@@ -47,16 +47,32 @@ func (c *CA) AppendIssued(cn, profile, serial, notAfterRFC3339, certPEM string)
 }
 
 func (c *CA) ListIssued(max int) ([]api.IssuedMeta, error) {
+	out := []api.IssuedMeta{}
+	err := c.EachIssued(func(ent api.IssuedMeta) error {
+		out = append(out, ent)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if max > 0 && len(out) > max {
+		out = out[len(out)-max:]
+	}
+	return out, nil
+}
+
+// EachIssued calls fn for every issuance record in the order they were
+// appended, reading the index one line at a time. An error from fn stops it.
+func (c *CA) EachIssued(fn func(api.IssuedMeta) error) error {
 	f, err := os.Open(c.issuedPath())
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return []api.IssuedMeta{}, nil
+			return nil
 		}
-		return nil, err
+		return err
 	}
 	defer f.Close()
 
-	var out []api.IssuedMeta
 	sc := bufio.NewScanner(f)
 	const maxLine = 512 * 1024
 	buf := make([]byte, 0, 64*1024)
@@ -67,15 +83,11 @@ func (c *CA) ListIssued(max int) ([]api.IssuedMeta, error) {
 		if err := json.Unmarshal(sc.Bytes(), &ent); err != nil {
 			continue
 		}
-		out = append(out, ent)
-	}
-	if err := sc.Err(); err != nil {
-		return nil, err
-	}
-	if max > 0 && len(out) > max {
-		out = out[len(out)-max:]
+		if err := fn(ent); err != nil {
+			return err
+		}
 	}
-	return out, nil
+	return sc.Err()
 }
 
 func parseCertNotAfter(certPEM string) (time.Time, error) {
@@ -109,3 +121,39 @@ func (c *CA) ExistsCNActive(cn string) (bool, error) {
 	}
 	return false, nil
 }
+
+func (c *CA) ActiveIssued(now time.Time) ([]api.IssuedMeta, error) {
+	list, err := c.ListIssued(0)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := c.RevokedSerials()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]api.IssuedMeta, 0, len(list))
+	for _, it := range list {
+		na, err := time.Parse(time.RFC3339, it.NotAfter)
+		if err != nil || !now.Before(na) {
+			continue
+		}
+		if _, ok := revoked[it.Serial]; ok {
+			continue
+		}
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+func (c *CA) hasIssued(serialDec string) (bool, error) {
+	list, err := c.ListIssued(0)
+	if err != nil {
+		return false, err
+	}
+	for _, it := range list {
+		if it.Serial == serialDec {
+			return true, nil
+		}
+	}
+	return false, nil
+}
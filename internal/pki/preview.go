@@ -0,0 +1,143 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+)
+
+// Preview describes the certificate GenKeyAndSign would issue for cn. Nothing is
+// signed and no serial is consumed.
+func Preview(ca *CA, cn string, profile api.Profile, days int) (*api.CertPreview, error) {
+	tpl, err := profileTemplate(cn, profile, days)
+	if err != nil {
+		return nil, err
+	}
+	return preview(ca, tpl, profile), nil
+}
+
+// PreviewCSR is Preview for SignCSR: the CSR is parsed and its signature checked,
+// and the subject CN is taken from it as SignCSR does.
+func PreviewCSR(ca *CA, csrPEM string, profile api.Profile, days int) (*api.CertPreview, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil {
+		return nil, errors.New("bad csr pem")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse csr: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("csr sig: %w", err)
+	}
+	return Preview(ca, csr.Subject.CommonName, profile, days)
+}
+
+func preview(ca *CA, tpl *x509.Certificate, profile api.Profile) *api.CertPreview {
+	notBefore := time.Now().Add(-1 * time.Minute)
+	p := &api.CertPreview{
+		Subject:      tpl.Subject.String(),
+		Issuer:       ca.Cert.Subject.String(),
+		CAID:         ca.ID,
+		Profile:      profile,
+		SANs:         tpl.DNSNames,
+		KeyUsage:     KeyUsageNames(tpl.KeyUsage),
+		ExtKeyUsage:  ExtKeyUsageNames(tpl.ExtKeyUsage),
+		IsCA:         tpl.IsCA,
+		NotBefore:    notBefore.UTC().Format(time.RFC3339),
+		NotAfter:     tpl.NotAfter.UTC().Format(time.RFC3339),
+		ValidityDays: int(tpl.NotAfter.Sub(notBefore).Round(time.Hour) / (24 * time.Hour)),
+	}
+	for _, ip := range tpl.IPAddresses {
+		p.SANs = append(p.SANs, ip.String())
+	}
+	return p
+}
+
+var keyUsages = []struct {
+	bit  x509.KeyUsage
+	name string
+}{
+	{x509.KeyUsageDigitalSignature, "digitalSignature"},
+	{x509.KeyUsageContentCommitment, "contentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "keyAgreement"},
+	{x509.KeyUsageCertSign, "keyCertSign"},
+	{x509.KeyUsageCRLSign, "cRLSign"},
+}
+
+// KeyUsageNames lists the key usage bits set in ku, in RFC 5280 order.
+func KeyUsageNames(ku x509.KeyUsage) []string {
+	out := []string{}
+	for _, u := range keyUsages {
+		if ku&u.bit != 0 {
+			out = append(out, u.name)
+		}
+	}
+	return out
+}
+
+var extKeyUsages = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "any",
+	x509.ExtKeyUsageServerAuth:      "serverAuth",
+	x509.ExtKeyUsageClientAuth:      "clientAuth",
+	x509.ExtKeyUsageCodeSigning:     "codeSigning",
+	x509.ExtKeyUsageEmailProtection: "emailProtection",
+	x509.ExtKeyUsageTimeStamping:    "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+}
+
+// ExtKeyUsageNames names each extended key usage.
+func ExtKeyUsageNames(eku []x509.ExtKeyUsage) []string {
+	out := make([]string, 0, len(eku))
+	for _, u := range eku {
+		if name, ok := extKeyUsages[u]; ok {
+			out = append(out, name)
+		} else {
+			out = append(out, fmt.Sprintf("eku(%d)", u))
+		}
+	}
+	return out
+}
+
+// PreviewRevoke reports what RevokeAndWriteCRL would do for serialDec without
+// writing revoked.json or the CRL.
+func (c *CA) PreviewRevoke(serialDec, reason string) (*api.RevokePreview, error) {
+	db, err := c.loadRevoked()
+	if err != nil {
+		return nil, fmt.Errorf("load revoked: %w", err)
+	}
+	p := &api.RevokePreview{
+		Serial:     serialDec,
+		CAID:       c.ID,
+		Reason:     reason,
+		Effect:     api.EffectRevoke,
+		CRLEntries: len(db.Entries) + 1,
+	}
+	list, err := c.ListIssued(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range list {
+		if it.Serial == serialDec {
+			p.Known, p.CN = true, it.CN
+		}
+	}
+	for _, e := range db.Entries {
+		if e.Serial != serialDec {
+			continue
+		}
+		p.Current = e.Reason
+		p.CRLEntries = len(db.Entries)
+		p.Effect = api.EffectNoop
+		if e.Reason == ReasonCertificateHold && reason != ReasonCertificateHold {
+			p.Effect = api.EffectUpgradeHold
+		}
+	}
+	return p, nil
+}
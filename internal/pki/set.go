@@ -0,0 +1,334 @@
+package pki
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+)
+
+// DefaultCAID names the intermediate stored directly in the PKI directory. During a
+// rollover further intermediates live in <pki>/cas/<id>/ with state in <state>/cas/<id>/.
+const DefaultCAID = "default"
+
+const dirCAs = "cas"
+
+var reCAID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Loader loads one CA from its PKI and state directories.
+type Loader func(id, pkiDir, stateDir string) (*CA, error)
+
+// Set is every intermediate the daemon knows about. Only the active one issues;
+// all of them keep revoking and publishing CRLs until they are removed.
+type Set struct {
+	State string
+	// Pending lists CAs prepared by "ca init" whose certificate has not been
+	// imported yet; they are skipped until it is.
+	Pending []string
+	cas     []*CA
+	active  *CA
+}
+
+func LoadSet(pkiDir, stateDir, activeID string, load Loader) (*Set, error) {
+	if load == nil {
+		load = func(_ string, pkiDir, stateDir string) (*CA, error) { return LoadCA(pkiDir, stateDir) }
+	}
+	s := &Set{State: stateDir}
+
+	if _, err := os.Stat(filepath.Join(pkiDir, fileIntCACert)); err == nil {
+		ca, err := load(DefaultCAID, pkiDir, stateDir)
+		if err != nil {
+			return nil, fmt.Errorf("ca %s: %w", DefaultCAID, err)
+		}
+		ca.ID = DefaultCAID
+		s.cas = append(s.cas, ca)
+	} else if _, err := os.Stat(filepath.Join(pkiDir, fileIntCACSR)); err == nil {
+		s.Pending = append(s.Pending, DefaultCAID)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(pkiDir, dirCAs))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read %s: %w", dirCAs, err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		id := e.Name()
+		if id == DefaultCAID || !reCAID.MatchString(id) {
+			return nil, fmt.Errorf("ca %q: invalid id", id)
+		}
+		dir := filepath.Join(pkiDir, dirCAs, id)
+		if _, err := os.Stat(filepath.Join(dir, fileIntCACert)); errors.Is(err, os.ErrNotExist) {
+			s.Pending = append(s.Pending, id)
+			continue
+		}
+		ca, err := load(id, dir, filepath.Join(stateDir, dirCAs, id))
+		if err != nil {
+			return nil, fmt.Errorf("ca %s: %w", id, err)
+		}
+		ca.ID = id
+		s.cas = append(s.cas, ca)
+	}
+
+	switch {
+	case len(s.cas) == 0:
+		return nil, fmt.Errorf("no CA found in %s", pkiDir)
+	case activeID != "":
+		s.active = s.Get(activeID)
+		if s.active == nil && slices.Contains(s.Pending, activeID) {
+			return nil, fmt.Errorf("active ca %q has no certificate yet; run ca import", activeID)
+		}
+		if s.active == nil {
+			return nil, fmt.Errorf("active ca %q not found", activeID)
+		}
+	case len(s.cas) == 1:
+		s.active = s.cas[0]
+	default:
+		if s.active = s.Get(DefaultCAID); s.active == nil {
+			return nil, errors.New("several CAs present; choose one with --active-ca")
+		}
+	}
+	return s, nil
+}
+
+// SingleSet wraps one CA, e.g. for tools that operate on a single intermediate.
+func SingleSet(ca *CA) *Set {
+	if ca.ID == "" {
+		ca.ID = DefaultCAID
+	}
+	return &Set{State: ca.State, cas: []*CA{ca}, active: ca}
+}
+
+func (s *Set) Active() *CA { return s.active }
+
+func (s *Set) All() []*CA { return s.cas }
+
+func (s *Set) Get(id string) *CA {
+	for _, ca := range s.cas {
+		if ca.ID == id {
+			return ca
+		}
+	}
+	return nil
+}
+
+// Locate finds the CA that issued serialDec. caID, when set, wins. A serial known to
+// no CA resolves to the active one; a serial issued by several needs caID.
+func (s *Set) Locate(serialDec, caID string) (*CA, error) {
+	if caID != "" {
+		if ca := s.Get(caID); ca != nil {
+			return ca, nil
+		}
+		return nil, ErrUnknownCA
+	}
+	var found []*CA
+	for _, ca := range s.cas {
+		ok, err := ca.hasIssued(serialDec)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = append(found, ca)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return s.active, nil
+	case 1:
+		return found[0], nil
+	default:
+		return nil, ErrAmbiguousSerial
+	}
+}
+
+var (
+	ErrUnknownCA       = errors.New("unknown ca")
+	ErrAmbiguousSerial = errors.New("serial issued by several CAs")
+	ErrNotIssued       = errors.New("serial not issued")
+)
+
+// Issued returns the issuance record of serialDec and the CA holding it. Unlike
+// Locate, a serial no CA knows is ErrNotIssued.
+func (s *Set) Issued(serialDec, caID string) (api.IssuedMeta, *CA, error) {
+	cas := s.cas
+	if caID != "" {
+		ca := s.Get(caID)
+		if ca == nil {
+			return api.IssuedMeta{}, nil, ErrUnknownCA
+		}
+		cas = []*CA{ca}
+	}
+	var meta api.IssuedMeta
+	var owner *CA
+	for _, ca := range cas {
+		list, err := ca.ListIssued(0)
+		if err != nil {
+			return api.IssuedMeta{}, nil, fmt.Errorf("ca %s: %w", ca.ID, err)
+		}
+		for _, it := range list {
+			if it.Serial != serialDec {
+				continue
+			}
+			if owner != nil && owner != ca {
+				return api.IssuedMeta{}, nil, ErrAmbiguousSerial
+			}
+			meta, owner = it, ca
+			meta.CAID = ca.ID
+		}
+	}
+	if owner == nil {
+		return api.IssuedMeta{}, nil, ErrNotIssued
+	}
+	return meta, owner, nil
+}
+
+func (s *Set) ListIssued(max int) ([]api.IssuedMeta, error) {
+	var out []api.IssuedMeta
+	for _, ca := range s.cas {
+		list, err := ca.ListIssued(0)
+		if err != nil {
+			return nil, fmt.Errorf("ca %s: %w", ca.ID, err)
+		}
+		for i := range list {
+			list[i].CAID = ca.ID
+		}
+		out = append(out, list...)
+	}
+	if len(s.cas) > 1 {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].NotAfter < out[j].NotAfter })
+	}
+	if max > 0 && len(out) > max {
+		out = out[len(out)-max:]
+	}
+	if out == nil {
+		out = []api.IssuedMeta{}
+	}
+	return out, nil
+}
+
+// EachIssued calls fn for every issuance record of every CA, CA by CA in the
+// order ListIssued would return them for a single CA, without a limit.
+func (s *Set) EachIssued(fn func(api.IssuedMeta) error) error {
+	for _, ca := range s.cas {
+		var fnErr error
+		err := ca.EachIssued(func(ent api.IssuedMeta) error {
+			ent.CAID = ca.ID
+			fnErr = fn(ent)
+			return fnErr
+		})
+		if fnErr != nil {
+			return fnErr
+		}
+		if err != nil {
+			return fmt.Errorf("ca %s: %w", ca.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Set) ActiveIssued(now time.Time) ([]api.IssuedMeta, error) {
+	var out []api.IssuedMeta
+	for _, ca := range s.cas {
+		list, err := ca.ActiveIssued(now)
+		if err != nil {
+			return nil, fmt.Errorf("ca %s: %w", ca.ID, err)
+		}
+		for i := range list {
+			list[i].CAID = ca.ID
+		}
+		out = append(out, list...)
+	}
+	return out, nil
+}
+
+func (s *Set) ExistsCNActive(cn string) (bool, error) {
+	for _, ca := range s.cas {
+		ok, err := ca.ExistsCNActive(cn)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (s *Set) RevokedCount() (int, error) {
+	n := 0
+	for _, ca := range s.cas {
+		set, err := ca.RevokedSerials()
+		if err != nil {
+			return 0, err
+		}
+		n += len(set)
+	}
+	return n, nil
+}
+
+// TrustPEM is the chain of every CA, active first, for clients that must accept
+// certificates from both sides of a rollover. Shared parents appear once.
+func (s *Set) TrustPEM() string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	add := func(der []byte) {
+		if !seen[string(der)] {
+			seen[string(der)] = true
+			b.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+		}
+	}
+	for _, ca := range append([]*CA{s.active}, s.cas...) {
+		add(ca.Cert.Raw)
+		for _, der := range ca.Chain {
+			add(der)
+		}
+	}
+	return b.String()
+}
+
+// CRLs returns the current CRL of each CA in order, creating an empty one for CAs
+// that have never revoked anything (OpenSSL rejects peers whose issuer has no CRL).
+func (s *Set) CRLs() ([]CRLFile, error) {
+	out := make([]CRLFile, 0, len(s.cas))
+	for _, ca := range s.cas {
+		pemStr, err := ca.EnsureCRL()
+		if err != nil {
+			return nil, fmt.Errorf("ca %s: %w", ca.ID, err)
+		}
+		out = append(out, CRLFile{CAID: ca.ID, PEM: pemStr})
+	}
+	return out, nil
+}
+
+// ReadCRLs is CRLs without signing missing ones; a CA without a CRL fails
+// with an error wrapping os.ErrNotExist.
+func (s *Set) ReadCRLs() ([]CRLFile, error) {
+	out := make([]CRLFile, 0, len(s.cas))
+	for _, ca := range s.cas {
+		pemStr, err := ca.ReadCRL()
+		if err != nil {
+			return nil, fmt.Errorf("ca %s: %w", ca.ID, err)
+		}
+		out = append(out, CRLFile{CAID: ca.ID, PEM: pemStr})
+	}
+	return out, nil
+}
+
+type CRLFile struct {
+	CAID string
+	PEM  string
+}
+
+func ConcatCRLs(files []CRLFile) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.PEM)
+	}
+	return b.String()
+}
@@ -43,6 +43,17 @@ func BuildServerTemplate(cn string, days int, _ []string) *x509.Certificate {
 	}
 }
 
+func profileTemplate(cn string, profile api.Profile, days int) (*x509.Certificate, error) {
+	switch profile {
+	case api.ProfileClient:
+		return BuildClientTemplate(cn, days), nil
+	case api.ProfileServer:
+		return BuildServerTemplate(cn, days, nil), nil
+	default:
+		return nil, errors.New("invalid profile")
+	}
+}
+
 func GenKeyAndSign(ca *CA, cn string, kt api.KeyType, profile api.Profile, days int, passphrase string) (*SignResult, error) {
 	if ca == nil {
 		return nil, errors.New("nil CA")
@@ -51,39 +62,14 @@ func GenKeyAndSign(ca *CA, cn string, kt api.KeyType, profile api.Profile, days
 		return nil, errors.New("invalid profile")
 	}
 
-	var pub any
-	var keyDER []byte
-
-	switch kt {
-	case api.KeyRSA4096:
-		rk, err := rsa.GenerateKey(rand.Reader, 4096)
-		if err != nil {
-			return nil, fmt.Errorf("rsa gen: %w", err)
-		}
-		pub = &rk.PublicKey
-		keyDER, err = x509.MarshalPKCS8PrivateKey(rk)
-		if err != nil {
-			return nil, fmt.Errorf("marshal pkcs8: %w", err)
-		}
-	case api.KeyEd25519:
-		_, ek, err := ed25519.GenerateKey(rand.Reader)
-		if err != nil {
-			return nil, fmt.Errorf("ed25519 gen: %w", err)
-		}
-		pub = ek.Public().(ed25519.PublicKey)
-		keyDER, err = x509.MarshalPKCS8PrivateKey(ek)
-		if err != nil {
-			return nil, fmt.Errorf("marshal pkcs8: %w", err)
-		}
-	default:
-		return nil, errors.New("unsupported key type")
+	pub, keyDER, err := generateKey(kt)
+	if err != nil {
+		return nil, err
 	}
 
-	var tpl *x509.Certificate
-	if profile == api.ProfileClient {
-		tpl = BuildClientTemplate(cn, days)
-	} else {
-		tpl = BuildServerTemplate(cn, days, nil)
+	tpl, err := profileTemplate(cn, profile, days)
+	if err != nil {
+		return nil, err
 	}
 
 	certPEM, serial, err := ca.SignCert(tpl, pub)
@@ -108,6 +94,56 @@ func GenKeyAndSign(ca *CA, cn string, kt api.KeyType, profile api.Profile, days
 	}, nil
 }
 
+// GenServerKeyAndSign issues a server certificate with a freshly generated, unencrypted
+// PKCS#8 key, for certificates deployed directly to the OpenVPN server.
+func GenServerKeyAndSign(ca *CA, cn string, kt api.KeyType, days int) (*SignResult, error) {
+	if ca == nil {
+		return nil, errors.New("nil CA")
+	}
+	pub, keyDER, err := generateKey(kt)
+	if err != nil {
+		return nil, err
+	}
+	tpl := BuildServerTemplate(cn, days, nil)
+	certPEM, serial, err := ca.SignCert(tpl, pub)
+	if err != nil {
+		return nil, err
+	}
+	return &SignResult{
+		CertPEM:  string(certPEM),
+		KeyPEM:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+		NotAfter: tpl.NotAfter,
+		Serial:   serial.String(),
+	}, nil
+}
+
+func generateKey(kt api.KeyType) (any, []byte, error) {
+	switch kt {
+	case api.KeyRSA4096:
+		rk, err := rsa.GenerateKey(rand.Reader, 4096)
+		if err != nil {
+			return nil, nil, fmt.Errorf("rsa gen: %w", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(rk)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshal pkcs8: %w", err)
+		}
+		return &rk.PublicKey, der, nil
+	case api.KeyEd25519:
+		_, ek, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ed25519 gen: %w", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(ek)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshal pkcs8: %w", err)
+		}
+		return ek.Public().(ed25519.PublicKey), der, nil
+	default:
+		return nil, nil, errors.New("unsupported key type")
+	}
+}
+
 func SignCSR(ca *CA, csrPEM string, profile api.Profile, days int) (*SignResult, error) {
 	block, _ := pem.Decode([]byte(csrPEM))
 	if block == nil {
@@ -121,13 +157,9 @@ func SignCSR(ca *CA, csrPEM string, profile api.Profile, days int) (*SignResult,
 		return nil, fmt.Errorf("csr sig: %w", err)
 	}
 
-	var tpl *x509.Certificate
-	if profile == api.ProfileClient {
-		tpl = BuildClientTemplate(csr.Subject.CommonName, days)
-	} else if profile == api.ProfileServer {
-		tpl = BuildServerTemplate(csr.Subject.CommonName, days, nil)
-	} else {
-		return nil, errors.New("invalid profile")
+	tpl, err := profileTemplate(csr.Subject.CommonName, profile, days)
+	if err != nil {
+		return nil, err
 	}
 
 	certPEM, serial, err := ca.SignCert(tpl, csr.PublicKey)
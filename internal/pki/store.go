@@ -15,17 +15,28 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 type CA struct {
+	ID     string
 	Cert   *x509.Certificate
 	Key    crypto.Signer
 	Chain  [][]byte
 	PKIDir string
 	State  string
+	// CRLValidity is the nextUpdate distance of generated CRLs; zero means 7 days.
+	CRLValidity time.Duration
+
+	keyMu  sync.RWMutex
+	encKey []byte
+	// issueMu serializes issuance, see Issuing.
+	issueMu sync.Mutex
 }
 
+var ErrLocked = errors.New("ca key locked")
+
 const (
 	fileIntCAKey  = "int-ca.key"
 	fileIntCACert = "int-ca.crt"
@@ -34,7 +45,6 @@ const (
 
 func LoadCA(pkiDir, stateDir string) (*CA, error) {
 	keyPath := filepath.Join(pkiDir, fileIntCAKey)
-	crtPath := filepath.Join(pkiDir, fileIntCACert)
 
 	keyPEM, err := os.ReadFile(keyPath)
 	if err != nil {
@@ -44,12 +54,70 @@ func LoadCA(pkiDir, stateDir string) (*CA, error) {
 	if block == nil {
 		return nil, errors.New("invalid CA key PEM")
 	}
-	priv, err := parsePrivateKey(block)
+	var priv crypto.Signer
+	var encKey []byte
+	switch {
+	case block.Type == pemEncryptedPrivateKey:
+		encKey = block.Bytes
+	case block.Headers["Proc-Type"] != "":
+		return nil, errors.New("legacy encrypted PEM key not supported; convert with openssl pkcs8 -topk8 -v2 aes-256-cbc")
+	default:
+		priv, err = parsePrivateKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("parse key: %w", err)
+		}
+	}
+
+	cert, err := ReadCACert(pkiDir)
 	if err != nil {
-		return nil, fmt.Errorf("parse key: %w", err)
+		return nil, err
+	}
+	chain, err := readChain(pkiDir, cert)
+	if err != nil {
+		return nil, err
 	}
+	if err := initState(stateDir); err != nil {
+		return nil, err
+	}
+
+	return &CA{
+		Cert:   cert,
+		Key:    priv,
+		Chain:  chain,
+		PKIDir: pkiDir,
+		State:  stateDir,
+		encKey: encKey,
+	}, nil
+}
 
-	crtPEM, err := os.ReadFile(crtPath)
+// LoadCAWithSigner loads the CA certificate from pkiDir and signs with key, such
+// as a PKCS#11 token; int-ca.key is not read.
+func LoadCAWithSigner(pkiDir, stateDir string, key crypto.Signer) (*CA, error) {
+	cert, err := ReadCACert(pkiDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyMatches(key, cert); err != nil {
+		return nil, err
+	}
+	chain, err := readChain(pkiDir, cert)
+	if err != nil {
+		return nil, err
+	}
+	if err := initState(stateDir); err != nil {
+		return nil, err
+	}
+	return &CA{
+		Cert:   cert,
+		Key:    key,
+		Chain:  chain,
+		PKIDir: pkiDir,
+		State:  stateDir,
+	}, nil
+}
+
+func ReadCACert(pkiDir string) (*x509.Certificate, error) {
+	crtPEM, err := os.ReadFile(filepath.Join(pkiDir, fileIntCACert))
 	if err != nil {
 		return nil, fmt.Errorf("read cert: %w", err)
 	}
@@ -61,23 +129,114 @@ func LoadCA(pkiDir, stateDir string) (*CA, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse cert: %w", err)
 	}
+	return cert, nil
+}
 
+// readChain loads the optional chain.pem (intermediate first, root last) and returns
+// the DER of every certificate above the intermediate. The intermediate itself may be
+// omitted from the file. The chain must verify up to its last, self-signed entry.
+func readChain(pkiDir string, cert *x509.Certificate) ([][]byte, error) {
+	b, err := os.ReadFile(filepath.Join(pkiDir, fileChain))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read chain: %w", err)
+	}
+	certs, err := parseCerts(b)
+	if err != nil {
+		return nil, fmt.Errorf("chain: %w", err)
+	}
+	if certs[0].Equal(cert) {
+		certs = certs[1:]
+	}
+	if len(certs) == 0 {
+		return nil, nil
+	}
+	root := certs[len(certs)-1]
+	if err := root.CheckSignatureFrom(root); err != nil {
+		return nil, fmt.Errorf("chain: last certificate %q is not a self-signed root", root.Subject)
+	}
+	roots, inter := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(root)
+	for _, c := range certs[:len(certs)-1] {
+		inter.AddCert(c)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: inter,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("chain: %w", err)
+	}
+	out := make([][]byte, len(certs))
+	for i, c := range certs {
+		out[i] = c.Raw
+	}
+	return out, nil
+}
+
+func initState(stateDir string) error {
 	if err := os.MkdirAll(stateDir, 0o700); err != nil {
-		return nil, fmt.Errorf("state dir: %w", err)
+		return fmt.Errorf("state dir: %w", err)
 	}
 	serialPath := filepath.Join(stateDir, fileSerial)
 	if _, err := os.Stat(serialPath); errors.Is(err, fs.ErrNotExist) {
 		if err := os.WriteFile(serialPath, []byte("1000"), 0o600); err != nil {
-			return nil, fmt.Errorf("init serial: %w", err)
+			return fmt.Errorf("init serial: %w", err)
 		}
 	}
+	return nil
+}
 
-	return &CA{
-		Cert:   cert,
-		Key:    priv,
-		PKIDir: pkiDir,
-		State:  stateDir,
-	}, nil
+func checkKeyMatches(priv crypto.Signer, cert *x509.Certificate) error {
+	pub, ok := priv.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return errors.New("CA key does not match CA certificate")
+	}
+	return nil
+}
+
+// Locked reports whether the CA key is still encrypted and signing is unavailable.
+func (c *CA) Locked() bool {
+	c.keyMu.RLock()
+	defer c.keyMu.RUnlock()
+	return c.Key == nil
+}
+
+// Unlock decrypts the PKCS#8 CA key with passphrase. It is a no-op once unlocked.
+func (c *CA) Unlock(passphrase []byte) error {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.Key != nil {
+		return nil
+	}
+	if c.encKey == nil {
+		return errors.New("no encrypted CA key")
+	}
+	der, err := DecryptPKCS8(c.encKey, passphrase)
+	if err != nil {
+		return err
+	}
+	priv, err := parsePrivateKey(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err != nil {
+		return ErrBadPassphrase
+	}
+	if err := checkKeyMatches(priv, c.Cert); err != nil {
+		return err
+	}
+	c.Key = priv
+	return nil
+}
+
+// Signer returns the CA key, or ErrLocked while the key is still encrypted.
+func (c *CA) Signer() (crypto.Signer, error) {
+	c.keyMu.RLock()
+	defer c.keyMu.RUnlock()
+	if c.Key == nil {
+		return nil, ErrLocked
+	}
+	return c.Key, nil
 }
 
 func nextSerial(stateDir string) (*big.Int, error) {
@@ -132,7 +291,18 @@ func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
 	}
 }
 
+// Issuing locks c for one issuance: the caller's CN check, serial allocation,
+// signing and AppendIssued must all run before the returned unlock is called.
+func (c *CA) Issuing() (unlock func()) {
+	c.issueMu.Lock()
+	return c.issueMu.Unlock
+}
+
 func (c *CA) SignCert(tpl *x509.Certificate, pub any) ([]byte, *big.Int, error) {
+	key, err := c.Signer()
+	if err != nil {
+		return nil, nil, err
+	}
 	serial, err := nextSerial(c.State)
 	if err != nil {
 		return nil, nil, fmt.Errorf("serial: %w", err)
@@ -143,7 +313,7 @@ func (c *CA) SignCert(tpl *x509.Certificate, pub any) ([]byte, *big.Int, error)
 	if tpl.NotAfter.IsZero() {
 		tpl.NotAfter = now.Add(180 * 24 * time.Hour)
 	}
-	der, err := x509.CreateCertificate(rand.Reader, tpl, c.Cert, pub, c.Key)
+	der, err := x509.CreateCertificate(rand.Reader, tpl, c.Cert, pub, key)
 	if err != nil {
 		return nil, nil, fmt.Errorf("create cert: %w", err)
 	}
@@ -154,3 +324,14 @@ func (c *CA) SignCert(tpl *x509.Certificate, pub any) ([]byte, *big.Int, error)
 func (c *CA) CertPEM() string {
 	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw}))
 }
+
+// ChainPEM is the intermediate followed by chain.pem up to the root; without a
+// chain.pem it is just the intermediate.
+func (c *CA) ChainPEM() string {
+	var b strings.Builder
+	b.WriteString(c.CertPEM())
+	for _, der := range c.Chain {
+		b.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	return b.String()
+}
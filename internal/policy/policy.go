@@ -1,17 +1,63 @@
 package policy
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
 	"regexp"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/hooks"
+	"github.com/HarounAhmad/vpn-certd/internal/validate"
 )
 
 type Policy struct {
-	ClientDays       int    `yaml:"client_days"`
-	ServerDays       int    `yaml:"server_days"`
-	AllowDuplicateCN bool   `yaml:"allow_duplicate_cn"`
-	CNPattern        string `yaml:"cn_pattern"`
+	Version          string       `yaml:"version"`
+	Digest           string       `yaml:"-"`
+	ClientDays       int          `yaml:"client_days"`
+	ServerDays       int          `yaml:"server_days"`
+	AllowDuplicateCN bool         `yaml:"allow_duplicate_cn"`
+	CNPattern        string       `yaml:"cn_pattern"`
+	Expiry           Expiry       `yaml:"expiry"`
+	Renewal          Renewal      `yaml:"renewal"`
+	Hooks            []hooks.Hook `yaml:"hooks"`
+	// BundleTemplates picks the bundle template by certificate profile when a
+	// BUILD_BUNDLE names none.
+	BundleTemplates map[api.Profile]string `yaml:"bundle_templates"`
+}
+
+type Expiry struct {
+	WindowsDays  []int         `yaml:"windows_days"`
+	ScanInterval time.Duration `yaml:"scan_interval"`
+	Notify       Notify        `yaml:"notify"`
+}
+
+type Notify struct {
+	Exec        []string      `yaml:"exec"`
+	ExecTimeout time.Duration `yaml:"exec_timeout"`
+	DropDir     string        `yaml:"drop_dir"`
+}
+
+type Renewal struct {
+	CheckInterval time.Duration `yaml:"check_interval"`
+	Targets       []RenewTarget `yaml:"targets"`
+}
+
+// RenewTarget is a server certificate managed by the daemon. When CSRPath is set the
+// stored CSR is re-signed; otherwise a fresh key of KeyType is generated and written to KeyPath.
+type RenewTarget struct {
+	CN          string        `yaml:"cn"`
+	BeforeDays  int           `yaml:"before_days"`
+	CertPath    string        `yaml:"cert_path"`
+	KeyPath     string        `yaml:"key_path"`
+	CSRPath     string        `yaml:"csr_path"`
+	KeyType     api.KeyType   `yaml:"key_type"`
+	PostRenew   []string      `yaml:"post_renew"`
+	HookTimeout time.Duration `yaml:"hook_timeout"`
 }
 
 func Default() Policy {
@@ -20,6 +66,12 @@ func Default() Policy {
 		ServerDays:       365,
 		AllowDuplicateCN: false,
 		CNPattern:        `^[A-Za-z0-9._-]{3,64}$`,
+		Expiry: Expiry{
+			WindowsDays:  []int{30, 7, 1},
+			ScanInterval: 6 * time.Hour,
+			Notify:       Notify{ExecTimeout: 30 * time.Second},
+		},
+		Renewal: Renewal{CheckInterval: time.Hour},
 	}
 }
 
@@ -35,6 +87,8 @@ func Load(path string) (Policy, error) {
 	if err := yaml.Unmarshal(b, &p); err != nil {
 		return Policy{}, err
 	}
+	sum := sha256.Sum256(b)
+	p.Digest = hex.EncodeToString(sum[:])
 	if p.ClientDays <= 0 {
 		p.ClientDays = 180
 	}
@@ -44,9 +98,67 @@ func Load(path string) (Policy, error) {
 	if p.CNPattern == "" {
 		p.CNPattern = Default().CNPattern
 	}
+	if len(p.Expiry.WindowsDays) == 0 {
+		p.Expiry.WindowsDays = Default().Expiry.WindowsDays
+	}
+	for _, d := range p.Expiry.WindowsDays {
+		if d <= 0 {
+			return Policy{}, errors.New("invalid expiry.windows_days")
+		}
+	}
+	if p.Expiry.ScanInterval <= 0 {
+		p.Expiry.ScanInterval = Default().Expiry.ScanInterval
+	}
+	if p.Expiry.Notify.ExecTimeout <= 0 {
+		p.Expiry.Notify.ExecTimeout = Default().Expiry.Notify.ExecTimeout
+	}
+	if p.Renewal.CheckInterval <= 0 {
+		p.Renewal.CheckInterval = Default().Renewal.CheckInterval
+	}
+	for i := range p.Renewal.Targets {
+		if err := p.Renewal.Targets[i].normalize(); err != nil {
+			return Policy{}, fmt.Errorf("renewal.targets[%d]: %w", i, err)
+		}
+	}
+	for i := range p.Hooks {
+		if err := p.Hooks[i].Validate(); err != nil {
+			return Policy{}, fmt.Errorf("hooks[%d]: %w", i, err)
+		}
+	}
+
+	for profile, name := range p.BundleTemplates {
+		if validate.Profile(profile) != nil || validate.Template(name) != nil {
+			return Policy{}, fmt.Errorf("invalid bundle_templates.%s", profile)
+		}
+	}
 
 	if _, err := regexp.Compile(p.CNPattern); err != nil {
 		return Policy{}, errors.New("invalid cn_pattern regex")
 	}
 	return p, nil
 }
+
+func (t *RenewTarget) normalize() error {
+	if err := validate.CN(t.CN); err != nil {
+		return errors.New("invalid cn")
+	}
+	if t.CertPath == "" {
+		return errors.New("cert_path required")
+	}
+	if t.CSRPath == "" && t.KeyPath == "" {
+		return errors.New("key_path or csr_path required")
+	}
+	if t.BeforeDays <= 0 {
+		t.BeforeDays = 30
+	}
+	if t.KeyType == "" {
+		t.KeyType = api.KeyRSA4096
+	}
+	if err := validate.KeyType(t.KeyType); err != nil {
+		return errors.New("invalid key_type")
+	}
+	if t.HookTimeout <= 0 {
+		t.HookTimeout = time.Minute
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package renew
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/hooks"
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+	"github.com/HarounAhmad/vpn-certd/internal/policy"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+)
+
+type Renewer struct {
+	CAs      *pki.Set
+	Targets  []policy.RenewTarget
+	Interval time.Duration
+	Days     int
+	Log      *slog.Logger
+	OnRenew  func(t policy.RenewTarget, res *pki.SignResult)
+	// Pending keeps certificates issued but not yet deployed; share it across
+	// Renewers of the same tenant so a policy reload does not reissue them.
+	Pending *Pending
+	mu      sync.Mutex
+}
+
+// Pending holds, per target, a renewal whose files could not be written. The
+// next check deploys it again instead of issuing another certificate.
+type Pending struct {
+	mu sync.Mutex
+	m  map[string]*pki.SignResult
+}
+
+func pendingKey(t policy.RenewTarget) string {
+	return t.CN + "\x00" + t.CertPath + "\x00" + t.KeyPath
+}
+
+func (p *Pending) take(t policy.RenewTarget, now time.Time) *pki.SignResult {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	res := p.m[pendingKey(t)]
+	delete(p.m, pendingKey(t))
+	// a certificate that would be due itself right away is not worth deploying
+	if res == nil || !now.Add(time.Duration(t.BeforeDays)*24*time.Hour).Before(res.NotAfter) {
+		return nil
+	}
+	return res
+}
+
+func (p *Pending) put(t policy.RenewTarget, res *pki.SignResult) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.m == nil {
+		p.m = map[string]*pki.SignResult{}
+	}
+	p.m[pendingKey(t)] = res
+}
+
+func (r *Renewer) Run(ctx context.Context) {
+	r.Check(ctx, time.Now())
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			r.Check(ctx, now)
+		}
+	}
+}
+
+func (r *Renewer) Check(ctx context.Context, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.CAs.Active().Locked() {
+		r.Log.Warn("renew_skipped", "reason", "ca_locked")
+		return
+	}
+	for _, t := range r.Targets {
+		due, notAfter, err := Due(t, now)
+		if err != nil {
+			r.Log.Warn("renew_check_failed", "cn", t.CN, "path", t.CertPath, "err", err.Error())
+		}
+		if !due {
+			r.Log.Debug("renew_not_due", "cn", t.CN, "not_after", notAfter.UTC().Format(time.RFC3339))
+			continue
+		}
+		if _, err := r.renew(ctx, t, now); err != nil {
+			r.Log.Error("renew_failed", "cn", t.CN, "err", err.Error())
+		}
+	}
+}
+
+// Due reports whether the deployed certificate is missing, unreadable, issued for a
+// different CN, not the pair of the deployed key (when the daemon generates it),
+// or within BeforeDays of expiry.
+func Due(t policy.RenewTarget, now time.Time) (bool, time.Time, error) {
+	b, err := os.ReadFile(t.CertPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return true, time.Time{}, nil
+		}
+		return true, time.Time{}, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return true, time.Time{}, errors.New("bad cert pem")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true, time.Time{}, err
+	}
+	if cert.Subject.CommonName != t.CN {
+		return true, cert.NotAfter, fmt.Errorf("deployed cert cn %q", cert.Subject.CommonName)
+	}
+	if t.CSRPath == "" {
+		if err := keyMatches(t.KeyPath, cert); err != nil {
+			return true, cert.NotAfter, err
+		}
+	}
+	return now.Add(time.Duration(t.BeforeDays) * 24 * time.Hour).After(cert.NotAfter), cert.NotAfter, nil
+}
+
+// keyMatches checks that the PKCS#8 key at path belongs to cert.
+func keyMatches(path string, cert *x509.Certificate) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("deployed key: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return errors.New("deployed key: bad pem")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("deployed key: %w", err)
+	}
+	pub, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("deployed key: unsupported type %T", key)
+	}
+	if eq, ok := pub.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !eq.Equal(cert.PublicKey) {
+		return errors.New("deployed key does not match cert")
+	}
+	return nil
+}
+
+func (r *Renewer) renew(ctx context.Context, t policy.RenewTarget, now time.Time) (*pki.SignResult, error) {
+	res := r.Pending.take(t, now)
+	if res != nil {
+		r.Log.Info("renew_redeploy", "cn", t.CN, "serial", res.Serial)
+	} else {
+		var err error
+		if res, err = r.issueAndRecord(t); err != nil {
+			return nil, err
+		}
+	}
+	// key and certificate replace the deployed pair together or not at all
+	files := []security.File{{Path: t.CertPath, Data: []byte(res.CertPEM), Perm: 0o644}}
+	if res.KeyPEM != "" {
+		files = append(files, security.File{Path: t.KeyPath, Data: []byte(res.KeyPEM), Perm: 0o600})
+	}
+	if err := security.AtomicWriteAll(files...); err != nil {
+		r.Pending.put(t, res)
+		return nil, fmt.Errorf("deploy serial %s: %w", res.Serial, err)
+	}
+	r.Log.Info("renewed", "cn", t.CN, "serial", res.Serial, "not_after", res.NotAfter.UTC().Format(time.RFC3339), "cert_path", t.CertPath)
+
+	if len(t.PostRenew) > 0 {
+		env := map[string]string{
+			"VPN_CERTD_EVENT":     "renewed",
+			"VPN_CERTD_CN":        t.CN,
+			"VPN_CERTD_SERIAL":    res.Serial,
+			"VPN_CERTD_NOT_AFTER": res.NotAfter.UTC().Format(time.RFC3339),
+			"VPN_CERTD_CERT_PATH": t.CertPath,
+			"VPN_CERTD_KEY_PATH":  t.KeyPath,
+		}
+		hr, err := hooks.Run(ctx, t.PostRenew, t.HookTimeout, env, nil)
+		if err != nil {
+			r.Log.Error("post_renew_failed", "cn", t.CN, "cmd", t.PostRenew[0], "exit", hr.ExitCode, "output", hr.Output, "err", err.Error())
+		} else {
+			r.Log.Info("post_renew_ok", "cn", t.CN, "cmd", t.PostRenew[0], "duration_ms", hr.Duration.Milliseconds())
+		}
+	}
+	if r.OnRenew != nil {
+		r.OnRenew(t, res)
+	}
+	return res, nil
+}
+
+// issueAndRecord issues for t and appends the record under the active CA's
+// issuance lock.
+func (r *Renewer) issueAndRecord(t policy.RenewTarget) (*pki.SignResult, error) {
+	ca := r.CAs.Active()
+	defer ca.Issuing()()
+	res, err := r.issue(t)
+	if err != nil {
+		return nil, err
+	}
+	if err := ca.AppendIssued(t.CN, string(api.ProfileServer), res.Serial, res.NotAfter.UTC().Format(time.RFC3339), res.CertPEM); err != nil {
+		r.Log.Warn("renew_append_issued_failed", "cn", t.CN, "serial", res.Serial, "err", err.Error())
+	}
+	return res, nil
+}
+
+func (r *Renewer) issue(t policy.RenewTarget) (*pki.SignResult, error) {
+	if t.CSRPath == "" {
+		return pki.GenServerKeyAndSign(r.CAs.Active(), t.CN, t.KeyType, r.Days)
+	}
+	b, err := os.ReadFile(t.CSRPath)
+	if err != nil {
+		return nil, fmt.Errorf("read csr: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("bad csr pem")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse csr: %w", err)
+	}
+	if csr.Subject.CommonName != t.CN {
+		return nil, fmt.Errorf("csr cn %q does not match target", csr.Subject.CommonName)
+	}
+	return pki.SignCSR(r.CAs.Active(), string(b), api.ProfileServer, r.Days)
+}
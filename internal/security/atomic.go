@@ -1,6 +1,7 @@
 package security
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 )
@@ -16,3 +17,71 @@ func AtomicWrite(path string, data []byte, perm os.FileMode) error {
 	}
 	return os.Rename(tmp, path)
 }
+
+// File is one file for AtomicWriteAll.
+type File struct {
+	Path string
+	Data []byte
+	Perm os.FileMode
+}
+
+// AtomicWriteAll writes every file beside its destination first and renames
+// them into place only once all were written. If a rename fails, the files
+// already replaced are restored from hard-linked backups, so the set is either
+// fully written or left as it was.
+func AtomicWriteAll(files ...File) error {
+	var staged, backups []string
+	defer func() {
+		for _, p := range append(staged, backups...) {
+			if p != "" {
+				_ = os.Remove(p)
+			}
+		}
+	}()
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+			return err
+		}
+		tmp := f.Path + ".tmp"
+		fh, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Perm)
+		if err != nil {
+			return err
+		}
+		staged = append(staged, tmp)
+		_, err = fh.Write(f.Data)
+		if cerr := fh.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// "" marks a destination that did not exist before
+	for _, f := range files {
+		bak := f.Path + ".prev"
+		_ = os.Remove(bak)
+		if err := os.Link(f.Path, bak); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			bak = ""
+		}
+		backups = append(backups, bak)
+	}
+	for i, f := range files {
+		if err := os.Rename(staged[i], f.Path); err != nil {
+			// a backup that cannot be restored stays on disk
+			for j := i - 1; j >= 0; j-- {
+				if backups[j] == "" {
+					_ = os.Remove(files[j].Path)
+				} else {
+					_ = os.Rename(backups[j], files[j].Path)
+				}
+				backups[j] = ""
+			}
+			return err
+		}
+		staged[i] = ""
+	}
+	return nil
+}
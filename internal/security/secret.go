@@ -0,0 +1,78 @@
+package security
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const maxSecretSize = 4096
+
+var ErrNoCredential = errors.New("credential not available")
+
+// ReadCredential reads a systemd credential (LoadCredential=/SetCredentialEncrypted=)
+// from $CREDENTIALS_DIRECTORY.
+func ReadCredential(name string) ([]byte, error) {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" || name == "" || filepath.Base(name) != name {
+		return nil, ErrNoCredential
+	}
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoCredential
+	}
+	if err != nil {
+		return nil, err
+	}
+	return trimSecret(b), nil
+}
+
+// ReadFD reads a secret from an inherited file descriptor and closes it.
+func ReadFD(fd int) ([]byte, error) {
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+	if f == nil {
+		return nil, fmt.Errorf("fd %d: invalid", fd)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, maxSecretSize))
+	if err != nil {
+		return nil, fmt.Errorf("fd %d: %w", fd, err)
+	}
+	return trimSecret(b), nil
+}
+
+func trimSecret(b []byte) []byte {
+	return bytes.TrimRight(b, "\r\n")
+}
+
+// ReadSecret resolves a secret reference of the form "credential:NAME",
+// "fd:N" or "file:PATH".
+func ReadSecret(ref string) ([]byte, error) {
+	kind, arg, ok := strings.Cut(ref, ":")
+	if !ok || arg == "" {
+		return nil, fmt.Errorf("secret source %q: want credential:NAME, fd:N or file:PATH", ref)
+	}
+	switch kind {
+	case "credential":
+		return ReadCredential(arg)
+	case "fd":
+		fd, err := strconv.Atoi(arg)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("secret source %q: bad fd", ref)
+		}
+		return ReadFD(fd)
+	case "file":
+		b, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return trimSecret(b), nil
+	default:
+		return nil, fmt.Errorf("secret source %q: unknown kind %q", ref, kind)
+	}
+}
@@ -0,0 +1,24 @@
+package security
+
+import (
+	"os"
+	"syscall"
+)
+
+// DirStatus reports whether dir accepts new files and how many bytes are
+// available to unprivileged users on its filesystem.
+func DirStatus(dir string) (writable bool, free uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return false, 0, err
+	}
+	free = uint64(st.Bavail) * uint64(st.Bsize)
+	f, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return false, free, nil
+	}
+	name := f.Name()
+	_ = f.Close()
+	_ = os.Remove(name)
+	return true, free, nil
+}
@@ -0,0 +1,544 @@
+// Package grpcapi serves the vpncertd.v1.Certd gRPC service on a UNIX socket.
+// Every RPC is translated to an api.Request for the same unixjson.Handler the
+// JSON socket uses.
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/server/unixjson"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+	pb "github.com/HarounAhmad/vpn-certd/pkg/certdpb"
+	"github.com/HarounAhmad/vpn-certd/pkg/version"
+)
+
+// HooksFailedTrailer is the trailer naming the required hooks that failed after
+// a successful call (api.Response.HooksFailed); the messages have no field for it.
+const HooksFailedTrailer = "vpn-certd-hooks-failed"
+
+type Server struct {
+	pb.UnimplementedCertdServer
+
+	Socket string
+	Log    *slog.Logger
+	H      unixjson.Handler
+	// Deadline bounds each unary call without a client deadline; zero means
+	// constants.ReadWriteDeadline.
+	Deadline time.Duration
+}
+
+func (s *Server) deadline() time.Duration {
+	if s.Deadline > 0 {
+		return s.Deadline
+	}
+	return constants.ReadWriteDeadline
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	if s.Socket == "" || s.H == nil || s.Log == nil {
+		return errors.New("server not configured")
+	}
+	_ = os.Remove(s.Socket)
+	l, err := net.Listen("unix", s.Socket)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	if err := os.Chmod(s.Socket, constants.SocketPerm0600); err != nil {
+		_ = l.Close()
+		return fmt.Errorf("chmod socket: %w", err)
+	}
+	gs := grpc.NewServer()
+	pb.RegisterCertdServer(gs, s)
+	go func() {
+		<-ctx.Done()
+		gs.Stop()
+	}()
+	go func() {
+		if err := gs.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			s.Log.Error("grpc_serve", "err", err.Error())
+		}
+	}()
+	s.Log.Info("grpc_listening", "socket", s.Socket)
+	return nil
+}
+
+// call runs req through the handler and converts a failure to a gRPC status.
+func (s *Server) call(ctx context.Context, req api.Request) (api.Response, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.deadline())
+		defer cancel()
+	}
+	resp, err := s.H.Handle(ctx, req)
+	if err != nil {
+		return resp, Status(err, resp)
+	}
+	if len(resp.HooksFailed) > 0 {
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(HooksFailedTrailer, strings.Join(resp.HooksFailed, ",")))
+	}
+	return resp, nil
+}
+
+// Status maps a handler error to a gRPC status with an ErrorInfo detail
+// carrying the daemon error code, field and retryability.
+func Status(err error, resp api.Response) error {
+	e := xerr.Public(err)
+	info := &errdetails.ErrorInfo{
+		Reason:   e.Msg,
+		Domain:   constants.AppName,
+		Metadata: map[string]string{"code": string(e.Code)},
+	}
+	if e.Field != "" {
+		info.Metadata["field"] = e.Field
+	}
+	if e.Retry {
+		info.Metadata["retryable"] = "true"
+	}
+	if resp.Serial != "" {
+		info.Metadata["serial"] = resp.Serial
+		info.Metadata["ca_id"] = resp.CAID
+	}
+	st := status.New(grpcCode(e), e.Error())
+	if d, derr := st.WithDetails(info); derr == nil {
+		st = d
+	}
+	return st.Err()
+}
+
+func grpcCode(e xerr.E) codes.Code {
+	switch e.Code {
+	case xerr.BadRequest:
+		return codes.InvalidArgument
+	case xerr.Forbidden:
+		return codes.PermissionDenied
+	case xerr.NotFound:
+		return codes.NotFound
+	case xerr.Conflict:
+		return codes.FailedPrecondition
+	case xerr.NotImpl:
+		return codes.Unimplemented
+	case xerr.Internal:
+		if e.Retry {
+			return codes.Unavailable
+		}
+		return codes.Internal
+	default:
+		return codes.Internal
+	}
+}
+
+func (s *Server) Hello(_ context.Context, in *pb.HelloRequest) (*pb.HelloResponse, error) {
+	out := &pb.HelloResponse{
+		Server:    version.Name + "/" + version.Version,
+		Protocol:  api.ProtocolGRPC,
+		Protocols: []int32{api.ProtocolGRPC},
+	}
+	for _, op := range api.Ops {
+		out.Ops = append(out.Ops, string(op))
+	}
+	return out, nil
+}
+
+func (s *Server) Health(ctx context.Context, _ *pb.HealthRequest) (*pb.HealthResponse, error) {
+	resp, err := s.call(ctx, api.Request{Op: api.OpHealth})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.HealthResponse{Status: resp.Serial, Time: resp.NotAfter}, nil
+}
+
+func (s *Server) Status(ctx context.Context, in *pb.StatusRequest) (*pb.StatusReport, error) {
+	resp, err := s.call(ctx, api.Request{Op: api.OpStatus, Tenant: in.Tenant})
+	if err != nil {
+		return nil, err
+	}
+	return statusReport(resp.Status), nil
+}
+
+func (s *Server) Unlock(ctx context.Context, in *pb.UnlockRequest) (*pb.StatusReport, error) {
+	resp, err := s.call(ctx, api.Request{Op: api.OpUnlock, Tenant: in.Tenant, Passphrase: in.Passphrase})
+	if err != nil {
+		return nil, err
+	}
+	return statusReport(resp.Status), nil
+}
+
+func (s *Server) Sign(ctx context.Context, in *pb.SignRequest) (*pb.IssueResponse, error) {
+	resp, err := s.call(ctx, api.Request{
+		Op:        api.OpSign,
+		Tenant:    in.Tenant,
+		CN:        in.Cn,
+		Profile:   api.Profile(in.Profile),
+		CSRPEM:    in.Csr,
+		DryRun:    in.DryRun,
+		RequestID: in.RequestId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return issueResponse(resp), nil
+}
+
+func (s *Server) GenKeyAndSign(ctx context.Context, in *pb.GenKeyAndSignRequest) (*pb.IssueResponse, error) {
+	resp, err := s.call(ctx, api.Request{
+		Op:         api.OpGenKeyAndSign,
+		Tenant:     in.Tenant,
+		CN:         in.Cn,
+		Profile:    api.Profile(in.Profile),
+		KeyType:    api.KeyType(in.KeyType),
+		Passphrase: in.Passphrase,
+		DryRun:     in.DryRun,
+		RequestID:  in.RequestId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return issueResponse(resp), nil
+}
+
+func (s *Server) Revoke(ctx context.Context, in *pb.RevokeRequest) (*pb.RevokeResponse, error) {
+	resp, err := s.call(ctx, api.Request{
+		Op:        api.OpRevoke,
+		Tenant:    in.Tenant,
+		Serial:    in.Serial,
+		Reason:    in.Reason,
+		CAID:      in.CaId,
+		DryRun:    in.DryRun,
+		RequestID: in.RequestId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := &pb.RevokeResponse{
+		CrlPem:    resp.CRLPEM,
+		CaId:      resp.CAID,
+		DryRun:    resp.DryRun,
+		RequestId: resp.RequestID,
+		Replayed:  resp.Replayed,
+	}
+	if p := resp.Revoke; p != nil {
+		out.Preview = &pb.RevokePreview{
+			Serial:        p.Serial,
+			CaId:          p.CAID,
+			Cn:            p.CN,
+			Known:         p.Known,
+			CurrentReason: p.Current,
+			Reason:        p.Reason,
+			Effect:        p.Effect,
+			CrlEntries:    int32(p.CRLEntries),
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) RevokeTLSKey(ctx context.Context, in *pb.RevokeTLSKeyRequest) (*pb.RevokeTLSKeyResponse, error) {
+	resp, err := s.call(ctx, api.Request{
+		Op:        api.OpRevokeTLSKey,
+		Tenant:    in.Tenant,
+		Serial:    in.Serial,
+		CAID:      in.CaId,
+		RequestID: in.RequestId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RevokeTLSKeyResponse{
+		Serial:    resp.Serial,
+		CaId:      resp.CAID,
+		TlsKeyId:  resp.TLSKeyID,
+		RequestId: resp.RequestID,
+		Replayed:  resp.Replayed,
+	}, nil
+}
+
+func (s *Server) GetCert(ctx context.Context, in *pb.GetCertRequest) (*pb.CertInfo, error) {
+	resp, err := s.call(ctx, api.Request{Op: api.OpGetCert, Tenant: in.Tenant, Serial: in.Serial, CAID: in.CaId})
+	if err != nil {
+		return nil, err
+	}
+	c := resp.Cert
+	return &pb.CertInfo{
+		Cert:             issuedCert(c.IssuedMeta),
+		Revoked:          c.Revoked,
+		RevocationReason: c.RevocationReason,
+		RevokedAt:        c.RevokedAt,
+		CertPem:          resp.CertPEM,
+	}, nil
+}
+
+func (s *Server) GetCRL(ctx context.Context, in *pb.GetCRLRequest) (*pb.CRLResponse, error) {
+	resp, err := s.call(ctx, api.Request{Op: api.OpGetCRL, Tenant: in.Tenant, CAID: in.CaId})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CRLResponse{CrlPem: resp.CRLPEM, CaId: resp.CAID, RequestId: resp.RequestID}, nil
+}
+
+func (s *Server) BuildBundle(ctx context.Context, in *pb.BuildBundleRequest) (*pb.BundleResponse, error) {
+	resp, err := s.call(ctx, api.Request{Op: api.OpBuildBundle, Tenant: in.Tenant, Bundle: &api.BundleReq{
+		CN:         in.Cn,
+		IncludeKey: in.IncludeKey,
+		RemoteHost: in.RemoteHost,
+		RemotePort: int(in.RemotePort),
+		Proto:      in.Proto,
+		Template:   in.Template,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	zip, err := base64.StdEncoding.DecodeString(resp.ZipB64)
+	if err != nil {
+		s.Log.Error("bundle_decode", "err", err.Error())
+		return nil, Status(xerr.InternalErr("bundle_failed"), api.Response{})
+	}
+	return &pb.BundleResponse{Zip: zip}, nil
+}
+
+func (s *Server) Batch(ctx context.Context, in *pb.BatchRequest) (*pb.BatchResponse, error) {
+	req := api.Request{
+		Op:         api.OpBatch,
+		Tenant:     in.Tenant,
+		Profile:    api.Profile(in.Profile),
+		KeyType:    api.KeyType(in.KeyType),
+		Passphrase: in.Passphrase,
+		DryRun:     in.DryRun,
+		RequestID:  in.RequestId,
+	}
+	for _, it := range in.Items {
+		item := api.BatchItem{
+			CN:         it.Cn,
+			Profile:    api.Profile(it.Profile),
+			KeyType:    api.KeyType(it.KeyType),
+			Passphrase: it.Passphrase,
+			RequestID:  it.RequestId,
+		}
+		if b := it.Bundle; b != nil {
+			item.Bundle = &api.BundleReq{
+				IncludeKey: b.IncludeKey,
+				RemoteHost: b.RemoteHost,
+				RemotePort: int(b.RemotePort),
+				Proto:      b.Proto,
+				Template:   b.Template,
+			}
+		}
+		req.Items = append(req.Items, item)
+	}
+	resp, err := s.call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out := &pb.BatchResponse{}
+	for _, r := range resp.Batch {
+		res := &pb.BatchResult{
+			Cn:              r.CN,
+			RequestId:       r.RequestID,
+			Serial:          r.Serial,
+			CaId:            r.CAID,
+			NotAfter:        r.NotAfter,
+			CertPem:         r.CertPEM,
+			KeyPemEncrypted: r.KeyPEMEnc,
+			Preview:         certPreview(r.Preview),
+			Replayed:        r.Replayed,
+			HooksFailed:     r.HooksFailed,
+		}
+		if r.ZipB64 != "" {
+			if res.Zip, err = base64.StdEncoding.DecodeString(r.ZipB64); err != nil {
+				s.Log.Error("bundle_decode", "cn", r.CN, "err", err.Error())
+				return nil, Status(xerr.InternalErr("bundle_failed"), api.Response{})
+			}
+		}
+		if e := r.Err; e != nil {
+			res.Error = &pb.BatchError{Code: e.Code, Field: e.Field, Message: e.Message, Retryable: e.Retryable}
+		}
+		out.Results = append(out.Results, res)
+	}
+	return out, nil
+}
+
+// ListIssued streams every issuance record from the handler when it can
+// stream, and the latest constants.ListIssuedMax otherwise.
+func (s *Server) ListIssued(in *pb.ListIssuedRequest, stream grpc.ServerStreamingServer[pb.IssuedCert]) error {
+	req := api.Request{Op: api.OpListIssued, Tenant: in.Tenant}
+	if sh, ok := s.H.(unixjson.StreamHandler); ok {
+		ctx := stream.Context()
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.deadline())
+			defer cancel()
+		}
+		err := sh.Stream(ctx, req, func(v any) error {
+			if it, ok := v.(api.IssuedMeta); ok {
+				return stream.Send(issuedCert(it))
+			}
+			return nil
+		})
+		if err != nil && stream.Context().Err() == nil {
+			return Status(err, api.Response{})
+		}
+		return nil
+	}
+	resp, err := s.call(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for _, it := range resp.Issued {
+		if err := stream.Send(issuedCert(it)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) Expiring(in *pb.ExpiringRequest, stream grpc.ServerStreamingServer[pb.ExpiringCert]) error {
+	resp, err := s.call(stream.Context(), api.Request{Op: api.OpExpiring, Tenant: in.Tenant, Days: int(in.Days)})
+	if err != nil {
+		return err
+	}
+	for _, it := range resp.Expiring {
+		if err := stream.Send(&pb.ExpiringCert{
+			CaId:       it.CAID,
+			Serial:     it.Serial,
+			Cn:         it.CN,
+			Profile:    it.Profile,
+			NotAfter:   it.NotAfter,
+			DaysLeft:   int32(it.DaysLeft),
+			WindowDays: int32(it.WindowDays),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) Subscribe(in *pb.SubscribeRequest, stream grpc.ServerStreamingServer[pb.Event]) error {
+	sh, ok := s.H.(unixjson.StreamHandler)
+	if !ok {
+		return status.Error(codes.Unimplemented, "subscribe not supported")
+	}
+	req := api.Request{Op: api.OpSubscribe, Tenant: in.Tenant, SinceSeq: in.SinceSeq}
+	err := sh.Stream(stream.Context(), req, func(v any) error {
+		ev, ok := v.(api.Event)
+		if !ok {
+			return nil
+		}
+		return stream.Send(&pb.Event{
+			Seq:      ev.Seq,
+			Type:     string(ev.Type),
+			Time:     ev.Time,
+			Cn:       ev.CN,
+			Serial:   ev.Serial,
+			CaId:     ev.CAID,
+			Profile:  ev.Profile,
+			Reason:   ev.Reason,
+			NotAfter: ev.NotAfter,
+			DaysLeft: int32(ev.DaysLeft),
+		})
+	})
+	if err != nil && stream.Context().Err() == nil {
+		return Status(err, api.Response{})
+	}
+	return nil
+}
+
+func issueResponse(r api.Response) *pb.IssueResponse {
+	return &pb.IssueResponse{
+		CertPem:         r.CertPEM,
+		ChainPem:        r.ChainPEM,
+		KeyPemEncrypted: r.KeyPEMEnc,
+		Serial:          r.Serial,
+		CaId:            r.CAID,
+		NotAfter:        r.NotAfter,
+		DryRun:          r.DryRun,
+		RequestId:       r.RequestID,
+		Replayed:        r.Replayed,
+		Preview:         certPreview(r.Preview),
+	}
+}
+
+func certPreview(p *api.CertPreview) *pb.CertPreview {
+	if p == nil {
+		return nil
+	}
+	return &pb.CertPreview{
+		Subject:      p.Subject,
+		Issuer:       p.Issuer,
+		CaId:         p.CAID,
+		Profile:      string(p.Profile),
+		KeyType:      p.KeyType,
+		Sans:         p.SANs,
+		KeyUsage:     p.KeyUsage,
+		ExtKeyUsage:  p.ExtKeyUsage,
+		IsCa:         p.IsCA,
+		NotBefore:    p.NotBefore,
+		NotAfter:     p.NotAfter,
+		ValidityDays: int32(p.ValidityDays),
+	}
+}
+
+func issuedCert(m api.IssuedMeta) *pb.IssuedCert {
+	return &pb.IssuedCert{
+		Serial:   m.Serial,
+		Cn:       m.CN,
+		Profile:  m.Profile,
+		NotAfter: m.NotAfter,
+		Sha256:   m.SHA256,
+		CaId:     m.CAID,
+	}
+}
+
+func statusReport(r *api.StatusReport) *pb.StatusReport {
+	if r == nil {
+		return &pb.StatusReport{}
+	}
+	out := &pb.StatusReport{
+		Verdict:       string(r.Verdict),
+		Tenant:        r.Tenant,
+		Problems:      r.Problems,
+		Version:       r.Version,
+		StartedAt:     r.StartedAt,
+		UptimeSeconds: r.UptimeSeconds,
+		TaKeyPresent:  r.TAKeyPresent,
+		Policy:        &pb.PolicyStatus{Version: r.Policy.Version, Digest: r.Policy.Digest},
+	}
+	for _, c := range r.CAs {
+		out.Cas = append(out.Cas, &pb.CAStatus{
+			Id:         c.ID,
+			Active:     c.Active,
+			Subject:    c.Subject,
+			NotAfter:   c.NotAfter,
+			DaysLeft:   int32(c.DaysLeft),
+			KeyMatches: c.KeyMatches,
+			Locked:     c.Locked,
+		})
+	}
+	if c := r.CRL; c != nil {
+		out.Crl = &pb.CRLStatus{
+			ThisUpdate: c.ThisUpdate,
+			NextUpdate: c.NextUpdate,
+			AgeSeconds: c.AgeSeconds,
+			DeployPath: c.DeployPath,
+			Deployed:   c.Deployed,
+			InSync:     c.InSync,
+		}
+	}
+	if st := r.Storage; st != nil {
+		out.Storage = &pb.StorageStatus{StateDir: st.StateDir, Writable: st.Writable, FreeBytes: st.FreeBytes}
+	}
+	return out
+}
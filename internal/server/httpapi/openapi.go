@@ -0,0 +1,152 @@
+package httpapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/pkg/version"
+)
+
+// openAPI is generated from routes and the api types, so it cannot drift from
+// what the handlers accept.
+var openAPI = buildOpenAPI()
+
+type object = map[string]any
+
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(api.Profile("")): {string(api.ProfileClient), string(api.ProfileServer)},
+	reflect.TypeOf(api.KeyType("")): {string(api.KeyRSA4096), string(api.KeyEd25519)},
+}
+
+func buildOpenAPI() object {
+	g := schemaGen{defs: object{}}
+	resp := object{"application/json": object{"schema": g.schema(reflect.TypeOf(api.Response{}))}}
+	paths := object{}
+	for _, rt := range routes {
+		var params []object
+		for _, p := range rt.Params {
+			params = append(params, object{
+				"name": p.Name, "in": p.In, "description": p.Help,
+				"required": p.In == "path", "schema": object{"type": "string"},
+			})
+		}
+		if rt.Method == http.MethodPost {
+			params = append(params, object{
+				"name": "Idempotency-Key", "in": "header", "schema": object{"type": "string"},
+				"description": "request_id: a retry with the same key returns the first response",
+			})
+		}
+		ok := object{}
+		for k, v := range resp {
+			ok[k] = v
+		}
+		if rt.raw != nil {
+			ok[rt.raw.mime] = object{"schema": object{"type": "string", "format": "binary"}}
+		}
+		op := object{
+			"operationId": rt.ID,
+			"summary":     rt.Summary,
+			"parameters":  params,
+			"responses": object{
+				strconv.Itoa(rt.Status): object{"description": http.StatusText(rt.Status), "content": ok},
+				"default": object{
+					"description": "error: err holds the code and message; status 400/403/404/409/500/501 follows the code",
+					"content":     resp,
+				},
+			},
+		}
+		if rt.Body != nil {
+			op["requestBody"] = object{
+				"required": true,
+				"content":  object{"application/json": object{"schema": g.schema(reflect.TypeOf(rt.Body))}},
+			}
+		}
+		item, _ := paths[rt.Path].(object)
+		if item == nil {
+			item = object{}
+			paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+	paths["/v1/openapi.json"] = object{"get": object{
+		"operationId": "openAPI",
+		"summary":     "This document",
+		"responses":   object{"200": object{"description": "OK"}},
+	}}
+	return object{
+		"openapi":    "3.0.3",
+		"info":       object{"title": version.Name, "version": version.Version},
+		"paths":      paths,
+		"components": object{"schemas": g.defs},
+	}
+}
+
+type schemaGen struct {
+	defs object
+}
+
+func (g *schemaGen) schema(t reflect.Type) object {
+	if e, ok := enums[t]; ok {
+		return object{"type": "string", "enum": e}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return object{"type": "integer"}
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer", "minimum": 0}
+	case reflect.Slice:
+		return object{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = object{} // placeholder while recursing
+			props, required := object{}, []string{}
+			g.fields(t, props, &required)
+			def := object{"type": "object", "properties": props}
+			if len(required) > 0 {
+				def["required"] = required
+			}
+			g.defs[name] = def
+		}
+		return object{"$ref": "#/components/schemas/" + name}
+	default:
+		return object{}
+	}
+}
+
+// fields adds t's JSON fields to props; embedded structs are flattened as
+// encoding/json does.
+func (g *schemaGen) fields(t reflect.Type, props object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
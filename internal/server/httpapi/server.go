@@ -0,0 +1,288 @@
+// Package httpapi serves the daemon's operations as a small REST API on a UNIX
+// socket. Every endpoint maps onto an api.Request handled by the same
+// unixjson.Handler as the newline-JSON socket.
+package httpapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/server/unixjson"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+)
+
+const maxBody = 1 << 20
+
+type Server struct {
+	Socket string
+	Log    *slog.Logger
+	H      unixjson.Handler
+	// Deadline bounds each request; zero means constants.ReadWriteDeadline.
+	Deadline time.Duration
+}
+
+func (s *Server) deadline() time.Duration {
+	if s.Deadline > 0 {
+		return s.Deadline
+	}
+	return constants.ReadWriteDeadline
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	if s.Socket == "" || s.H == nil || s.Log == nil {
+		return errors.New("server not configured")
+	}
+	_ = os.Remove(s.Socket)
+	l, err := net.Listen("unix", s.Socket)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	if err := os.Chmod(s.Socket, constants.SocketPerm0600); err != nil {
+		_ = l.Close()
+		return fmt.Errorf("chmod socket: %w", err)
+	}
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      s.deadline() + 5*time.Second,
+		IdleTimeout:       constants.RPCIdleTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.Log.Error("http_serve", "err", err.Error())
+		}
+	}()
+	s.Log.Info("http_listening", "socket", s.Socket)
+	return nil
+}
+
+// Handler routes /v1 requests; exported so it can be mounted elsewhere.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.Method+" "+rt.Path, func(w http.ResponseWriter, r *http.Request) {
+			s.serve(w, r, rt)
+		})
+	}
+	mux.HandleFunc("GET /v1/openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, openAPI)
+	})
+	return mux
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, rt route) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	req, err := rt.build(r)
+	if err != nil {
+		var resp api.Response
+		resp.Fail(xerr.Bad(err.Error()))
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+	req.Tenant = r.URL.Query().Get("tenant")
+	if id := r.Header.Get("Idempotency-Key"); id != "" {
+		req.RequestID = id
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.deadline())
+	defer cancel()
+
+	resp, err := s.H.Handle(ctx, req)
+	if resp.RequestID != "" {
+		w.Header().Set("X-Request-Id", resp.RequestID)
+	}
+	if err != nil {
+		resp.Fail(err)
+		writeJSON(w, StatusOf(err), resp)
+		return
+	}
+	if rt.raw != nil && strings.Contains(r.Header.Get("Accept"), rt.raw.mime) {
+		if b, ok := rt.raw.body(resp); ok {
+			w.Header().Set("Content-Type", rt.raw.mime)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(b)
+			return
+		}
+	}
+	status := rt.Status
+	if resp.DryRun || resp.Replayed {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, resp)
+}
+
+// StatusOf maps an error's xerr.Code to an HTTP status; a retryable internal
+// error is 503.
+func StatusOf(err error) int {
+	e := xerr.Public(err)
+	switch e.Code {
+	case xerr.BadRequest:
+		return http.StatusBadRequest
+	case xerr.Forbidden:
+		return http.StatusForbidden
+	case xerr.NotFound:
+		return http.StatusNotFound
+	case xerr.Conflict:
+		return http.StatusConflict
+	case xerr.NotImpl:
+		return http.StatusNotImplemented
+	case xerr.Internal:
+		if e.Retry {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusInternalServerError
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	return nil
+}
+
+// IssueBody is POST /v1/certs. With csr the CSR is signed (SIGN); without it a
+// key pair is generated and returned encrypted under passphrase (GENKEY_AND_SIGN).
+type IssueBody struct {
+	CN         string      `json:"cn"`
+	Profile    api.Profile `json:"profile"`
+	KeyType    api.KeyType `json:"key_type,omitempty"`
+	Passphrase string      `json:"passphrase,omitempty"`
+	CSR        string      `json:"csr,omitempty"`
+	DryRun     bool        `json:"dry_run,omitempty"`
+}
+
+// RevokeBody is POST /v1/certs/{serial}/revoke.
+type RevokeBody struct {
+	Reason string `json:"reason"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// rawBody serves a response field as a non-JSON document when the client
+// asks for mime in Accept.
+type rawBody struct {
+	mime string
+	body func(api.Response) ([]byte, bool)
+}
+
+type param struct {
+	Name, In, Help string
+}
+
+type route struct {
+	ID, Method, Path, Summary string
+	Status                    int
+	Body                      any // request body type, nil for none
+	Params                    []param
+	raw                       *rawBody
+	build                     func(r *http.Request) (api.Request, error)
+}
+
+var (
+	pTenant = param{"tenant", "query", "tenant name (when the daemon serves several)"}
+	pCAID   = param{"ca_id", "query", "CA ID; needed only when several CAs issued the serial"}
+	pSerial = param{"serial", "path", "certificate serial (decimal)"}
+)
+
+var routes = []route{
+	{
+		ID: "issueCert", Method: "POST", Path: "/v1/certs", Status: http.StatusCreated, Body: IssueBody{},
+		Summary: "Issue a certificate: sign a CSR, or generate a key pair and sign it",
+		Params:  []param{pTenant},
+		build: func(r *http.Request) (api.Request, error) {
+			var b IssueBody
+			if err := decodeBody(r, &b); err != nil {
+				return api.Request{}, err
+			}
+			req := api.Request{Op: api.OpGenKeyAndSign, CN: b.CN, Profile: b.Profile, KeyType: b.KeyType,
+				Passphrase: b.Passphrase, DryRun: b.DryRun}
+			if b.CSR != "" {
+				req.Op, req.CSRPEM = api.OpSign, b.CSR
+			}
+			return req, nil
+		},
+	},
+	{
+		ID: "getCert", Method: "GET", Path: "/v1/certs/{serial}", Status: http.StatusOK,
+		Summary: "Issuance record and revocation state of a certificate",
+		Params:  []param{pSerial, pTenant, pCAID},
+		raw: &rawBody{"application/x-pem-file", func(resp api.Response) ([]byte, bool) {
+			return []byte(resp.CertPEM), resp.CertPEM != ""
+		}},
+		build: func(r *http.Request) (api.Request, error) {
+			return api.Request{Op: api.OpGetCert, Serial: r.PathValue("serial"), CAID: r.URL.Query().Get("ca_id")}, nil
+		},
+	},
+	{
+		ID: "revokeCert", Method: "POST", Path: "/v1/certs/{serial}/revoke", Status: http.StatusOK, Body: RevokeBody{},
+		Summary: "Revoke a certificate and re-sign the CRL",
+		Params:  []param{pSerial, pTenant, pCAID},
+		build: func(r *http.Request) (api.Request, error) {
+			var b RevokeBody
+			if err := decodeBody(r, &b); err != nil {
+				return api.Request{}, err
+			}
+			return api.Request{Op: api.OpRevoke, Serial: r.PathValue("serial"), Reason: b.Reason,
+				CAID: r.URL.Query().Get("ca_id"), DryRun: b.DryRun}, nil
+		},
+	},
+	{
+		ID: "revokeTLSKey", Method: "POST", Path: "/v1/certs/{serial}/revoke-tls-key", Status: http.StatusOK,
+		Summary: "Retire the tls-crypt-v2 client key of a certificate; the next bundle gets a new one",
+		Params:  []param{pSerial, pTenant, pCAID},
+		build: func(r *http.Request) (api.Request, error) {
+			return api.Request{Op: api.OpRevokeTLSKey, Serial: r.PathValue("serial"), CAID: r.URL.Query().Get("ca_id")}, nil
+		},
+	},
+	{
+		ID: "getCRL", Method: "GET", Path: "/v1/crl", Status: http.StatusOK,
+		Summary: "Current CRL (all CAs concatenated unless ca_id is given)",
+		Params:  []param{pTenant, {"ca_id", "query", "return only this CA's CRL"}},
+		raw: &rawBody{"application/x-pem-file", func(resp api.Response) ([]byte, bool) {
+			return []byte(resp.CRLPEM), true
+		}},
+		build: func(r *http.Request) (api.Request, error) {
+			return api.Request{Op: api.OpGetCRL, CAID: r.URL.Query().Get("ca_id")}, nil
+		},
+	},
+	{
+		ID: "buildBundle", Method: "POST", Path: "/v1/bundles", Status: http.StatusOK, Body: api.BundleReq{},
+		Summary: "Build a client bundle (zip with .ovpn, CA, cert and optionally key)",
+		Params:  []param{pTenant},
+		raw: &rawBody{"application/zip", func(resp api.Response) ([]byte, bool) {
+			b, err := base64.StdEncoding.DecodeString(resp.ZipB64)
+			return b, err == nil
+		}},
+		build: func(r *http.Request) (api.Request, error) {
+			var b api.BundleReq
+			if err := decodeBody(r, &b); err != nil {
+				return api.Request{}, err
+			}
+			return api.Request{Op: api.OpBuildBundle, Bundle: &b}, nil
+		},
+	},
+}
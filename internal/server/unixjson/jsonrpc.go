@@ -0,0 +1,327 @@
+package unixjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+)
+
+// isRPC reports whether the first message on a connection selects protocol 2:
+// a JSON-RPC batch or an object with "jsonrpc": "2.0".
+func isRPC(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		return true
+	}
+	var probe struct {
+		JSONRPC string `json:"jsonrpc"`
+	}
+	return json.Unmarshal(raw, &probe) == nil && probe.JSONRPC == api.JSONRPCVersion
+}
+
+// rpcConn is one protocol 2 connection. Calls run concurrently and answer in
+// completion order; clients match responses by id.
+type rpcConn struct {
+	s   *Server
+	c   net.Conn
+	ctx context.Context
+	// subs is cancelled once the client stops sending, ending subscriptions
+	// while ordinary calls still finish
+	subs context.Context
+
+	wmu sync.Mutex
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	calls   map[string]context.CancelFunc
+	streams int
+
+	wg  sync.WaitGroup
+	sem chan struct{}
+}
+
+func (s *Server) serveRPC(c net.Conn, dec *json.Decoder, first json.RawMessage) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = c.SetReadDeadline(time.Now())
+	}()
+	_ = c.SetDeadline(time.Time{})
+
+	subs, stopSubs := context.WithCancel(ctx)
+	defer stopSubs()
+	rc := &rpcConn{
+		s:     s,
+		c:     c,
+		ctx:   ctx,
+		subs:  subs,
+		enc:   json.NewEncoder(c),
+		calls: map[string]context.CancelFunc{},
+		sem:   make(chan struct{}, constants.RPCMaxInFlight),
+	}
+	msg := first
+	for {
+		rc.dispatch(msg)
+		rc.idle()
+		msg = nil
+		if err := dec.Decode(&msg); err != nil {
+			var syn *json.SyntaxError
+			if errors.As(err, &syn) {
+				_ = rc.write(rpcFail(nil, api.RPCParseError, err.Error(), nil))
+			} else if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.Log.Debug("rpc_read", "err", err.Error())
+			}
+			break
+		}
+	}
+	// the client is gone or done sending: stop subscriptions, let calls finish
+	stopSubs()
+	rc.wg.Wait()
+}
+
+// idle arms the idle timeout unless a subscription keeps the connection open.
+func (rc *rpcConn) idle() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	switch {
+	case rc.ctx.Err() != nil:
+		_ = rc.c.SetReadDeadline(time.Now())
+	case rc.streams == 0:
+		_ = rc.c.SetReadDeadline(time.Now().Add(constants.RPCIdleTimeout))
+	}
+}
+
+func (rc *rpcConn) write(v any) error {
+	rc.wmu.Lock()
+	defer rc.wmu.Unlock()
+	_ = rc.c.SetWriteDeadline(time.Now().Add(rc.s.deadline()))
+	err := rc.enc.Encode(v)
+	if err != nil {
+		rc.s.Log.Debug("rpc_write", "err", err.Error())
+	}
+	return err
+}
+
+func (rc *rpcConn) dispatch(raw json.RawMessage) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(raw, &batch); err != nil || len(batch) == 0 {
+			_ = rc.write(rpcFail(nil, api.RPCInvalidRequest, "invalid batch", nil))
+			return
+		}
+		rc.run(func() {
+			var resps []*api.RPCResponse
+			for _, m := range batch {
+				if r := rc.call(m, true); r != nil {
+					resps = append(resps, r)
+				}
+			}
+			if len(resps) > 0 {
+				_ = rc.write(resps)
+			}
+		})
+		return
+	}
+
+	var req api.RPCRequest
+	if json.Unmarshal(raw, &req) == nil && rc.isStream(api.Op(req.Method)) {
+		// subscriptions live until cancelled and do not count against the limit
+		rc.wg.Add(1)
+		go func() {
+			defer rc.wg.Done()
+			rc.writeResp(rc.call(raw, false))
+		}()
+		return
+	}
+	rc.run(func() { rc.writeResp(rc.call(raw, false)) })
+}
+
+func (rc *rpcConn) writeResp(r *api.RPCResponse) {
+	if r != nil {
+		_ = rc.write(r)
+	}
+}
+
+// run executes f in the background once an in-flight slot is free.
+func (rc *rpcConn) run(f func()) {
+	select {
+	case rc.sem <- struct{}{}:
+	case <-rc.ctx.Done():
+		return
+	}
+	rc.wg.Add(1)
+	go func() {
+		defer func() {
+			<-rc.sem
+			rc.wg.Done()
+		}()
+		f()
+	}()
+}
+
+func (rc *rpcConn) isStream(op api.Op) bool {
+	sh, ok := rc.s.H.(StreamHandler)
+	return ok && sh.IsStream(op)
+}
+
+// call executes one JSON-RPC call; it returns nil for notifications.
+func (rc *rpcConn) call(raw json.RawMessage, inBatch bool) *api.RPCResponse {
+	var m api.RPCRequest
+	if err := json.Unmarshal(raw, &m); err != nil || m.JSONRPC != api.JSONRPCVersion || m.Method == "" {
+		return rpcFail(m.ID, api.RPCInvalidRequest, "invalid request", nil)
+	}
+	resp := rc.exec(m, inBatch)
+	if len(m.ID) == 0 {
+		return nil
+	}
+	return resp
+}
+
+func (rc *rpcConn) exec(m api.RPCRequest, inBatch bool) *api.RPCResponse {
+	if m.Method == api.RPCCancel {
+		var p api.RPCCancelParams
+		if err := strictDecode(m.Params, &p); err != nil || len(p.ID) == 0 {
+			return rpcFail(m.ID, api.RPCInvalidParams, "params: id required", nil)
+		}
+		rc.mu.Lock()
+		cancel, ok := rc.calls[string(p.ID)]
+		rc.mu.Unlock()
+		if !ok {
+			return rpcErr(m.ID, xerr.NotFoundErr("id"), nil)
+		}
+		cancel()
+		return &api.RPCResponse{JSONRPC: api.JSONRPCVersion, ID: m.ID, Result: &api.Response{}}
+	}
+
+	op := api.Op(m.Method)
+	if !op.Known() {
+		return rpcFail(m.ID, api.RPCMethodNotFound, "method not found: "+m.Method, nil)
+	}
+	var req api.Request
+	if len(m.Params) > 0 {
+		if err := strictDecode(m.Params, &req); err != nil {
+			return rpcFail(m.ID, api.RPCInvalidParams, err.Error(), nil)
+		}
+	}
+	if req.Op != "" && req.Op != op {
+		return rpcFail(m.ID, api.RPCInvalidParams, "params: op does not match method", nil)
+	}
+	req.Op = op
+
+	if op == api.OpHello {
+		h, err := rc.s.hello(api.ProtocolJSONRPC, req.Protocols)
+		if err != nil {
+			return rpcErr(m.ID, err, nil)
+		}
+		return &api.RPCResponse{JSONRPC: api.JSONRPCVersion, ID: m.ID, Result: &api.Response{Hello: h}}
+	}
+
+	if rc.isStream(op) {
+		if inBatch || len(m.ID) == 0 {
+			return rpcFail(m.ID, api.RPCInvalidRequest, m.Method+" needs its own call with an id", nil)
+		}
+		return rc.stream(m.ID, req)
+	}
+
+	ctx, cancel := context.WithTimeout(rc.ctx, rc.s.deadline())
+	defer cancel()
+	if done := rc.track(m.ID, cancel); done != nil {
+		defer done()
+	}
+	resp, err := rc.s.H.Handle(ctx, req)
+	if err != nil {
+		return rpcErr(m.ID, err, &resp)
+	}
+	return &api.RPCResponse{JSONRPC: api.JSONRPCVersion, ID: m.ID, Result: &resp}
+}
+
+// track registers cancel under id for rpc.cancel; the returned func removes it.
+func (rc *rpcConn) track(id json.RawMessage, cancel context.CancelFunc) func() {
+	if len(id) == 0 {
+		return nil
+	}
+	key := string(id)
+	rc.mu.Lock()
+	rc.calls[key] = cancel
+	rc.mu.Unlock()
+	return func() {
+		rc.mu.Lock()
+		delete(rc.calls, key)
+		rc.mu.Unlock()
+	}
+}
+
+// stream runs a subscription, sending each message as an RPCEvent
+// notification. It ends with a normal response when cancelled.
+func (rc *rpcConn) stream(id json.RawMessage, req api.Request) *api.RPCResponse {
+	ctx, cancel := context.WithCancel(rc.subs)
+	defer cancel()
+	done := rc.track(id, cancel)
+	rc.mu.Lock()
+	rc.streams++
+	_ = rc.c.SetReadDeadline(time.Time{})
+	rc.mu.Unlock()
+	defer func() {
+		done()
+		rc.mu.Lock()
+		rc.streams--
+		rc.mu.Unlock()
+		rc.idle()
+	}()
+
+	send := func(v any) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return rc.write(api.RPCNotification{
+			JSONRPC: api.JSONRPCVersion,
+			Method:  api.RPCEvent,
+			Params:  api.RPCMessage{ID: id, Event: v},
+		})
+	}
+	if err := rc.s.H.(StreamHandler).Stream(ctx, req, send); err != nil && ctx.Err() == nil {
+		rc.s.Log.Debug("stream_closed", "op", string(req.Op), "err", err.Error())
+		return rpcErr(id, err, nil)
+	}
+	return &api.RPCResponse{JSONRPC: api.JSONRPCVersion, ID: id, Result: &api.Response{}}
+}
+
+func strictDecode(raw json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func rpcFail(id json.RawMessage, code int, msg string, data *api.RPCErrorData) *api.RPCResponse {
+	return &api.RPCResponse{
+		JSONRPC: api.JSONRPCVersion,
+		ID:      id,
+		Error:   &api.RPCError{Code: code, Message: msg, Data: data},
+	}
+}
+
+// rpcErr maps a handler error to a JSON-RPC error. resp is attached when the
+// operation produced output before failing.
+func rpcErr(id json.RawMessage, err error, resp *api.Response) *api.RPCResponse {
+	e := api.ErrorOf(err)
+	data := &api.RPCErrorData{Code: e.Code, Field: e.Field, Retryable: e.Retryable}
+	if resp != nil && (resp.Serial != "" || resp.CRLPEM != "") {
+		data.Response = resp
+	}
+	rpcCode := api.RPCServerError
+	if e.Code == string(xerr.BadRequest) {
+		rpcCode = api.RPCInvalidParams
+	}
+	return rpcFail(id, rpcCode, e.Message, data)
+}
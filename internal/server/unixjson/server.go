@@ -7,9 +7,15 @@ import (
 	"fmt"
 	"github.com/HarounAhmad/vpn-certd/internal/api"
 	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+	"github.com/HarounAhmad/vpn-certd/pkg/version"
+	"io"
 	"log/slog"
 	"net"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -17,11 +23,31 @@ type Handler interface {
 	Handle(ctx context.Context, req api.Request) (api.Response, error)
 }
 
+// StreamHandler is implemented by handlers that answer some ops with a
+// stream of newline-delimited JSON messages instead of a single response.
+type StreamHandler interface {
+	IsStream(op api.Op) bool
+	Stream(ctx context.Context, req api.Request, send func(any) error) error
+}
+
 type Server struct {
 	Socket string
 	Log    *slog.Logger
 	H      Handler
-	l      net.Listener
+	// Deadline bounds each read/write; zero means constants.ReadWriteDeadline.
+	Deadline time.Duration
+	// Idempotent is announced in HELLO: H answers request_id retries from
+	// stored responses.
+	Idempotent bool
+	l          net.Listener
+	ctx        context.Context
+}
+
+func (s *Server) deadline() time.Duration {
+	if s.Deadline > 0 {
+		return s.Deadline
+	}
+	return constants.ReadWriteDeadline
 }
 
 func (s *Server) Start(ctx context.Context) error {
@@ -38,6 +64,7 @@ func (s *Server) Start(ctx context.Context) error {
 		return fmt.Errorf("chmod socket: %w", err)
 	}
 	s.l = l
+	s.ctx = ctx
 	s.Log.Info("listening", "socket", s.Socket)
 	go s.acceptLoop()
 	go func() {
@@ -59,27 +86,106 @@ func (s *Server) acceptLoop() {
 
 func (s *Server) handleConn(c net.Conn) {
 	defer c.Close()
-	_ = c.SetDeadline(time.Now().Add(constants.ReadWriteDeadline))
+	_ = c.SetDeadline(time.Now().Add(s.deadline()))
 
 	dec := json.NewDecoder(c)
-	dec.DisallowUnknownFields()
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		s.respondErr(c, xerr.Bad(err.Error()))
+		return
+	}
+	if isRPC(raw) {
+		s.serveRPC(c, dec, raw)
+		return
+	}
 	var req api.Request
-	if err := dec.Decode(&req); err != nil {
-		s.respondErr(c, "bad_request: "+err.Error())
+	if err := strictDecode(raw, &req); err != nil {
+		s.respondErr(c, xerr.Bad(err.Error()))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), constants.ReadWriteDeadline)
+	if req.Op == api.OpHello {
+		h, err := s.hello(api.ProtocolLegacy, req.Protocols)
+		if err != nil {
+			s.respondErr(c, err)
+			return
+		}
+		_ = json.NewEncoder(c).Encode(api.Response{Hello: h})
+		return
+	}
+
+	if sh, ok := s.H.(StreamHandler); ok && sh.IsStream(req.Op) {
+		s.stream(c, sh, req)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.deadline())
 	defer cancel()
 
 	resp, err := s.H.Handle(ctx, req)
 	if err != nil {
-		resp.Error = err.Error()
+		resp.Fail(err)
 	}
+	// a handler that ran up to the deadline (e.g. a long BATCH) still answers
+	_ = c.SetWriteDeadline(time.Now().Add(s.deadline()))
 	enc := json.NewEncoder(c)
 	_ = enc.Encode(&resp)
 }
 
-func (s *Server) respondErr(c net.Conn, msg string) {
-	_ = json.NewEncoder(c).Encode(api.Response{Error: msg})
+func (s *Server) stream(c net.Conn, sh StreamHandler, req api.Request) {
+	_ = c.SetDeadline(time.Time{})
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	go func() {
+		// the client has nothing more to say; EOF means it went away
+		_, _ = io.Copy(io.Discard, c)
+		cancel()
+	}()
+
+	enc := json.NewEncoder(c)
+	send := func(v any) error {
+		_ = c.SetWriteDeadline(time.Now().Add(s.deadline()))
+		return enc.Encode(v)
+	}
+	if err := sh.Stream(ctx, req, send); err != nil {
+		s.Log.Debug("stream_closed", "op", string(req.Op), "err", err.Error())
+		var resp api.Response
+		resp.Fail(err)
+		_ = send(resp)
+	}
+}
+
+var protocols = []int{api.ProtocolLegacy, api.ProtocolJSONRPC}
+
+// hello answers HELLO on a connection speaking proto. A client offering only
+// versions this server lacks gets bad_request.
+func (s *Server) hello(proto int, offered []int) (*api.Hello, error) {
+	if len(offered) > 0 && !slices.ContainsFunc(offered, func(v int) bool { return slices.Contains(protocols, v) }) {
+		return nil, xerr.Bad("protocol")
+	}
+	return &api.Hello{
+		Server:      version.Name + "/" + version.Version,
+		Protocol:    proto,
+		Protocols:   protocols,
+		Ops:         api.Ops,
+		Fields:      requestFields,
+		Idempotency: s.Idempotent,
+	}, nil
+}
+
+// requestFields are the JSON names of api.Request.
+var requestFields = func() []string {
+	t := reflect.TypeOf(api.Request{})
+	out := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		out = append(out, name)
+	}
+	return out
+}()
+
+func (s *Server) respondErr(c net.Conn, err error) {
+	var resp api.Response
+	resp.Fail(err)
+	_ = json.NewEncoder(c).Encode(resp)
 }
@@ -0,0 +1,202 @@
+package tlscrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/security"
+)
+
+// ServerKeyFile is the server key's name in the store directory; OpenVPN's
+// "tls-crypt-v2" server directive points at it.
+const ServerKeyFile = "server.key"
+
+// ErrNoKey is returned by Revoke for a certificate without a current client key.
+var ErrNoKey = errors.New("no tls-crypt-v2 client key")
+
+// ClientKey is one wrapped client key and the certificate it was made for.
+type ClientKey struct {
+	ID        string     `json:"id"`
+	CN        string     `json:"cn"`
+	CAID      string     `json:"ca_id"`
+	Serial    string     `json:"serial"`
+	Created   time.Time  `json:"created"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	KeyPEM    string     `json:"key_pem"`
+}
+
+// Store keeps a tenant's server key and its client keys in Dir:
+// server.key, clients/<ca_id>/<serial>.json for the current key of each
+// certificate and revoked/<id>.json for retired ones.
+type Store struct {
+	Dir    string
+	Tenant string
+
+	server *ServerKey
+	mu     sync.Mutex
+}
+
+// Open loads the server key from dir, generating it on first use.
+func Open(dir, tenant string) (*Store, error) {
+	if err := os.MkdirAll(dir, constants.DirPerm0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, ServerKeyFile)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		var s string
+		if s, err = GenerateServerKey(); err == nil {
+			b, err = []byte(s), security.AtomicWrite(path, []byte(s), constants.FilePerm0600)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	k, err := ParseServerKey(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Store{Dir: dir, Tenant: tenant, server: k}, nil
+}
+
+// ServerKeyPath is the file to reference from the OpenVPN server config.
+func (s *Store) ServerKeyPath() string { return filepath.Join(s.Dir, ServerKeyFile) }
+
+func (s *Store) currentPath(caID, serial string) string {
+	return filepath.Join(s.Dir, "clients", caID, serial+".json")
+}
+
+// ClientKey returns the current client key of a certificate, wrapping a new one
+// when it has none.
+func (s *Store) ClientKey(caID, serial, cn string) (ClientKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.currentPath(caID, serial)
+	if k, err := readKey(path); !errors.Is(err, fs.ErrNotExist) {
+		return k, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return ClientKey{}, err
+	}
+	k := ClientKey{ID: hex.EncodeToString(id), CN: cn, CAID: caID, Serial: serial, Created: time.Now().UTC()}
+	pemStr, err := s.server.WrapClientKey(s.Metadata(k))
+	if err != nil {
+		return ClientKey{}, err
+	}
+	k.KeyPEM = pemStr
+	if err := writeKey(path, k); err != nil {
+		return ClientKey{}, err
+	}
+	return k, nil
+}
+
+// Metadata is the payload wrapped into k, one line of key=value fields:
+//
+//	vpn-certd id=<id> tenant=<tenant> ca_id=<ca_id> serial=<serial> cn=<cn>
+func (s *Store) Metadata(k ClientKey) []byte {
+	return fmt.Appendf(nil, "vpn-certd id=%s tenant=%s ca_id=%s serial=%s cn=%s", k.ID, s.Tenant, k.CAID, k.Serial, k.CN)
+}
+
+// Revoke retires the current client key of a certificate; the next ClientKey
+// call wraps a fresh one with a new ID.
+func (s *Store) Revoke(caID, serial string, now time.Time) (ClientKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.currentPath(caID, serial)
+	k, err := readKey(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ClientKey{}, ErrNoKey
+	}
+	if err != nil {
+		return ClientKey{}, err
+	}
+	at := now.UTC()
+	k.RevokedAt = &at
+	if err := writeKey(filepath.Join(s.Dir, "revoked", k.ID+".json"), k); err != nil {
+		return ClientKey{}, err
+	}
+	return k, os.Remove(path)
+}
+
+// Keys returns every client key, current and retired, oldest first.
+func (s *Store) Keys() ([]ClientKey, error) {
+	var out []ClientKey
+	for _, sub := range []string{"clients", "revoked"} {
+		root := filepath.Join(s.Dir, sub)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && path == root {
+				return filepath.SkipDir
+			}
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+				return err
+			}
+			k, err := readKey(path)
+			if err != nil {
+				return err
+			}
+			out = append(out, k)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out, nil
+}
+
+// RevokedList renders the file the server's verify script checks: one line per
+// key that is retired or whose certificate certRevoked reports as revoked (or
+// on hold), starting with the key ID.
+func RevokedList(keys []ClientKey, certRevoked func(caID, serial string) bool) []byte {
+	var b bytes.Buffer
+	b.WriteString("# tls-crypt-v2 client keys refused by the verify script: <id> <ca_id>:<serial> <cn> <why>\n")
+	for _, k := range keys {
+		var why string
+		switch {
+		case k.RevokedAt != nil:
+			why = "key-revoked"
+		case certRevoked(k.CAID, k.Serial):
+			why = "cert-revoked"
+		default:
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s:%s %s %s\n", k.ID, k.CAID, k.Serial, k.CN, why)
+	}
+	return b.Bytes()
+}
+
+func readKey(path string) (ClientKey, error) {
+	var k ClientKey
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return k, err
+	}
+	if err := json.Unmarshal(b, &k); err != nil {
+		return k, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+func writeKey(path string, k ClientKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPerm0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return security.AtomicWrite(path, b, constants.FilePerm0600)
+}
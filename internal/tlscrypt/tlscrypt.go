@@ -0,0 +1,99 @@
+// Package tlscrypt implements OpenVPN's tls-crypt-v2 key format (OpenVPN 2.5+):
+// a server key, and client keys wrapped with it so the server can recover them,
+// together with their metadata, without storing anything per client.
+package tlscrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+const (
+	serverKeyPEM = "OpenVPN tls-crypt-v2 server key"
+	clientKeyPEM = "OpenVPN tls-crypt-v2 client key"
+
+	// both keys are OpenVPN "struct key"s: 64 cipher bytes then 64 HMAC bytes;
+	// a client key holds two of them
+	serverKeyLen = 128
+	clientKeyLen = 256
+	tagLen       = sha256.Size
+	maxWrapped   = 1024
+
+	// MetadataUser marks metadata the server's --tls-crypt-v2-verify script
+	// receives verbatim in $metadata_file.
+	MetadataUser = 0x00
+
+	// MaxMetadata is the longest metadata payload a wrapped key can carry.
+	MaxMetadata = maxWrapped - clientKeyLen - tagLen - 2 - 1
+)
+
+// ServerKey wraps client keys: AES-256-CTR with the first 32 cipher bytes and
+// HMAC-SHA256 with the first 32 HMAC bytes, as OpenVPN uses them.
+type ServerKey struct {
+	enc  []byte
+	auth []byte
+}
+
+// GenerateServerKey returns a new server key in the PEM form of
+// "openvpn --genkey tls-crypt-v2-server".
+func GenerateServerKey() (string, error) {
+	b := make([]byte, serverKeyLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: serverKeyPEM, Bytes: b})), nil
+}
+
+// ParseServerKey reads a PEM server key.
+func ParseServerKey(pemStr string) (*ServerKey, error) {
+	blk, _ := pem.Decode([]byte(pemStr))
+	if blk == nil || blk.Type != serverKeyPEM {
+		return nil, errors.New("not a tls-crypt-v2 server key")
+	}
+	if len(blk.Bytes) != serverKeyLen {
+		return nil, fmt.Errorf("tls-crypt-v2 server key: %d bytes, want %d", len(blk.Bytes), serverKeyLen)
+	}
+	return &ServerKey{enc: blk.Bytes[:32], auth: blk.Bytes[64:96]}, nil
+}
+
+// WrapClientKey generates a client key carrying user metadata and returns it in
+// the PEM form of "openvpn --genkey tls-crypt-v2-client": Kc followed by
+// WKc = T || AES-256-CTR(Kc || metadata) || len, where T is the HMAC of
+// len || Kc || metadata and its first 16 bytes are the CTR IV.
+func (k *ServerKey) WrapClientKey(metadata []byte) (string, error) {
+	if len(metadata) > MaxMetadata {
+		return "", fmt.Errorf("tls-crypt-v2 metadata: %d bytes, at most %d", len(metadata), MaxMetadata)
+	}
+	kc := make([]byte, clientKeyLen)
+	if _, err := rand.Read(kc); err != nil {
+		return "", err
+	}
+	plain := append(append(kc[:clientKeyLen:clientKeyLen], MetadataUser), metadata...)
+	netLen := binary.BigEndian.AppendUint16(nil, uint16(tagLen+len(plain)+2))
+
+	mac := hmac.New(sha256.New, k.auth)
+	mac.Write(netLen)
+	mac.Write(plain)
+	tag := mac.Sum(nil)
+
+	block, err := aes.NewCipher(k.enc)
+	if err != nil {
+		return "", err
+	}
+	ct := make([]byte, len(plain))
+	cipher.NewCTR(block, tag[:aes.BlockSize]).XORKeyStream(ct, plain)
+
+	out := make([]byte, 0, clientKeyLen+tagLen+len(ct)+2)
+	out = append(out, kc...)
+	out = append(out, tag...)
+	out = append(out, ct...)
+	out = append(out, netLen...)
+	return string(pem.EncodeToMemory(&pem.Block{Type: clientKeyPEM, Bytes: out})), nil
+}
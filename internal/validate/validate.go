@@ -11,7 +11,10 @@ import (
 )
 
 var (
-	reCN = regexp.MustCompile(`^[A-Za-z0-9._-]{3,64}$`)
+	reCN     = regexp.MustCompile(`^[A-Za-z0-9._-]{3,64}$`)
+	reTenant = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+	reReqID  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+	reTmpl   = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
 )
 
 const (
@@ -91,6 +94,28 @@ func parseCNOnly(subj string) (string, error) {
 	return "", errors.New("invalid_subject_no_cn")
 }
 
+func Tenant(s string) error {
+	if !reTenant.MatchString(s) {
+		return errors.New("invalid_tenant")
+	}
+	return nil
+}
+
+func RequestID(s string) error {
+	if !reReqID.MatchString(s) {
+		return errors.New("invalid_request_id")
+	}
+	return nil
+}
+
+// Template checks a bundle profile template name.
+func Template(s string) error {
+	if !reTmpl.MatchString(s) {
+		return errors.New("invalid_template")
+	}
+	return nil
+}
+
 func SerialDec(s string) error {
 	if s == "" {
 		return errors.New("invalid_serial_empty")
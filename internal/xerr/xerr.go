@@ -1,6 +1,10 @@
 package xerr
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 type Code string
 
@@ -16,11 +20,67 @@ const (
 type E struct {
 	Code Code
 	Msg  string
+	// Field is the request field at fault, if any.
+	Field string
+	// Retry marks a failure the same request may not hit again later.
+	Retry bool
+	// Cause is the underlying error; it is logged but never sent to clients.
+	Cause error
 }
 
 func (e E) Error() string { return fmt.Sprintf("%s: %s", e.Code, e.Msg) }
+func (e E) Unwrap() error { return e.Cause }
 
 func Bad(msg string) error            { return E{Code: BadRequest, Msg: msg} }
 func InternalErr(msg string) error    { return E{Code: Internal, Msg: msg} }
 func NotImplemented(msg string) error { return E{Code: NotImpl, Msg: msg} }
 func ConflictErr(msg string) error    { return E{Code: Conflict, Msg: msg} }
+func ForbiddenErr(msg string) error   { return E{Code: Forbidden, Msg: msg} }
+
+// Invalid reports a bad value in request field.
+func Invalid(field string) error { return E{Code: BadRequest, Msg: field, Field: field} }
+
+// NotFoundErr reports that what request field names does not exist.
+func NotFoundErr(field string) error { return E{Code: NotFound, Msg: field, Field: field} }
+
+// Wrap is an internal error whose cause stays server-side.
+func Wrap(msg string, cause error) error { return E{Code: Internal, Msg: msg, Cause: cause} }
+
+// CodeOf returns the code of the first xerr.E in err's chain, or Internal for
+// any other non-nil error.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var e E
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Internal
+}
+
+// Public is what a client may see of err: the first E in its chain without
+// Cause. Any other error becomes a bare internal_error; timeouts are retryable.
+func Public(err error) E {
+	timeout := errors.Is(err, context.DeadlineExceeded)
+	var e E
+	switch {
+	case errors.As(err, &e):
+		e.Cause = nil
+	case timeout:
+		e = E{Code: Internal, Msg: "timeout"}
+	default:
+		e = E{Code: Internal, Msg: "internal"}
+	}
+	e.Retry = e.Retry || timeout
+	return e
+}
+
+// Detail is err including any Cause, for logs.
+func Detail(err error) string {
+	var e E
+	if errors.As(err, &e) && e.Cause != nil {
+		return err.Error() + ": " + e.Cause.Error()
+	}
+	return err.Error()
+}
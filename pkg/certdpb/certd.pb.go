@@ -0,0 +1,3333 @@
+// gRPC transport for vpn-certd. Each RPC is one socket op; field names match
+// the JSON protocol, so request and response semantics are documented once in
+// the README. Regenerate the Go stubs with `make proto`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: vpncertd/v1/certd.proto
+
+package certdpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HelloRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Protocols     []int32                `protobuf:"varint,1,rep,packed,name=protocols,proto3" json:"protocols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloRequest) Reset() {
+	*x = HelloRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloRequest) ProtoMessage() {}
+
+func (x *HelloRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloRequest.ProtoReflect.Descriptor instead.
+func (*HelloRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HelloRequest) GetProtocols() []int32 {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+type HelloResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Protocol      int32                  `protobuf:"varint,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Protocols     []int32                `protobuf:"varint,3,rep,packed,name=protocols,proto3" json:"protocols,omitempty"`
+	Ops           []string               `protobuf:"bytes,4,rep,name=ops,proto3" json:"ops,omitempty"`
+	Fields        []string               `protobuf:"bytes,5,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloResponse) Reset() {
+	*x = HelloResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloResponse) ProtoMessage() {}
+
+func (x *HelloResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloResponse.ProtoReflect.Descriptor instead.
+func (*HelloResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HelloResponse) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *HelloResponse) GetProtocol() int32 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *HelloResponse) GetProtocols() []int32 {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *HelloResponse) GetOps() []string {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+func (x *HelloResponse) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{2}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Time          string                 `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HealthResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StatusRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+type UnlockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Passphrase    string                 `protobuf:"bytes,2,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockRequest) Reset() {
+	*x = UnlockRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockRequest) ProtoMessage() {}
+
+func (x *UnlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockRequest.ProtoReflect.Descriptor instead.
+func (*UnlockRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UnlockRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *UnlockRequest) GetPassphrase() string {
+	if x != nil {
+		return x.Passphrase
+	}
+	return ""
+}
+
+type SignRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Cn            string                 `protobuf:"bytes,2,opt,name=cn,proto3" json:"cn,omitempty"`
+	Profile       string                 `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	Csr           string                 `protobuf:"bytes,4,opt,name=csr,proto3" json:"csr,omitempty"`
+	DryRun        bool                   `protobuf:"varint,5,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	RequestId     string                 `protobuf:"bytes,6,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignRequest) Reset() {
+	*x = SignRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignRequest) ProtoMessage() {}
+
+func (x *SignRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignRequest.ProtoReflect.Descriptor instead.
+func (*SignRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SignRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *SignRequest) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *SignRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *SignRequest) GetCsr() string {
+	if x != nil {
+		return x.Csr
+	}
+	return ""
+}
+
+func (x *SignRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *SignRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type GenKeyAndSignRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Cn            string                 `protobuf:"bytes,2,opt,name=cn,proto3" json:"cn,omitempty"`
+	Profile       string                 `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	KeyType       string                 `protobuf:"bytes,4,opt,name=key_type,json=keyType,proto3" json:"key_type,omitempty"`
+	Passphrase    string                 `protobuf:"bytes,5,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
+	DryRun        bool                   `protobuf:"varint,6,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	RequestId     string                 `protobuf:"bytes,7,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenKeyAndSignRequest) Reset() {
+	*x = GenKeyAndSignRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenKeyAndSignRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenKeyAndSignRequest) ProtoMessage() {}
+
+func (x *GenKeyAndSignRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenKeyAndSignRequest.ProtoReflect.Descriptor instead.
+func (*GenKeyAndSignRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GenKeyAndSignRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *GenKeyAndSignRequest) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *GenKeyAndSignRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *GenKeyAndSignRequest) GetKeyType() string {
+	if x != nil {
+		return x.KeyType
+	}
+	return ""
+}
+
+func (x *GenKeyAndSignRequest) GetPassphrase() string {
+	if x != nil {
+		return x.Passphrase
+	}
+	return ""
+}
+
+func (x *GenKeyAndSignRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *GenKeyAndSignRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type IssueResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CertPem         string                 `protobuf:"bytes,1,opt,name=cert_pem,json=certPem,proto3" json:"cert_pem,omitempty"`
+	ChainPem        string                 `protobuf:"bytes,2,opt,name=chain_pem,json=chainPem,proto3" json:"chain_pem,omitempty"`
+	KeyPemEncrypted string                 `protobuf:"bytes,3,opt,name=key_pem_encrypted,json=keyPemEncrypted,proto3" json:"key_pem_encrypted,omitempty"`
+	Serial          string                 `protobuf:"bytes,4,opt,name=serial,proto3" json:"serial,omitempty"`
+	CaId            string                 `protobuf:"bytes,5,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	NotAfter        string                 `protobuf:"bytes,6,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	DryRun          bool                   `protobuf:"varint,7,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Preview         *CertPreview           `protobuf:"bytes,8,opt,name=preview,proto3" json:"preview,omitempty"`
+	RequestId       string                 `protobuf:"bytes,9,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Replayed        bool                   `protobuf:"varint,10,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *IssueResponse) Reset() {
+	*x = IssueResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueResponse) ProtoMessage() {}
+
+func (x *IssueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueResponse.ProtoReflect.Descriptor instead.
+func (*IssueResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IssueResponse) GetCertPem() string {
+	if x != nil {
+		return x.CertPem
+	}
+	return ""
+}
+
+func (x *IssueResponse) GetChainPem() string {
+	if x != nil {
+		return x.ChainPem
+	}
+	return ""
+}
+
+func (x *IssueResponse) GetKeyPemEncrypted() string {
+	if x != nil {
+		return x.KeyPemEncrypted
+	}
+	return ""
+}
+
+func (x *IssueResponse) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *IssueResponse) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *IssueResponse) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *IssueResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *IssueResponse) GetPreview() *CertPreview {
+	if x != nil {
+		return x.Preview
+	}
+	return nil
+}
+
+func (x *IssueResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *IssueResponse) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+type CertPreview struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Issuer        string                 `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	CaId          string                 `protobuf:"bytes,3,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	Profile       string                 `protobuf:"bytes,4,opt,name=profile,proto3" json:"profile,omitempty"`
+	KeyType       string                 `protobuf:"bytes,5,opt,name=key_type,json=keyType,proto3" json:"key_type,omitempty"`
+	Sans          []string               `protobuf:"bytes,6,rep,name=sans,proto3" json:"sans,omitempty"`
+	KeyUsage      []string               `protobuf:"bytes,7,rep,name=key_usage,json=keyUsage,proto3" json:"key_usage,omitempty"`
+	ExtKeyUsage   []string               `protobuf:"bytes,8,rep,name=ext_key_usage,json=extKeyUsage,proto3" json:"ext_key_usage,omitempty"`
+	IsCa          bool                   `protobuf:"varint,9,opt,name=is_ca,json=isCa,proto3" json:"is_ca,omitempty"`
+	NotBefore     string                 `protobuf:"bytes,10,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter      string                 `protobuf:"bytes,11,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	ValidityDays  int32                  `protobuf:"varint,12,opt,name=validity_days,json=validityDays,proto3" json:"validity_days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CertPreview) Reset() {
+	*x = CertPreview{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertPreview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertPreview) ProtoMessage() {}
+
+func (x *CertPreview) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertPreview.ProtoReflect.Descriptor instead.
+func (*CertPreview) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CertPreview) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *CertPreview) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *CertPreview) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *CertPreview) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *CertPreview) GetKeyType() string {
+	if x != nil {
+		return x.KeyType
+	}
+	return ""
+}
+
+func (x *CertPreview) GetSans() []string {
+	if x != nil {
+		return x.Sans
+	}
+	return nil
+}
+
+func (x *CertPreview) GetKeyUsage() []string {
+	if x != nil {
+		return x.KeyUsage
+	}
+	return nil
+}
+
+func (x *CertPreview) GetExtKeyUsage() []string {
+	if x != nil {
+		return x.ExtKeyUsage
+	}
+	return nil
+}
+
+func (x *CertPreview) GetIsCa() bool {
+	if x != nil {
+		return x.IsCa
+	}
+	return false
+}
+
+func (x *CertPreview) GetNotBefore() string {
+	if x != nil {
+		return x.NotBefore
+	}
+	return ""
+}
+
+func (x *CertPreview) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *CertPreview) GetValidityDays() int32 {
+	if x != nil {
+		return x.ValidityDays
+	}
+	return 0
+}
+
+type RevokeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Serial        string                 `protobuf:"bytes,2,opt,name=serial,proto3" json:"serial,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	CaId          string                 `protobuf:"bytes,4,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	DryRun        bool                   `protobuf:"varint,5,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	RequestId     string                 `protobuf:"bytes,6,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeRequest) Reset() {
+	*x = RevokeRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeRequest) ProtoMessage() {}
+
+func (x *RevokeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeRequest.ProtoReflect.Descriptor instead.
+func (*RevokeRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RevokeRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *RevokeRequest) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *RevokeRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RevokeRequest) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *RevokeRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *RevokeRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type RevokeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CrlPem        string                 `protobuf:"bytes,1,opt,name=crl_pem,json=crlPem,proto3" json:"crl_pem,omitempty"`
+	CaId          string                 `protobuf:"bytes,2,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	DryRun        bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Preview       *RevokePreview         `protobuf:"bytes,4,opt,name=preview,proto3" json:"preview,omitempty"`
+	RequestId     string                 `protobuf:"bytes,5,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Replayed      bool                   `protobuf:"varint,6,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeResponse) Reset() {
+	*x = RevokeResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeResponse) ProtoMessage() {}
+
+func (x *RevokeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeResponse.ProtoReflect.Descriptor instead.
+func (*RevokeResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RevokeResponse) GetCrlPem() string {
+	if x != nil {
+		return x.CrlPem
+	}
+	return ""
+}
+
+func (x *RevokeResponse) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *RevokeResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *RevokeResponse) GetPreview() *RevokePreview {
+	if x != nil {
+		return x.Preview
+	}
+	return nil
+}
+
+func (x *RevokeResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RevokeResponse) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+type RevokePreview struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Serial        string                 `protobuf:"bytes,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	CaId          string                 `protobuf:"bytes,2,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	Cn            string                 `protobuf:"bytes,3,opt,name=cn,proto3" json:"cn,omitempty"`
+	Known         bool                   `protobuf:"varint,4,opt,name=known,proto3" json:"known,omitempty"`
+	CurrentReason string                 `protobuf:"bytes,5,opt,name=current_reason,json=currentReason,proto3" json:"current_reason,omitempty"`
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	Effect        string                 `protobuf:"bytes,7,opt,name=effect,proto3" json:"effect,omitempty"`
+	CrlEntries    int32                  `protobuf:"varint,8,opt,name=crl_entries,json=crlEntries,proto3" json:"crl_entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokePreview) Reset() {
+	*x = RevokePreview{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokePreview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokePreview) ProtoMessage() {}
+
+func (x *RevokePreview) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokePreview.ProtoReflect.Descriptor instead.
+func (*RevokePreview) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RevokePreview) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *RevokePreview) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *RevokePreview) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *RevokePreview) GetKnown() bool {
+	if x != nil {
+		return x.Known
+	}
+	return false
+}
+
+func (x *RevokePreview) GetCurrentReason() string {
+	if x != nil {
+		return x.CurrentReason
+	}
+	return ""
+}
+
+func (x *RevokePreview) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RevokePreview) GetEffect() string {
+	if x != nil {
+		return x.Effect
+	}
+	return ""
+}
+
+func (x *RevokePreview) GetCrlEntries() int32 {
+	if x != nil {
+		return x.CrlEntries
+	}
+	return 0
+}
+
+type RevokeTLSKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Serial        string                 `protobuf:"bytes,2,opt,name=serial,proto3" json:"serial,omitempty"`
+	CaId          string                 `protobuf:"bytes,3,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,4,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeTLSKeyRequest) Reset() {
+	*x = RevokeTLSKeyRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeTLSKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeTLSKeyRequest) ProtoMessage() {}
+
+func (x *RevokeTLSKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeTLSKeyRequest.ProtoReflect.Descriptor instead.
+func (*RevokeTLSKeyRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RevokeTLSKeyRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *RevokeTLSKeyRequest) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *RevokeTLSKeyRequest) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *RevokeTLSKeyRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type RevokeTLSKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Serial        string                 `protobuf:"bytes,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	CaId          string                 `protobuf:"bytes,2,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	TlsKeyId      string                 `protobuf:"bytes,3,opt,name=tls_key_id,json=tlsKeyId,proto3" json:"tls_key_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,4,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Replayed      bool                   `protobuf:"varint,5,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeTLSKeyResponse) Reset() {
+	*x = RevokeTLSKeyResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeTLSKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeTLSKeyResponse) ProtoMessage() {}
+
+func (x *RevokeTLSKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeTLSKeyResponse.ProtoReflect.Descriptor instead.
+func (*RevokeTLSKeyResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RevokeTLSKeyResponse) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *RevokeTLSKeyResponse) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *RevokeTLSKeyResponse) GetTlsKeyId() string {
+	if x != nil {
+		return x.TlsKeyId
+	}
+	return ""
+}
+
+func (x *RevokeTLSKeyResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RevokeTLSKeyResponse) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+type GetCertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Serial        string                 `protobuf:"bytes,2,opt,name=serial,proto3" json:"serial,omitempty"`
+	CaId          string                 `protobuf:"bytes,3,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCertRequest) Reset() {
+	*x = GetCertRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCertRequest) ProtoMessage() {}
+
+func (x *GetCertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCertRequest.ProtoReflect.Descriptor instead.
+func (*GetCertRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetCertRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *GetCertRequest) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *GetCertRequest) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+type CertInfo struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Cert             *IssuedCert            `protobuf:"bytes,1,opt,name=cert,proto3" json:"cert,omitempty"`
+	Revoked          bool                   `protobuf:"varint,2,opt,name=revoked,proto3" json:"revoked,omitempty"`
+	RevocationReason string                 `protobuf:"bytes,3,opt,name=revocation_reason,json=revocationReason,proto3" json:"revocation_reason,omitempty"`
+	RevokedAt        string                 `protobuf:"bytes,4,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	CertPem          string                 `protobuf:"bytes,5,opt,name=cert_pem,json=certPem,proto3" json:"cert_pem,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CertInfo) Reset() {
+	*x = CertInfo{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertInfo) ProtoMessage() {}
+
+func (x *CertInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertInfo.ProtoReflect.Descriptor instead.
+func (*CertInfo) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CertInfo) GetCert() *IssuedCert {
+	if x != nil {
+		return x.Cert
+	}
+	return nil
+}
+
+func (x *CertInfo) GetRevoked() bool {
+	if x != nil {
+		return x.Revoked
+	}
+	return false
+}
+
+func (x *CertInfo) GetRevocationReason() string {
+	if x != nil {
+		return x.RevocationReason
+	}
+	return ""
+}
+
+func (x *CertInfo) GetRevokedAt() string {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return ""
+}
+
+func (x *CertInfo) GetCertPem() string {
+	if x != nil {
+		return x.CertPem
+	}
+	return ""
+}
+
+type GetCRLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	CaId          string                 `protobuf:"bytes,2,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCRLRequest) Reset() {
+	*x = GetCRLRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCRLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCRLRequest) ProtoMessage() {}
+
+func (x *GetCRLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCRLRequest.ProtoReflect.Descriptor instead.
+func (*GetCRLRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetCRLRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *GetCRLRequest) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+type CRLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CrlPem        string                 `protobuf:"bytes,1,opt,name=crl_pem,json=crlPem,proto3" json:"crl_pem,omitempty"`
+	CaId          string                 `protobuf:"bytes,2,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Replayed      bool                   `protobuf:"varint,4,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CRLResponse) Reset() {
+	*x = CRLResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CRLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CRLResponse) ProtoMessage() {}
+
+func (x *CRLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CRLResponse.ProtoReflect.Descriptor instead.
+func (*CRLResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CRLResponse) GetCrlPem() string {
+	if x != nil {
+		return x.CrlPem
+	}
+	return ""
+}
+
+func (x *CRLResponse) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *CRLResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CRLResponse) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+type BuildBundleRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Tenant     string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Cn         string                 `protobuf:"bytes,2,opt,name=cn,proto3" json:"cn,omitempty"`
+	IncludeKey bool                   `protobuf:"varint,3,opt,name=include_key,json=includeKey,proto3" json:"include_key,omitempty"`
+	RemoteHost string                 `protobuf:"bytes,4,opt,name=remote_host,json=remoteHost,proto3" json:"remote_host,omitempty"`
+	RemotePort int32                  `protobuf:"varint,5,opt,name=remote_port,json=remotePort,proto3" json:"remote_port,omitempty"`
+	Proto      string                 `protobuf:"bytes,6,opt,name=proto,proto3" json:"proto,omitempty"`
+	// Names the profile template; empty uses the policy's choice for the
+	// certificate's profile, else the default.
+	Template      string `protobuf:"bytes,7,opt,name=template,proto3" json:"template,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildBundleRequest) Reset() {
+	*x = BuildBundleRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildBundleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildBundleRequest) ProtoMessage() {}
+
+func (x *BuildBundleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildBundleRequest.ProtoReflect.Descriptor instead.
+func (*BuildBundleRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BuildBundleRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *BuildBundleRequest) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *BuildBundleRequest) GetIncludeKey() bool {
+	if x != nil {
+		return x.IncludeKey
+	}
+	return false
+}
+
+func (x *BuildBundleRequest) GetRemoteHost() string {
+	if x != nil {
+		return x.RemoteHost
+	}
+	return ""
+}
+
+func (x *BuildBundleRequest) GetRemotePort() int32 {
+	if x != nil {
+		return x.RemotePort
+	}
+	return 0
+}
+
+func (x *BuildBundleRequest) GetProto() string {
+	if x != nil {
+		return x.Proto
+	}
+	return ""
+}
+
+func (x *BuildBundleRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+type BundleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Zip           []byte                 `protobuf:"bytes,1,opt,name=zip,proto3" json:"zip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BundleResponse) Reset() {
+	*x = BundleResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BundleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BundleResponse) ProtoMessage() {}
+
+func (x *BundleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BundleResponse.ProtoReflect.Descriptor instead.
+func (*BundleResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BundleResponse) GetZip() []byte {
+	if x != nil {
+		return x.Zip
+	}
+	return nil
+}
+
+type BatchRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Tenant string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// Defaults for items that leave them empty.
+	Profile       string       `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	KeyType       string       `protobuf:"bytes,3,opt,name=key_type,json=keyType,proto3" json:"key_type,omitempty"`
+	Passphrase    string       `protobuf:"bytes,4,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
+	Items         []*BatchItem `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	DryRun        bool         `protobuf:"varint,6,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	RequestId     string       `protobuf:"bytes,7,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchRequest) Reset() {
+	*x = BatchRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchRequest) ProtoMessage() {}
+
+func (x *BatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchRequest.ProtoReflect.Descriptor instead.
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BatchRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *BatchRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *BatchRequest) GetKeyType() string {
+	if x != nil {
+		return x.KeyType
+	}
+	return ""
+}
+
+func (x *BatchRequest) GetPassphrase() string {
+	if x != nil {
+		return x.Passphrase
+	}
+	return ""
+}
+
+func (x *BatchRequest) GetItems() []*BatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *BatchRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *BatchRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type BatchItem struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Cn         string                 `protobuf:"bytes,1,opt,name=cn,proto3" json:"cn,omitempty"`
+	Profile    string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	KeyType    string                 `protobuf:"bytes,3,opt,name=key_type,json=keyType,proto3" json:"key_type,omitempty"`
+	Passphrase string                 `protobuf:"bytes,4,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
+	// Set to also build a bundle of the new certificate.
+	Bundle        *BundleOptions `protobuf:"bytes,5,opt,name=bundle,proto3" json:"bundle,omitempty"`
+	RequestId     string         `protobuf:"bytes,6,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchItem) ProtoMessage() {}
+
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *BatchItem) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *BatchItem) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *BatchItem) GetKeyType() string {
+	if x != nil {
+		return x.KeyType
+	}
+	return ""
+}
+
+func (x *BatchItem) GetPassphrase() string {
+	if x != nil {
+		return x.Passphrase
+	}
+	return ""
+}
+
+func (x *BatchItem) GetBundle() *BundleOptions {
+	if x != nil {
+		return x.Bundle
+	}
+	return nil
+}
+
+func (x *BatchItem) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type BundleOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IncludeKey    bool                   `protobuf:"varint,1,opt,name=include_key,json=includeKey,proto3" json:"include_key,omitempty"`
+	RemoteHost    string                 `protobuf:"bytes,2,opt,name=remote_host,json=remoteHost,proto3" json:"remote_host,omitempty"`
+	RemotePort    int32                  `protobuf:"varint,3,opt,name=remote_port,json=remotePort,proto3" json:"remote_port,omitempty"`
+	Proto         string                 `protobuf:"bytes,4,opt,name=proto,proto3" json:"proto,omitempty"`
+	Template      string                 `protobuf:"bytes,5,opt,name=template,proto3" json:"template,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BundleOptions) Reset() {
+	*x = BundleOptions{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BundleOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BundleOptions) ProtoMessage() {}
+
+func (x *BundleOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BundleOptions.ProtoReflect.Descriptor instead.
+func (*BundleOptions) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BundleOptions) GetIncludeKey() bool {
+	if x != nil {
+		return x.IncludeKey
+	}
+	return false
+}
+
+func (x *BundleOptions) GetRemoteHost() string {
+	if x != nil {
+		return x.RemoteHost
+	}
+	return ""
+}
+
+func (x *BundleOptions) GetRemotePort() int32 {
+	if x != nil {
+		return x.RemotePort
+	}
+	return 0
+}
+
+func (x *BundleOptions) GetProto() string {
+	if x != nil {
+		return x.Proto
+	}
+	return ""
+}
+
+func (x *BundleOptions) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+type BatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per item, in item order.
+	Results       []*BatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResponse) Reset() {
+	*x = BatchResponse{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResponse) ProtoMessage() {}
+
+func (x *BatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResponse.ProtoReflect.Descriptor instead.
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *BatchResponse) GetResults() []*BatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Cn              string                 `protobuf:"bytes,1,opt,name=cn,proto3" json:"cn,omitempty"`
+	RequestId       string                 `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Serial          string                 `protobuf:"bytes,3,opt,name=serial,proto3" json:"serial,omitempty"`
+	CaId            string                 `protobuf:"bytes,4,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	NotAfter        string                 `protobuf:"bytes,5,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	CertPem         string                 `protobuf:"bytes,6,opt,name=cert_pem,json=certPem,proto3" json:"cert_pem,omitempty"`
+	KeyPemEncrypted string                 `protobuf:"bytes,7,opt,name=key_pem_encrypted,json=keyPemEncrypted,proto3" json:"key_pem_encrypted,omitempty"`
+	Zip             []byte                 `protobuf:"bytes,8,opt,name=zip,proto3" json:"zip,omitempty"`
+	Preview         *CertPreview           `protobuf:"bytes,9,opt,name=preview,proto3" json:"preview,omitempty"`
+	Replayed        bool                   `protobuf:"varint,10,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	HooksFailed     []string               `protobuf:"bytes,11,rep,name=hooks_failed,json=hooksFailed,proto3" json:"hooks_failed,omitempty"`
+	Error           *BatchError            `protobuf:"bytes,12,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BatchResult) Reset() {
+	*x = BatchResult{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResult) ProtoMessage() {}
+
+func (x *BatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResult.ProtoReflect.Descriptor instead.
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *BatchResult) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *BatchResult) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *BatchResult) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *BatchResult) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *BatchResult) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *BatchResult) GetCertPem() string {
+	if x != nil {
+		return x.CertPem
+	}
+	return ""
+}
+
+func (x *BatchResult) GetKeyPemEncrypted() string {
+	if x != nil {
+		return x.KeyPemEncrypted
+	}
+	return ""
+}
+
+func (x *BatchResult) GetZip() []byte {
+	if x != nil {
+		return x.Zip
+	}
+	return nil
+}
+
+func (x *BatchResult) GetPreview() *CertPreview {
+	if x != nil {
+		return x.Preview
+	}
+	return nil
+}
+
+func (x *BatchResult) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+func (x *BatchResult) GetHooksFailed() []string {
+	if x != nil {
+		return x.HooksFailed
+	}
+	return nil
+}
+
+func (x *BatchResult) GetError() *BatchError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type BatchError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Field         string                 `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Retryable     bool                   `protobuf:"varint,4,opt,name=retryable,proto3" json:"retryable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchError) Reset() {
+	*x = BatchError{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchError) ProtoMessage() {}
+
+func (x *BatchError) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchError.ProtoReflect.Descriptor instead.
+func (*BatchError) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *BatchError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *BatchError) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *BatchError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *BatchError) GetRetryable() bool {
+	if x != nil {
+		return x.Retryable
+	}
+	return false
+}
+
+type ListIssuedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIssuedRequest) Reset() {
+	*x = ListIssuedRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIssuedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIssuedRequest) ProtoMessage() {}
+
+func (x *ListIssuedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIssuedRequest.ProtoReflect.Descriptor instead.
+func (*ListIssuedRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListIssuedRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+type IssuedCert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Serial        string                 `protobuf:"bytes,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	Cn            string                 `protobuf:"bytes,2,opt,name=cn,proto3" json:"cn,omitempty"`
+	Profile       string                 `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	NotAfter      string                 `protobuf:"bytes,4,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	Sha256        string                 `protobuf:"bytes,5,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	CaId          string                 `protobuf:"bytes,6,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssuedCert) Reset() {
+	*x = IssuedCert{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssuedCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuedCert) ProtoMessage() {}
+
+func (x *IssuedCert) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuedCert.ProtoReflect.Descriptor instead.
+func (*IssuedCert) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *IssuedCert) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *IssuedCert) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *IssuedCert) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *IssuedCert) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *IssuedCert) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *IssuedCert) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+type ExpiringRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Days          int32                  `protobuf:"varint,2,opt,name=days,proto3" json:"days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpiringRequest) Reset() {
+	*x = ExpiringRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpiringRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpiringRequest) ProtoMessage() {}
+
+func (x *ExpiringRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpiringRequest.ProtoReflect.Descriptor instead.
+func (*ExpiringRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ExpiringRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *ExpiringRequest) GetDays() int32 {
+	if x != nil {
+		return x.Days
+	}
+	return 0
+}
+
+type ExpiringCert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CaId          string                 `protobuf:"bytes,1,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	Serial        string                 `protobuf:"bytes,2,opt,name=serial,proto3" json:"serial,omitempty"`
+	Cn            string                 `protobuf:"bytes,3,opt,name=cn,proto3" json:"cn,omitempty"`
+	Profile       string                 `protobuf:"bytes,4,opt,name=profile,proto3" json:"profile,omitempty"`
+	NotAfter      string                 `protobuf:"bytes,5,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	DaysLeft      int32                  `protobuf:"varint,6,opt,name=days_left,json=daysLeft,proto3" json:"days_left,omitempty"`
+	WindowDays    int32                  `protobuf:"varint,7,opt,name=window_days,json=windowDays,proto3" json:"window_days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpiringCert) Reset() {
+	*x = ExpiringCert{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpiringCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpiringCert) ProtoMessage() {}
+
+func (x *ExpiringCert) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpiringCert.ProtoReflect.Descriptor instead.
+func (*ExpiringCert) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ExpiringCert) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *ExpiringCert) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *ExpiringCert) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *ExpiringCert) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *ExpiringCert) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *ExpiringCert) GetDaysLeft() int32 {
+	if x != nil {
+		return x.DaysLeft
+	}
+	return 0
+}
+
+func (x *ExpiringCert) GetWindowDays() int32 {
+	if x != nil {
+		return x.WindowDays
+	}
+	return 0
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	SinceSeq      uint64                 `protobuf:"varint,2,opt,name=since_seq,json=sinceSeq,proto3" json:"since_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *SubscribeRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetSinceSeq() uint64 {
+	if x != nil {
+		return x.SinceSeq
+	}
+	return 0
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Time          string                 `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Cn            string                 `protobuf:"bytes,4,opt,name=cn,proto3" json:"cn,omitempty"`
+	Serial        string                 `protobuf:"bytes,5,opt,name=serial,proto3" json:"serial,omitempty"`
+	CaId          string                 `protobuf:"bytes,6,opt,name=ca_id,json=caId,proto3" json:"ca_id,omitempty"`
+	Profile       string                 `protobuf:"bytes,7,opt,name=profile,proto3" json:"profile,omitempty"`
+	Reason        string                 `protobuf:"bytes,8,opt,name=reason,proto3" json:"reason,omitempty"`
+	NotAfter      string                 `protobuf:"bytes,9,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	DaysLeft      int32                  `protobuf:"varint,10,opt,name=days_left,json=daysLeft,proto3" json:"days_left,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *Event) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *Event) GetCn() string {
+	if x != nil {
+		return x.Cn
+	}
+	return ""
+}
+
+func (x *Event) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *Event) GetCaId() string {
+	if x != nil {
+		return x.CaId
+	}
+	return ""
+}
+
+func (x *Event) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *Event) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Event) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *Event) GetDaysLeft() int32 {
+	if x != nil {
+		return x.DaysLeft
+	}
+	return 0
+}
+
+type StatusReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Verdict       string                 `protobuf:"bytes,1,opt,name=verdict,proto3" json:"verdict,omitempty"`
+	Tenant        string                 `protobuf:"bytes,2,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Problems      []string               `protobuf:"bytes,3,rep,name=problems,proto3" json:"problems,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	StartedAt     string                 `protobuf:"bytes,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	UptimeSeconds int64                  `protobuf:"varint,6,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	Cas           []*CAStatus            `protobuf:"bytes,7,rep,name=cas,proto3" json:"cas,omitempty"`
+	Crl           *CRLStatus             `protobuf:"bytes,8,opt,name=crl,proto3" json:"crl,omitempty"`
+	Storage       *StorageStatus         `protobuf:"bytes,9,opt,name=storage,proto3" json:"storage,omitempty"`
+	TaKeyPresent  bool                   `protobuf:"varint,10,opt,name=ta_key_present,json=taKeyPresent,proto3" json:"ta_key_present,omitempty"`
+	Policy        *PolicyStatus          `protobuf:"bytes,11,opt,name=policy,proto3" json:"policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusReport) Reset() {
+	*x = StatusReport{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusReport) ProtoMessage() {}
+
+func (x *StatusReport) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusReport.ProtoReflect.Descriptor instead.
+func (*StatusReport) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *StatusReport) GetVerdict() string {
+	if x != nil {
+		return x.Verdict
+	}
+	return ""
+}
+
+func (x *StatusReport) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *StatusReport) GetProblems() []string {
+	if x != nil {
+		return x.Problems
+	}
+	return nil
+}
+
+func (x *StatusReport) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *StatusReport) GetStartedAt() string {
+	if x != nil {
+		return x.StartedAt
+	}
+	return ""
+}
+
+func (x *StatusReport) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+func (x *StatusReport) GetCas() []*CAStatus {
+	if x != nil {
+		return x.Cas
+	}
+	return nil
+}
+
+func (x *StatusReport) GetCrl() *CRLStatus {
+	if x != nil {
+		return x.Crl
+	}
+	return nil
+}
+
+func (x *StatusReport) GetStorage() *StorageStatus {
+	if x != nil {
+		return x.Storage
+	}
+	return nil
+}
+
+func (x *StatusReport) GetTaKeyPresent() bool {
+	if x != nil {
+		return x.TaKeyPresent
+	}
+	return false
+}
+
+func (x *StatusReport) GetPolicy() *PolicyStatus {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+type CAStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Active        bool                   `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`
+	Subject       string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	NotAfter      string                 `protobuf:"bytes,4,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	DaysLeft      int32                  `protobuf:"varint,5,opt,name=days_left,json=daysLeft,proto3" json:"days_left,omitempty"`
+	KeyMatches    bool                   `protobuf:"varint,6,opt,name=key_matches,json=keyMatches,proto3" json:"key_matches,omitempty"`
+	Locked        bool                   `protobuf:"varint,7,opt,name=locked,proto3" json:"locked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CAStatus) Reset() {
+	*x = CAStatus{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CAStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CAStatus) ProtoMessage() {}
+
+func (x *CAStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CAStatus.ProtoReflect.Descriptor instead.
+func (*CAStatus) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *CAStatus) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CAStatus) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *CAStatus) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *CAStatus) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *CAStatus) GetDaysLeft() int32 {
+	if x != nil {
+		return x.DaysLeft
+	}
+	return 0
+}
+
+func (x *CAStatus) GetKeyMatches() bool {
+	if x != nil {
+		return x.KeyMatches
+	}
+	return false
+}
+
+func (x *CAStatus) GetLocked() bool {
+	if x != nil {
+		return x.Locked
+	}
+	return false
+}
+
+type CRLStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ThisUpdate    string                 `protobuf:"bytes,1,opt,name=this_update,json=thisUpdate,proto3" json:"this_update,omitempty"`
+	NextUpdate    string                 `protobuf:"bytes,2,opt,name=next_update,json=nextUpdate,proto3" json:"next_update,omitempty"`
+	AgeSeconds    int64                  `protobuf:"varint,3,opt,name=age_seconds,json=ageSeconds,proto3" json:"age_seconds,omitempty"`
+	DeployPath    string                 `protobuf:"bytes,4,opt,name=deploy_path,json=deployPath,proto3" json:"deploy_path,omitempty"`
+	Deployed      bool                   `protobuf:"varint,5,opt,name=deployed,proto3" json:"deployed,omitempty"`
+	InSync        bool                   `protobuf:"varint,6,opt,name=in_sync,json=inSync,proto3" json:"in_sync,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CRLStatus) Reset() {
+	*x = CRLStatus{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CRLStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CRLStatus) ProtoMessage() {}
+
+func (x *CRLStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CRLStatus.ProtoReflect.Descriptor instead.
+func (*CRLStatus) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *CRLStatus) GetThisUpdate() string {
+	if x != nil {
+		return x.ThisUpdate
+	}
+	return ""
+}
+
+func (x *CRLStatus) GetNextUpdate() string {
+	if x != nil {
+		return x.NextUpdate
+	}
+	return ""
+}
+
+func (x *CRLStatus) GetAgeSeconds() int64 {
+	if x != nil {
+		return x.AgeSeconds
+	}
+	return 0
+}
+
+func (x *CRLStatus) GetDeployPath() string {
+	if x != nil {
+		return x.DeployPath
+	}
+	return ""
+}
+
+func (x *CRLStatus) GetDeployed() bool {
+	if x != nil {
+		return x.Deployed
+	}
+	return false
+}
+
+func (x *CRLStatus) GetInSync() bool {
+	if x != nil {
+		return x.InSync
+	}
+	return false
+}
+
+type StorageStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StateDir      string                 `protobuf:"bytes,1,opt,name=state_dir,json=stateDir,proto3" json:"state_dir,omitempty"`
+	Writable      bool                   `protobuf:"varint,2,opt,name=writable,proto3" json:"writable,omitempty"`
+	FreeBytes     uint64                 `protobuf:"varint,3,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StorageStatus) Reset() {
+	*x = StorageStatus{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageStatus) ProtoMessage() {}
+
+func (x *StorageStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageStatus.ProtoReflect.Descriptor instead.
+func (*StorageStatus) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *StorageStatus) GetStateDir() string {
+	if x != nil {
+		return x.StateDir
+	}
+	return ""
+}
+
+func (x *StorageStatus) GetWritable() bool {
+	if x != nil {
+		return x.Writable
+	}
+	return false
+}
+
+func (x *StorageStatus) GetFreeBytes() uint64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+type PolicyStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Digest        string                 `protobuf:"bytes,2,opt,name=digest,proto3" json:"digest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PolicyStatus) Reset() {
+	*x = PolicyStatus{}
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PolicyStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyStatus) ProtoMessage() {}
+
+func (x *PolicyStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_vpncertd_v1_certd_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyStatus.ProtoReflect.Descriptor instead.
+func (*PolicyStatus) Descriptor() ([]byte, []int) {
+	return file_vpncertd_v1_certd_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *PolicyStatus) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PolicyStatus) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+var File_vpncertd_v1_certd_proto protoreflect.FileDescriptor
+
+const file_vpncertd_v1_certd_proto_rawDesc = "" +
+	"\n" +
+	"\x17vpncertd/v1/certd.proto\x12\vvpncertd.v1\",\n" +
+	"\fHelloRequest\x12\x1c\n" +
+	"\tprotocols\x18\x01 \x03(\x05R\tprotocols\"\x8b\x01\n" +
+	"\rHelloResponse\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x1a\n" +
+	"\bprotocol\x18\x02 \x01(\x05R\bprotocol\x12\x1c\n" +
+	"\tprotocols\x18\x03 \x03(\x05R\tprotocols\x12\x10\n" +
+	"\x03ops\x18\x04 \x03(\tR\x03ops\x12\x16\n" +
+	"\x06fields\x18\x05 \x03(\tR\x06fields\"\x0f\n" +
+	"\rHealthRequest\"<\n" +
+	"\x0eHealthResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\tR\x04time\"'\n" +
+	"\rStatusRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\"G\n" +
+	"\rUnlockRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x1e\n" +
+	"\n" +
+	"passphrase\x18\x02 \x01(\tR\n" +
+	"passphrase\"\x99\x01\n" +
+	"\vSignRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x0e\n" +
+	"\x02cn\x18\x02 \x01(\tR\x02cn\x12\x18\n" +
+	"\aprofile\x18\x03 \x01(\tR\aprofile\x12\x10\n" +
+	"\x03csr\x18\x04 \x01(\tR\x03csr\x12\x17\n" +
+	"\adry_run\x18\x05 \x01(\bR\x06dryRun\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x06 \x01(\tR\trequestId\"\xcb\x01\n" +
+	"\x14GenKeyAndSignRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x0e\n" +
+	"\x02cn\x18\x02 \x01(\tR\x02cn\x12\x18\n" +
+	"\aprofile\x18\x03 \x01(\tR\aprofile\x12\x19\n" +
+	"\bkey_type\x18\x04 \x01(\tR\akeyType\x12\x1e\n" +
+	"\n" +
+	"passphrase\x18\x05 \x01(\tR\n" +
+	"passphrase\x12\x17\n" +
+	"\adry_run\x18\x06 \x01(\bR\x06dryRun\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\a \x01(\tR\trequestId\"\xc5\x02\n" +
+	"\rIssueResponse\x12\x19\n" +
+	"\bcert_pem\x18\x01 \x01(\tR\acertPem\x12\x1b\n" +
+	"\tchain_pem\x18\x02 \x01(\tR\bchainPem\x12*\n" +
+	"\x11key_pem_encrypted\x18\x03 \x01(\tR\x0fkeyPemEncrypted\x12\x16\n" +
+	"\x06serial\x18\x04 \x01(\tR\x06serial\x12\x13\n" +
+	"\x05ca_id\x18\x05 \x01(\tR\x04caId\x12\x1b\n" +
+	"\tnot_after\x18\x06 \x01(\tR\bnotAfter\x12\x17\n" +
+	"\adry_run\x18\a \x01(\bR\x06dryRun\x122\n" +
+	"\apreview\x18\b \x01(\v2\x18.vpncertd.v1.CertPreviewR\apreview\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\t \x01(\tR\trequestId\x12\x1a\n" +
+	"\breplayed\x18\n" +
+	" \x01(\bR\breplayed\"\xd4\x02\n" +
+	"\vCertPreview\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x16\n" +
+	"\x06issuer\x18\x02 \x01(\tR\x06issuer\x12\x13\n" +
+	"\x05ca_id\x18\x03 \x01(\tR\x04caId\x12\x18\n" +
+	"\aprofile\x18\x04 \x01(\tR\aprofile\x12\x19\n" +
+	"\bkey_type\x18\x05 \x01(\tR\akeyType\x12\x12\n" +
+	"\x04sans\x18\x06 \x03(\tR\x04sans\x12\x1b\n" +
+	"\tkey_usage\x18\a \x03(\tR\bkeyUsage\x12\"\n" +
+	"\rext_key_usage\x18\b \x03(\tR\vextKeyUsage\x12\x13\n" +
+	"\x05is_ca\x18\t \x01(\bR\x04isCa\x12\x1d\n" +
+	"\n" +
+	"not_before\x18\n" +
+	" \x01(\tR\tnotBefore\x12\x1b\n" +
+	"\tnot_after\x18\v \x01(\tR\bnotAfter\x12#\n" +
+	"\rvalidity_days\x18\f \x01(\x05R\fvalidityDays\"\xa4\x01\n" +
+	"\rRevokeRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x16\n" +
+	"\x06serial\x18\x02 \x01(\tR\x06serial\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x13\n" +
+	"\x05ca_id\x18\x04 \x01(\tR\x04caId\x12\x17\n" +
+	"\adry_run\x18\x05 \x01(\bR\x06dryRun\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x06 \x01(\tR\trequestId\"\xc8\x01\n" +
+	"\x0eRevokeResponse\x12\x17\n" +
+	"\acrl_pem\x18\x01 \x01(\tR\x06crlPem\x12\x13\n" +
+	"\x05ca_id\x18\x02 \x01(\tR\x04caId\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\x124\n" +
+	"\apreview\x18\x04 \x01(\v2\x1a.vpncertd.v1.RevokePreviewR\apreview\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x05 \x01(\tR\trequestId\x12\x1a\n" +
+	"\breplayed\x18\x06 \x01(\bR\breplayed\"\xda\x01\n" +
+	"\rRevokePreview\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\tR\x06serial\x12\x13\n" +
+	"\x05ca_id\x18\x02 \x01(\tR\x04caId\x12\x0e\n" +
+	"\x02cn\x18\x03 \x01(\tR\x02cn\x12\x14\n" +
+	"\x05known\x18\x04 \x01(\bR\x05known\x12%\n" +
+	"\x0ecurrent_reason\x18\x05 \x01(\tR\rcurrentReason\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12\x16\n" +
+	"\x06effect\x18\a \x01(\tR\x06effect\x12\x1f\n" +
+	"\vcrl_entries\x18\b \x01(\x05R\n" +
+	"crlEntries\"y\n" +
+	"\x13RevokeTLSKeyRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x16\n" +
+	"\x06serial\x18\x02 \x01(\tR\x06serial\x12\x13\n" +
+	"\x05ca_id\x18\x03 \x01(\tR\x04caId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x04 \x01(\tR\trequestId\"\x9c\x01\n" +
+	"\x14RevokeTLSKeyResponse\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\tR\x06serial\x12\x13\n" +
+	"\x05ca_id\x18\x02 \x01(\tR\x04caId\x12\x1c\n" +
+	"\n" +
+	"tls_key_id\x18\x03 \x01(\tR\btlsKeyId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x04 \x01(\tR\trequestId\x12\x1a\n" +
+	"\breplayed\x18\x05 \x01(\bR\breplayed\"U\n" +
+	"\x0eGetCertRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x16\n" +
+	"\x06serial\x18\x02 \x01(\tR\x06serial\x12\x13\n" +
+	"\x05ca_id\x18\x03 \x01(\tR\x04caId\"\xb8\x01\n" +
+	"\bCertInfo\x12+\n" +
+	"\x04cert\x18\x01 \x01(\v2\x17.vpncertd.v1.IssuedCertR\x04cert\x12\x18\n" +
+	"\arevoked\x18\x02 \x01(\bR\arevoked\x12+\n" +
+	"\x11revocation_reason\x18\x03 \x01(\tR\x10revocationReason\x12\x1d\n" +
+	"\n" +
+	"revoked_at\x18\x04 \x01(\tR\trevokedAt\x12\x19\n" +
+	"\bcert_pem\x18\x05 \x01(\tR\acertPem\"<\n" +
+	"\rGetCRLRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x13\n" +
+	"\x05ca_id\x18\x02 \x01(\tR\x04caId\"v\n" +
+	"\vCRLResponse\x12\x17\n" +
+	"\acrl_pem\x18\x01 \x01(\tR\x06crlPem\x12\x13\n" +
+	"\x05ca_id\x18\x02 \x01(\tR\x04caId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\tR\trequestId\x12\x1a\n" +
+	"\breplayed\x18\x04 \x01(\bR\breplayed\"\xd1\x01\n" +
+	"\x12BuildBundleRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x0e\n" +
+	"\x02cn\x18\x02 \x01(\tR\x02cn\x12\x1f\n" +
+	"\vinclude_key\x18\x03 \x01(\bR\n" +
+	"includeKey\x12\x1f\n" +
+	"\vremote_host\x18\x04 \x01(\tR\n" +
+	"remoteHost\x12\x1f\n" +
+	"\vremote_port\x18\x05 \x01(\x05R\n" +
+	"remotePort\x12\x14\n" +
+	"\x05proto\x18\x06 \x01(\tR\x05proto\x12\x1a\n" +
+	"\btemplate\x18\a \x01(\tR\btemplate\"\"\n" +
+	"\x0eBundleResponse\x12\x10\n" +
+	"\x03zip\x18\x01 \x01(\fR\x03zip\"\xe1\x01\n" +
+	"\fBatchRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\x12\x19\n" +
+	"\bkey_type\x18\x03 \x01(\tR\akeyType\x12\x1e\n" +
+	"\n" +
+	"passphrase\x18\x04 \x01(\tR\n" +
+	"passphrase\x12,\n" +
+	"\x05items\x18\x05 \x03(\v2\x16.vpncertd.v1.BatchItemR\x05items\x12\x17\n" +
+	"\adry_run\x18\x06 \x01(\bR\x06dryRun\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\a \x01(\tR\trequestId\"\xc3\x01\n" +
+	"\tBatchItem\x12\x0e\n" +
+	"\x02cn\x18\x01 \x01(\tR\x02cn\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\x12\x19\n" +
+	"\bkey_type\x18\x03 \x01(\tR\akeyType\x12\x1e\n" +
+	"\n" +
+	"passphrase\x18\x04 \x01(\tR\n" +
+	"passphrase\x122\n" +
+	"\x06bundle\x18\x05 \x01(\v2\x1a.vpncertd.v1.BundleOptionsR\x06bundle\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x06 \x01(\tR\trequestId\"\xa4\x01\n" +
+	"\rBundleOptions\x12\x1f\n" +
+	"\vinclude_key\x18\x01 \x01(\bR\n" +
+	"includeKey\x12\x1f\n" +
+	"\vremote_host\x18\x02 \x01(\tR\n" +
+	"remoteHost\x12\x1f\n" +
+	"\vremote_port\x18\x03 \x01(\x05R\n" +
+	"remotePort\x12\x14\n" +
+	"\x05proto\x18\x04 \x01(\tR\x05proto\x12\x1a\n" +
+	"\btemplate\x18\x05 \x01(\tR\btemplate\"C\n" +
+	"\rBatchResponse\x122\n" +
+	"\aresults\x18\x01 \x03(\v2\x18.vpncertd.v1.BatchResultR\aresults\"\x81\x03\n" +
+	"\vBatchResult\x12\x0e\n" +
+	"\x02cn\x18\x01 \x01(\tR\x02cn\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12\x16\n" +
+	"\x06serial\x18\x03 \x01(\tR\x06serial\x12\x13\n" +
+	"\x05ca_id\x18\x04 \x01(\tR\x04caId\x12\x1b\n" +
+	"\tnot_after\x18\x05 \x01(\tR\bnotAfter\x12\x19\n" +
+	"\bcert_pem\x18\x06 \x01(\tR\acertPem\x12*\n" +
+	"\x11key_pem_encrypted\x18\a \x01(\tR\x0fkeyPemEncrypted\x12\x10\n" +
+	"\x03zip\x18\b \x01(\fR\x03zip\x122\n" +
+	"\apreview\x18\t \x01(\v2\x18.vpncertd.v1.CertPreviewR\apreview\x12\x1a\n" +
+	"\breplayed\x18\n" +
+	" \x01(\bR\breplayed\x12!\n" +
+	"\fhooks_failed\x18\v \x03(\tR\vhooksFailed\x12-\n" +
+	"\x05error\x18\f \x01(\v2\x17.vpncertd.v1.BatchErrorR\x05error\"n\n" +
+	"\n" +
+	"BatchError\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x14\n" +
+	"\x05field\x18\x02 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
+	"\tretryable\x18\x04 \x01(\bR\tretryable\"+\n" +
+	"\x11ListIssuedRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\"\x98\x01\n" +
+	"\n" +
+	"IssuedCert\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\tR\x06serial\x12\x0e\n" +
+	"\x02cn\x18\x02 \x01(\tR\x02cn\x12\x18\n" +
+	"\aprofile\x18\x03 \x01(\tR\aprofile\x12\x1b\n" +
+	"\tnot_after\x18\x04 \x01(\tR\bnotAfter\x12\x16\n" +
+	"\x06sha256\x18\x05 \x01(\tR\x06sha256\x12\x13\n" +
+	"\x05ca_id\x18\x06 \x01(\tR\x04caId\"=\n" +
+	"\x0fExpiringRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x12\n" +
+	"\x04days\x18\x02 \x01(\x05R\x04days\"\xc0\x01\n" +
+	"\fExpiringCert\x12\x13\n" +
+	"\x05ca_id\x18\x01 \x01(\tR\x04caId\x12\x16\n" +
+	"\x06serial\x18\x02 \x01(\tR\x06serial\x12\x0e\n" +
+	"\x02cn\x18\x03 \x01(\tR\x02cn\x12\x18\n" +
+	"\aprofile\x18\x04 \x01(\tR\aprofile\x12\x1b\n" +
+	"\tnot_after\x18\x05 \x01(\tR\bnotAfter\x12\x1b\n" +
+	"\tdays_left\x18\x06 \x01(\x05R\bdaysLeft\x12\x1f\n" +
+	"\vwindow_days\x18\a \x01(\x05R\n" +
+	"windowDays\"G\n" +
+	"\x10SubscribeRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x1b\n" +
+	"\tsince_seq\x18\x02 \x01(\x04R\bsinceSeq\"\xea\x01\n" +
+	"\x05Event\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\tR\x04time\x12\x0e\n" +
+	"\x02cn\x18\x04 \x01(\tR\x02cn\x12\x16\n" +
+	"\x06serial\x18\x05 \x01(\tR\x06serial\x12\x13\n" +
+	"\x05ca_id\x18\x06 \x01(\tR\x04caId\x12\x18\n" +
+	"\aprofile\x18\a \x01(\tR\aprofile\x12\x16\n" +
+	"\x06reason\x18\b \x01(\tR\x06reason\x12\x1b\n" +
+	"\tnot_after\x18\t \x01(\tR\bnotAfter\x12\x1b\n" +
+	"\tdays_left\x18\n" +
+	" \x01(\x05R\bdaysLeft\"\x9e\x03\n" +
+	"\fStatusReport\x12\x18\n" +
+	"\averdict\x18\x01 \x01(\tR\averdict\x12\x16\n" +
+	"\x06tenant\x18\x02 \x01(\tR\x06tenant\x12\x1a\n" +
+	"\bproblems\x18\x03 \x03(\tR\bproblems\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\tR\tstartedAt\x12%\n" +
+	"\x0euptime_seconds\x18\x06 \x01(\x03R\ruptimeSeconds\x12'\n" +
+	"\x03cas\x18\a \x03(\v2\x15.vpncertd.v1.CAStatusR\x03cas\x12(\n" +
+	"\x03crl\x18\b \x01(\v2\x16.vpncertd.v1.CRLStatusR\x03crl\x124\n" +
+	"\astorage\x18\t \x01(\v2\x1a.vpncertd.v1.StorageStatusR\astorage\x12$\n" +
+	"\x0eta_key_present\x18\n" +
+	" \x01(\bR\ftaKeyPresent\x121\n" +
+	"\x06policy\x18\v \x01(\v2\x19.vpncertd.v1.PolicyStatusR\x06policy\"\xbf\x01\n" +
+	"\bCAStatus\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06active\x18\x02 \x01(\bR\x06active\x12\x18\n" +
+	"\asubject\x18\x03 \x01(\tR\asubject\x12\x1b\n" +
+	"\tnot_after\x18\x04 \x01(\tR\bnotAfter\x12\x1b\n" +
+	"\tdays_left\x18\x05 \x01(\x05R\bdaysLeft\x12\x1f\n" +
+	"\vkey_matches\x18\x06 \x01(\bR\n" +
+	"keyMatches\x12\x16\n" +
+	"\x06locked\x18\a \x01(\bR\x06locked\"\xc4\x01\n" +
+	"\tCRLStatus\x12\x1f\n" +
+	"\vthis_update\x18\x01 \x01(\tR\n" +
+	"thisUpdate\x12\x1f\n" +
+	"\vnext_update\x18\x02 \x01(\tR\n" +
+	"nextUpdate\x12\x1f\n" +
+	"\vage_seconds\x18\x03 \x01(\x03R\n" +
+	"ageSeconds\x12\x1f\n" +
+	"\vdeploy_path\x18\x04 \x01(\tR\n" +
+	"deployPath\x12\x1a\n" +
+	"\bdeployed\x18\x05 \x01(\bR\bdeployed\x12\x17\n" +
+	"\ain_sync\x18\x06 \x01(\bR\x06inSync\"g\n" +
+	"\rStorageStatus\x12\x1b\n" +
+	"\tstate_dir\x18\x01 \x01(\tR\bstateDir\x12\x1a\n" +
+	"\bwritable\x18\x02 \x01(\bR\bwritable\x12\x1d\n" +
+	"\n" +
+	"free_bytes\x18\x03 \x01(\x04R\tfreeBytes\"@\n" +
+	"\fPolicyStatus\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06digest\x18\x02 \x01(\tR\x06digest2\x90\b\n" +
+	"\x05Certd\x12>\n" +
+	"\x05Hello\x12\x19.vpncertd.v1.HelloRequest\x1a\x1a.vpncertd.v1.HelloResponse\x12A\n" +
+	"\x06Health\x12\x1a.vpncertd.v1.HealthRequest\x1a\x1b.vpncertd.v1.HealthResponse\x12?\n" +
+	"\x06Status\x12\x1a.vpncertd.v1.StatusRequest\x1a\x19.vpncertd.v1.StatusReport\x12?\n" +
+	"\x06Unlock\x12\x1a.vpncertd.v1.UnlockRequest\x1a\x19.vpncertd.v1.StatusReport\x12<\n" +
+	"\x04Sign\x12\x18.vpncertd.v1.SignRequest\x1a\x1a.vpncertd.v1.IssueResponse\x12N\n" +
+	"\rGenKeyAndSign\x12!.vpncertd.v1.GenKeyAndSignRequest\x1a\x1a.vpncertd.v1.IssueResponse\x12A\n" +
+	"\x06Revoke\x12\x1a.vpncertd.v1.RevokeRequest\x1a\x1b.vpncertd.v1.RevokeResponse\x12S\n" +
+	"\fRevokeTLSKey\x12 .vpncertd.v1.RevokeTLSKeyRequest\x1a!.vpncertd.v1.RevokeTLSKeyResponse\x12=\n" +
+	"\aGetCert\x12\x1b.vpncertd.v1.GetCertRequest\x1a\x15.vpncertd.v1.CertInfo\x12>\n" +
+	"\x06GetCRL\x12\x1a.vpncertd.v1.GetCRLRequest\x1a\x18.vpncertd.v1.CRLResponse\x12K\n" +
+	"\vBuildBundle\x12\x1f.vpncertd.v1.BuildBundleRequest\x1a\x1b.vpncertd.v1.BundleResponse\x12>\n" +
+	"\x05Batch\x12\x19.vpncertd.v1.BatchRequest\x1a\x1a.vpncertd.v1.BatchResponse\x12G\n" +
+	"\n" +
+	"ListIssued\x12\x1e.vpncertd.v1.ListIssuedRequest\x1a\x17.vpncertd.v1.IssuedCert0\x01\x12E\n" +
+	"\bExpiring\x12\x1c.vpncertd.v1.ExpiringRequest\x1a\x19.vpncertd.v1.ExpiringCert0\x01\x12@\n" +
+	"\tSubscribe\x12\x1d.vpncertd.v1.SubscribeRequest\x1a\x12.vpncertd.v1.Event0\x01B\\\n" +
+	"\"com.github.harounahmad.vpncertd.v1P\x01Z4github.com/HarounAhmad/vpn-certd/pkg/certdpb;certdpbb\x06proto3"
+
+var (
+	file_vpncertd_v1_certd_proto_rawDescOnce sync.Once
+	file_vpncertd_v1_certd_proto_rawDescData []byte
+)
+
+func file_vpncertd_v1_certd_proto_rawDescGZIP() []byte {
+	file_vpncertd_v1_certd_proto_rawDescOnce.Do(func() {
+		file_vpncertd_v1_certd_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_vpncertd_v1_certd_proto_rawDesc), len(file_vpncertd_v1_certd_proto_rawDesc)))
+	})
+	return file_vpncertd_v1_certd_proto_rawDescData
+}
+
+var file_vpncertd_v1_certd_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
+var file_vpncertd_v1_certd_proto_goTypes = []any{
+	(*HelloRequest)(nil),         // 0: vpncertd.v1.HelloRequest
+	(*HelloResponse)(nil),        // 1: vpncertd.v1.HelloResponse
+	(*HealthRequest)(nil),        // 2: vpncertd.v1.HealthRequest
+	(*HealthResponse)(nil),       // 3: vpncertd.v1.HealthResponse
+	(*StatusRequest)(nil),        // 4: vpncertd.v1.StatusRequest
+	(*UnlockRequest)(nil),        // 5: vpncertd.v1.UnlockRequest
+	(*SignRequest)(nil),          // 6: vpncertd.v1.SignRequest
+	(*GenKeyAndSignRequest)(nil), // 7: vpncertd.v1.GenKeyAndSignRequest
+	(*IssueResponse)(nil),        // 8: vpncertd.v1.IssueResponse
+	(*CertPreview)(nil),          // 9: vpncertd.v1.CertPreview
+	(*RevokeRequest)(nil),        // 10: vpncertd.v1.RevokeRequest
+	(*RevokeResponse)(nil),       // 11: vpncertd.v1.RevokeResponse
+	(*RevokePreview)(nil),        // 12: vpncertd.v1.RevokePreview
+	(*RevokeTLSKeyRequest)(nil),  // 13: vpncertd.v1.RevokeTLSKeyRequest
+	(*RevokeTLSKeyResponse)(nil), // 14: vpncertd.v1.RevokeTLSKeyResponse
+	(*GetCertRequest)(nil),       // 15: vpncertd.v1.GetCertRequest
+	(*CertInfo)(nil),             // 16: vpncertd.v1.CertInfo
+	(*GetCRLRequest)(nil),        // 17: vpncertd.v1.GetCRLRequest
+	(*CRLResponse)(nil),          // 18: vpncertd.v1.CRLResponse
+	(*BuildBundleRequest)(nil),   // 19: vpncertd.v1.BuildBundleRequest
+	(*BundleResponse)(nil),       // 20: vpncertd.v1.BundleResponse
+	(*BatchRequest)(nil),         // 21: vpncertd.v1.BatchRequest
+	(*BatchItem)(nil),            // 22: vpncertd.v1.BatchItem
+	(*BundleOptions)(nil),        // 23: vpncertd.v1.BundleOptions
+	(*BatchResponse)(nil),        // 24: vpncertd.v1.BatchResponse
+	(*BatchResult)(nil),          // 25: vpncertd.v1.BatchResult
+	(*BatchError)(nil),           // 26: vpncertd.v1.BatchError
+	(*ListIssuedRequest)(nil),    // 27: vpncertd.v1.ListIssuedRequest
+	(*IssuedCert)(nil),           // 28: vpncertd.v1.IssuedCert
+	(*ExpiringRequest)(nil),      // 29: vpncertd.v1.ExpiringRequest
+	(*ExpiringCert)(nil),         // 30: vpncertd.v1.ExpiringCert
+	(*SubscribeRequest)(nil),     // 31: vpncertd.v1.SubscribeRequest
+	(*Event)(nil),                // 32: vpncertd.v1.Event
+	(*StatusReport)(nil),         // 33: vpncertd.v1.StatusReport
+	(*CAStatus)(nil),             // 34: vpncertd.v1.CAStatus
+	(*CRLStatus)(nil),            // 35: vpncertd.v1.CRLStatus
+	(*StorageStatus)(nil),        // 36: vpncertd.v1.StorageStatus
+	(*PolicyStatus)(nil),         // 37: vpncertd.v1.PolicyStatus
+}
+var file_vpncertd_v1_certd_proto_depIdxs = []int32{
+	9,  // 0: vpncertd.v1.IssueResponse.preview:type_name -> vpncertd.v1.CertPreview
+	12, // 1: vpncertd.v1.RevokeResponse.preview:type_name -> vpncertd.v1.RevokePreview
+	28, // 2: vpncertd.v1.CertInfo.cert:type_name -> vpncertd.v1.IssuedCert
+	22, // 3: vpncertd.v1.BatchRequest.items:type_name -> vpncertd.v1.BatchItem
+	23, // 4: vpncertd.v1.BatchItem.bundle:type_name -> vpncertd.v1.BundleOptions
+	25, // 5: vpncertd.v1.BatchResponse.results:type_name -> vpncertd.v1.BatchResult
+	9,  // 6: vpncertd.v1.BatchResult.preview:type_name -> vpncertd.v1.CertPreview
+	26, // 7: vpncertd.v1.BatchResult.error:type_name -> vpncertd.v1.BatchError
+	34, // 8: vpncertd.v1.StatusReport.cas:type_name -> vpncertd.v1.CAStatus
+	35, // 9: vpncertd.v1.StatusReport.crl:type_name -> vpncertd.v1.CRLStatus
+	36, // 10: vpncertd.v1.StatusReport.storage:type_name -> vpncertd.v1.StorageStatus
+	37, // 11: vpncertd.v1.StatusReport.policy:type_name -> vpncertd.v1.PolicyStatus
+	0,  // 12: vpncertd.v1.Certd.Hello:input_type -> vpncertd.v1.HelloRequest
+	2,  // 13: vpncertd.v1.Certd.Health:input_type -> vpncertd.v1.HealthRequest
+	4,  // 14: vpncertd.v1.Certd.Status:input_type -> vpncertd.v1.StatusRequest
+	5,  // 15: vpncertd.v1.Certd.Unlock:input_type -> vpncertd.v1.UnlockRequest
+	6,  // 16: vpncertd.v1.Certd.Sign:input_type -> vpncertd.v1.SignRequest
+	7,  // 17: vpncertd.v1.Certd.GenKeyAndSign:input_type -> vpncertd.v1.GenKeyAndSignRequest
+	10, // 18: vpncertd.v1.Certd.Revoke:input_type -> vpncertd.v1.RevokeRequest
+	13, // 19: vpncertd.v1.Certd.RevokeTLSKey:input_type -> vpncertd.v1.RevokeTLSKeyRequest
+	15, // 20: vpncertd.v1.Certd.GetCert:input_type -> vpncertd.v1.GetCertRequest
+	17, // 21: vpncertd.v1.Certd.GetCRL:input_type -> vpncertd.v1.GetCRLRequest
+	19, // 22: vpncertd.v1.Certd.BuildBundle:input_type -> vpncertd.v1.BuildBundleRequest
+	21, // 23: vpncertd.v1.Certd.Batch:input_type -> vpncertd.v1.BatchRequest
+	27, // 24: vpncertd.v1.Certd.ListIssued:input_type -> vpncertd.v1.ListIssuedRequest
+	29, // 25: vpncertd.v1.Certd.Expiring:input_type -> vpncertd.v1.ExpiringRequest
+	31, // 26: vpncertd.v1.Certd.Subscribe:input_type -> vpncertd.v1.SubscribeRequest
+	1,  // 27: vpncertd.v1.Certd.Hello:output_type -> vpncertd.v1.HelloResponse
+	3,  // 28: vpncertd.v1.Certd.Health:output_type -> vpncertd.v1.HealthResponse
+	33, // 29: vpncertd.v1.Certd.Status:output_type -> vpncertd.v1.StatusReport
+	33, // 30: vpncertd.v1.Certd.Unlock:output_type -> vpncertd.v1.StatusReport
+	8,  // 31: vpncertd.v1.Certd.Sign:output_type -> vpncertd.v1.IssueResponse
+	8,  // 32: vpncertd.v1.Certd.GenKeyAndSign:output_type -> vpncertd.v1.IssueResponse
+	11, // 33: vpncertd.v1.Certd.Revoke:output_type -> vpncertd.v1.RevokeResponse
+	14, // 34: vpncertd.v1.Certd.RevokeTLSKey:output_type -> vpncertd.v1.RevokeTLSKeyResponse
+	16, // 35: vpncertd.v1.Certd.GetCert:output_type -> vpncertd.v1.CertInfo
+	18, // 36: vpncertd.v1.Certd.GetCRL:output_type -> vpncertd.v1.CRLResponse
+	20, // 37: vpncertd.v1.Certd.BuildBundle:output_type -> vpncertd.v1.BundleResponse
+	24, // 38: vpncertd.v1.Certd.Batch:output_type -> vpncertd.v1.BatchResponse
+	28, // 39: vpncertd.v1.Certd.ListIssued:output_type -> vpncertd.v1.IssuedCert
+	30, // 40: vpncertd.v1.Certd.Expiring:output_type -> vpncertd.v1.ExpiringCert
+	32, // 41: vpncertd.v1.Certd.Subscribe:output_type -> vpncertd.v1.Event
+	27, // [27:42] is the sub-list for method output_type
+	12, // [12:27] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_vpncertd_v1_certd_proto_init() }
+func file_vpncertd_v1_certd_proto_init() {
+	if File_vpncertd_v1_certd_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_vpncertd_v1_certd_proto_rawDesc), len(file_vpncertd_v1_certd_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   38,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_vpncertd_v1_certd_proto_goTypes,
+		DependencyIndexes: file_vpncertd_v1_certd_proto_depIdxs,
+		MessageInfos:      file_vpncertd_v1_certd_proto_msgTypes,
+	}.Build()
+	File_vpncertd_v1_certd_proto = out.File
+	file_vpncertd_v1_certd_proto_goTypes = nil
+	file_vpncertd_v1_certd_proto_depIdxs = nil
+}
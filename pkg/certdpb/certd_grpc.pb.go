@@ -0,0 +1,679 @@
+// gRPC transport for vpn-certd. Each RPC is one socket op; field names match
+// the JSON protocol, so request and response semantics are documented once in
+// the README. Regenerate the Go stubs with `make proto`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: vpncertd/v1/certd.proto
+
+package certdpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Certd_Hello_FullMethodName         = "/vpncertd.v1.Certd/Hello"
+	Certd_Health_FullMethodName        = "/vpncertd.v1.Certd/Health"
+	Certd_Status_FullMethodName        = "/vpncertd.v1.Certd/Status"
+	Certd_Unlock_FullMethodName        = "/vpncertd.v1.Certd/Unlock"
+	Certd_Sign_FullMethodName          = "/vpncertd.v1.Certd/Sign"
+	Certd_GenKeyAndSign_FullMethodName = "/vpncertd.v1.Certd/GenKeyAndSign"
+	Certd_Revoke_FullMethodName        = "/vpncertd.v1.Certd/Revoke"
+	Certd_RevokeTLSKey_FullMethodName  = "/vpncertd.v1.Certd/RevokeTLSKey"
+	Certd_GetCert_FullMethodName       = "/vpncertd.v1.Certd/GetCert"
+	Certd_GetCRL_FullMethodName        = "/vpncertd.v1.Certd/GetCRL"
+	Certd_BuildBundle_FullMethodName   = "/vpncertd.v1.Certd/BuildBundle"
+	Certd_Batch_FullMethodName         = "/vpncertd.v1.Certd/Batch"
+	Certd_ListIssued_FullMethodName    = "/vpncertd.v1.Certd/ListIssued"
+	Certd_Expiring_FullMethodName      = "/vpncertd.v1.Certd/Expiring"
+	Certd_Subscribe_FullMethodName     = "/vpncertd.v1.Certd/Subscribe"
+)
+
+// CertdClient is the client API for Certd service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CertdClient interface {
+	Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloResponse, error)
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusReport, error)
+	Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*StatusReport, error)
+	Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*IssueResponse, error)
+	GenKeyAndSign(ctx context.Context, in *GenKeyAndSignRequest, opts ...grpc.CallOption) (*IssueResponse, error)
+	Revoke(ctx context.Context, in *RevokeRequest, opts ...grpc.CallOption) (*RevokeResponse, error)
+	RevokeTLSKey(ctx context.Context, in *RevokeTLSKeyRequest, opts ...grpc.CallOption) (*RevokeTLSKeyResponse, error)
+	GetCert(ctx context.Context, in *GetCertRequest, opts ...grpc.CallOption) (*CertInfo, error)
+	GetCRL(ctx context.Context, in *GetCRLRequest, opts ...grpc.CallOption) (*CRLResponse, error)
+	BuildBundle(ctx context.Context, in *BuildBundleRequest, opts ...grpc.CallOption) (*BundleResponse, error)
+	// Batch is one BATCH: the items run in order and fail individually, so
+	// per-item errors come back in the results rather than as the call status.
+	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// Lists are streamed one entry per message. ListIssued sends every
+	// issuance record, CA by CA in issuance order, with no cap; the JSON
+	// LIST_ISSUED op returns only the latest 200.
+	ListIssued(ctx context.Context, in *ListIssuedRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IssuedCert], error)
+	Expiring(ctx context.Context, in *ExpiringRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExpiringCert], error)
+	// Subscribe streams lifecycle events until the client cancels.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type certdClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCertdClient(cc grpc.ClientConnInterface) CertdClient {
+	return &certdClient{cc}
+}
+
+func (c *certdClient) Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HelloResponse)
+	err := c.cc.Invoke(ctx, Certd_Hello_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, Certd_Health_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusReport)
+	err := c.cc.Invoke(ctx, Certd_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*StatusReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusReport)
+	err := c.cc.Invoke(ctx, Certd_Unlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*IssueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IssueResponse)
+	err := c.cc.Invoke(ctx, Certd_Sign_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) GenKeyAndSign(ctx context.Context, in *GenKeyAndSignRequest, opts ...grpc.CallOption) (*IssueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IssueResponse)
+	err := c.cc.Invoke(ctx, Certd_GenKeyAndSign_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) Revoke(ctx context.Context, in *RevokeRequest, opts ...grpc.CallOption) (*RevokeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeResponse)
+	err := c.cc.Invoke(ctx, Certd_Revoke_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) RevokeTLSKey(ctx context.Context, in *RevokeTLSKeyRequest, opts ...grpc.CallOption) (*RevokeTLSKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeTLSKeyResponse)
+	err := c.cc.Invoke(ctx, Certd_RevokeTLSKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) GetCert(ctx context.Context, in *GetCertRequest, opts ...grpc.CallOption) (*CertInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CertInfo)
+	err := c.cc.Invoke(ctx, Certd_GetCert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) GetCRL(ctx context.Context, in *GetCRLRequest, opts ...grpc.CallOption) (*CRLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CRLResponse)
+	err := c.cc.Invoke(ctx, Certd_GetCRL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) BuildBundle(ctx context.Context, in *BuildBundleRequest, opts ...grpc.CallOption) (*BundleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BundleResponse)
+	err := c.cc.Invoke(ctx, Certd_BuildBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, Certd_Batch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certdClient) ListIssued(ctx context.Context, in *ListIssuedRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IssuedCert], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Certd_ServiceDesc.Streams[0], Certd_ListIssued_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListIssuedRequest, IssuedCert]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Certd_ListIssuedClient = grpc.ServerStreamingClient[IssuedCert]
+
+func (c *certdClient) Expiring(ctx context.Context, in *ExpiringRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExpiringCert], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Certd_ServiceDesc.Streams[1], Certd_Expiring_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExpiringRequest, ExpiringCert]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Certd_ExpiringClient = grpc.ServerStreamingClient[ExpiringCert]
+
+func (c *certdClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Certd_ServiceDesc.Streams[2], Certd_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Certd_SubscribeClient = grpc.ServerStreamingClient[Event]
+
+// CertdServer is the server API for Certd service.
+// All implementations must embed UnimplementedCertdServer
+// for forward compatibility.
+type CertdServer interface {
+	Hello(context.Context, *HelloRequest) (*HelloResponse, error)
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	Status(context.Context, *StatusRequest) (*StatusReport, error)
+	Unlock(context.Context, *UnlockRequest) (*StatusReport, error)
+	Sign(context.Context, *SignRequest) (*IssueResponse, error)
+	GenKeyAndSign(context.Context, *GenKeyAndSignRequest) (*IssueResponse, error)
+	Revoke(context.Context, *RevokeRequest) (*RevokeResponse, error)
+	RevokeTLSKey(context.Context, *RevokeTLSKeyRequest) (*RevokeTLSKeyResponse, error)
+	GetCert(context.Context, *GetCertRequest) (*CertInfo, error)
+	GetCRL(context.Context, *GetCRLRequest) (*CRLResponse, error)
+	BuildBundle(context.Context, *BuildBundleRequest) (*BundleResponse, error)
+	// Batch is one BATCH: the items run in order and fail individually, so
+	// per-item errors come back in the results rather than as the call status.
+	Batch(context.Context, *BatchRequest) (*BatchResponse, error)
+	// Lists are streamed one entry per message. ListIssued sends every
+	// issuance record, CA by CA in issuance order, with no cap; the JSON
+	// LIST_ISSUED op returns only the latest 200.
+	ListIssued(*ListIssuedRequest, grpc.ServerStreamingServer[IssuedCert]) error
+	Expiring(*ExpiringRequest, grpc.ServerStreamingServer[ExpiringCert]) error
+	// Subscribe streams lifecycle events until the client cancels.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedCertdServer()
+}
+
+// UnimplementedCertdServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCertdServer struct{}
+
+func (UnimplementedCertdServer) Hello(context.Context, *HelloRequest) (*HelloResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hello not implemented")
+}
+func (UnimplementedCertdServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedCertdServer) Status(context.Context, *StatusRequest) (*StatusReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedCertdServer) Unlock(context.Context, *UnlockRequest) (*StatusReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unlock not implemented")
+}
+func (UnimplementedCertdServer) Sign(context.Context, *SignRequest) (*IssueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sign not implemented")
+}
+func (UnimplementedCertdServer) GenKeyAndSign(context.Context, *GenKeyAndSignRequest) (*IssueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenKeyAndSign not implemented")
+}
+func (UnimplementedCertdServer) Revoke(context.Context, *RevokeRequest) (*RevokeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Revoke not implemented")
+}
+func (UnimplementedCertdServer) RevokeTLSKey(context.Context, *RevokeTLSKeyRequest) (*RevokeTLSKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeTLSKey not implemented")
+}
+func (UnimplementedCertdServer) GetCert(context.Context, *GetCertRequest) (*CertInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCert not implemented")
+}
+func (UnimplementedCertdServer) GetCRL(context.Context, *GetCRLRequest) (*CRLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCRL not implemented")
+}
+func (UnimplementedCertdServer) BuildBundle(context.Context, *BuildBundleRequest) (*BundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuildBundle not implemented")
+}
+func (UnimplementedCertdServer) Batch(context.Context, *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Batch not implemented")
+}
+func (UnimplementedCertdServer) ListIssued(*ListIssuedRequest, grpc.ServerStreamingServer[IssuedCert]) error {
+	return status.Errorf(codes.Unimplemented, "method ListIssued not implemented")
+}
+func (UnimplementedCertdServer) Expiring(*ExpiringRequest, grpc.ServerStreamingServer[ExpiringCert]) error {
+	return status.Errorf(codes.Unimplemented, "method Expiring not implemented")
+}
+func (UnimplementedCertdServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedCertdServer) mustEmbedUnimplementedCertdServer() {}
+func (UnimplementedCertdServer) testEmbeddedByValue()               {}
+
+// UnsafeCertdServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CertdServer will
+// result in compilation errors.
+type UnsafeCertdServer interface {
+	mustEmbedUnimplementedCertdServer()
+}
+
+func RegisterCertdServer(s grpc.ServiceRegistrar, srv CertdServer) {
+	// If the following call pancis, it indicates UnimplementedCertdServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Certd_ServiceDesc, srv)
+}
+
+func _Certd_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).Hello(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_Hello_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).Hello(ctx, req.(*HelloRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_Unlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).Unlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_Unlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).Unlock(ctx, req.(*UnlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_Sign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).Sign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_Sign_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).Sign(ctx, req.(*SignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_GenKeyAndSign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenKeyAndSignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).GenKeyAndSign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_GenKeyAndSign_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).GenKeyAndSign(ctx, req.(*GenKeyAndSignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_Revoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).Revoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_Revoke_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).Revoke(ctx, req.(*RevokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_RevokeTLSKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeTLSKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).RevokeTLSKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_RevokeTLSKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).RevokeTLSKey(ctx, req.(*RevokeTLSKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_GetCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).GetCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_GetCert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).GetCert(ctx, req.(*GetCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_GetCRL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCRLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).GetCRL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_GetCRL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).GetCRL(ctx, req.(*GetCRLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_BuildBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).BuildBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_BuildBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).BuildBundle(ctx, req.(*BuildBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertdServer).Batch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Certd_Batch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertdServer).Batch(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Certd_ListIssued_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListIssuedRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CertdServer).ListIssued(m, &grpc.GenericServerStream[ListIssuedRequest, IssuedCert]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Certd_ListIssuedServer = grpc.ServerStreamingServer[IssuedCert]
+
+func _Certd_Expiring_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExpiringRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CertdServer).Expiring(m, &grpc.GenericServerStream[ExpiringRequest, ExpiringCert]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Certd_ExpiringServer = grpc.ServerStreamingServer[ExpiringCert]
+
+func _Certd_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CertdServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Certd_SubscribeServer = grpc.ServerStreamingServer[Event]
+
+// Certd_ServiceDesc is the grpc.ServiceDesc for Certd service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Certd_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vpncertd.v1.Certd",
+	HandlerType: (*CertdServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Hello",
+			Handler:    _Certd_Hello_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _Certd_Health_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _Certd_Status_Handler,
+		},
+		{
+			MethodName: "Unlock",
+			Handler:    _Certd_Unlock_Handler,
+		},
+		{
+			MethodName: "Sign",
+			Handler:    _Certd_Sign_Handler,
+		},
+		{
+			MethodName: "GenKeyAndSign",
+			Handler:    _Certd_GenKeyAndSign_Handler,
+		},
+		{
+			MethodName: "Revoke",
+			Handler:    _Certd_Revoke_Handler,
+		},
+		{
+			MethodName: "RevokeTLSKey",
+			Handler:    _Certd_RevokeTLSKey_Handler,
+		},
+		{
+			MethodName: "GetCert",
+			Handler:    _Certd_GetCert_Handler,
+		},
+		{
+			MethodName: "GetCRL",
+			Handler:    _Certd_GetCRL_Handler,
+		},
+		{
+			MethodName: "BuildBundle",
+			Handler:    _Certd_BuildBundle_Handler,
+		},
+		{
+			MethodName: "Batch",
+			Handler:    _Certd_Batch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListIssued",
+			Handler:       _Certd_ListIssued_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Expiring",
+			Handler:       _Certd_Expiring_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Certd_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "vpncertd/v1/certd.proto",
+}
@@ -0,0 +1,263 @@
+// Package client talks to vpn-certd over its UNIX socket. Each call is one
+// request on its own connection (wire protocol 1), so a Client is safe for
+// concurrent use.
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/idempotency"
+)
+
+// Wire types, re-exported so callers outside this module can name them.
+type (
+	Request     = api.Request
+	Response    = api.Response
+	Profile     = api.Profile
+	KeyType     = api.KeyType
+	IssuedMeta  = api.IssuedMeta
+	BundleReq   = api.BundleReq
+	BatchItem   = api.BatchItem
+	BatchResult = api.BatchResult
+)
+
+const (
+	ProfileClient = api.ProfileClient
+	ProfileServer = api.ProfileServer
+	KeyRSA4096    = api.KeyRSA4096
+	KeyEd25519    = api.KeyEd25519
+)
+
+// Defaults used when the Client fields are zero.
+const (
+	DefaultTimeout = 30 * time.Second
+	DefaultBackoff = 200 * time.Millisecond
+)
+
+type Client struct {
+	Socket string
+	// Tenant is sent with every request; empty selects the daemon's default.
+	Tenant string
+	// Timeout bounds each attempt; the context deadline still applies.
+	Timeout time.Duration
+	// Retries is how often a transient failure is retried. Mutating requests
+	// without a request_id get a generated one and are only resent after they
+	// may have reached the daemon if its HELLO reports idempotency records, so
+	// a retry never issues or revokes twice. GENKEY_AND_SIGN and BATCH are
+	// never resent then: a replay does not carry the generated private key.
+	Retries int
+	// Backoff is the wait before the first retry; it doubles after each.
+	Backoff time.Duration
+
+	dial func(ctx context.Context) (net.Conn, error)
+
+	mu         sync.Mutex
+	idempotent *bool
+}
+
+// New returns a Client for the daemon socket at path that retries twice.
+func New(path string) *Client {
+	return &Client{Socket: path, Retries: 2}
+}
+
+// NewWithDialer returns a Client that opens connections with dial instead of
+// the socket, e.g. to an in-memory server in tests.
+func NewWithDialer(dial func(ctx context.Context) (net.Conn, error)) *Client {
+	return &Client{Retries: 2, dial: dial}
+}
+
+// Option adjusts a request before it is sent.
+type Option func(*Request)
+
+// WithRequestID sets the idempotency key; a retry with the same ID gets the
+// first response.
+func WithRequestID(id string) Option { return func(r *Request) { r.RequestID = id } }
+
+// WithCA selects the CA for ops that take ca_id.
+func WithCA(id string) Option { return func(r *Request) { r.CAID = id } }
+
+// DryRun validates SIGN, GENKEY_AND_SIGN and REVOKE without committing them.
+func DryRun() Option { return func(r *Request) { r.DryRun = true } }
+
+// Issue generates a key pair for cn and signs it. The key comes back in
+// KeyPEMEnc, encrypted under passphrase.
+func (c *Client) Issue(ctx context.Context, cn string, profile Profile, kt KeyType, passphrase string, opts ...Option) (Response, error) {
+	return c.Do(ctx, Request{Op: api.OpGenKeyAndSign, CN: cn, Profile: profile, KeyType: kt, Passphrase: passphrase}, opts...)
+}
+
+// Sign signs a PEM CSR for cn.
+func (c *Client) Sign(ctx context.Context, cn string, profile Profile, csrPEM string, opts ...Option) (Response, error) {
+	return c.Do(ctx, Request{Op: api.OpSign, CN: cn, Profile: profile, CSRPEM: csrPEM}, opts...)
+}
+
+// Revoke revokes serial and returns the re-signed CRL in CRLPEM.
+func (c *Client) Revoke(ctx context.Context, serial, reason string, opts ...Option) (Response, error) {
+	return c.Do(ctx, Request{Op: api.OpRevoke, Serial: serial, Reason: reason}, opts...)
+}
+
+// GetCRL returns the PEM CRL, of every CA unless WithCA is given.
+func (c *Client) GetCRL(ctx context.Context, opts ...Option) (string, error) {
+	resp, err := c.Do(ctx, Request{Op: api.OpGetCRL}, opts...)
+	return resp.CRLPEM, err
+}
+
+// ListIssued returns the latest 200 issuance records; the gRPC ListIssued
+// stream has them all.
+func (c *Client) ListIssued(ctx context.Context, opts ...Option) ([]IssuedMeta, error) {
+	resp, err := c.Do(ctx, Request{Op: api.OpListIssued}, opts...)
+	return resp.Issued, err
+}
+
+// BuildBundle returns the client bundle zip.
+func (c *Client) BuildBundle(ctx context.Context, b BundleReq, opts ...Option) ([]byte, error) {
+	resp, err := c.Do(ctx, Request{Op: api.OpBuildBundle, Bundle: &b}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	zip, err := base64.StdEncoding.DecodeString(resp.ZipB64)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	return zip, nil
+}
+
+// Batch issues every item and, where the item asks for it, builds its bundle.
+// Items fail individually: check each result's Err. Like Issue it is not
+// resent once it may have reached the daemon; with WithRequestID the daemon
+// derives one request_id per item, so a caller can resend it and get the rows
+// already issued back, without their keys.
+func (c *Client) Batch(ctx context.Context, items []BatchItem, opts ...Option) ([]BatchResult, error) {
+	resp, err := c.Do(ctx, Request{Op: api.OpBatch, Items: items}, opts...)
+	return resp.Batch, err
+}
+
+// Do sends req, retrying transient failures. A failure reported by the daemon
+// is an *Error; the response is returned alongside it.
+func (c *Client) Do(ctx context.Context, req Request, opts ...Option) (Response, error) {
+	for _, o := range opts {
+		o(&req)
+	}
+	if req.Tenant == "" {
+		req.Tenant = c.Tenant
+	}
+	mutating := (idempotency.Applies(req.Op) || req.Op == api.OpBatch) && !req.DryRun
+	if c.Retries > 0 && req.RequestID == "" && mutating && !generatesKey(req.Op) {
+		req.RequestID = newRequestID()
+	}
+	// resending is safe when the daemon either never saw the request or will
+	// answer a repeat from its idempotency records with everything the first
+	// answer held
+	safe := func() bool {
+		return !mutating || (req.RequestID != "" && !generatesKey(req.Op) && c.replays(ctx))
+	}
+
+	backoff := c.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		resp, sent, err := c.once(ctx, req)
+		if err == nil || attempt >= c.Retries || ctx.Err() != nil || !retryable(err, sent, safe) {
+			return resp, err
+		}
+		select {
+		case <-time.After(backoff << attempt):
+		case <-ctx.Done():
+			return resp, err
+		}
+	}
+}
+
+func retryable(err error, sent bool, safe func() bool) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Retryable && safe()
+	}
+	return !sent || safe()
+}
+
+// generatesKey reports whether op returns a private key the daemon does not
+// keep for replays.
+func generatesKey(op api.Op) bool {
+	return op == api.OpGenKeyAndSign || op == api.OpBatch
+}
+
+// replays reports whether the daemon answers request_id retries from stored
+// responses, asking it with HELLO once. A daemon that cannot be asked or does
+// not say so is assumed not to.
+func (c *Client) replays(ctx context.Context) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.idempotent == nil {
+		resp, _, err := c.once(ctx, Request{Op: api.OpHello})
+		if err != nil {
+			return false
+		}
+		ok := resp.Hello != nil && resp.Hello.Idempotency
+		c.idempotent = &ok
+	}
+	return *c.idempotent
+}
+
+// once runs one attempt. sent reports whether the request may have reached
+// the daemon.
+func (c *Client) once(ctx context.Context, req Request) (resp Response, sent bool, err error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return resp, false, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+	dl, _ := ctx.Deadline()
+	_ = conn.SetDeadline(dl)
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return resp, false, err
+	}
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		return resp, true, fmt.Errorf("write: %w", err)
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return resp, true, fmt.Errorf("read: %w", err)
+	}
+	if resp.Err != nil || resp.Error != "" {
+		return resp, true, errorOf(resp)
+	}
+	return resp, true, nil
+}
+
+func (c *Client) connect(ctx context.Context) (net.Conn, error) {
+	if c.dial != nil {
+		return c.dial(ctx)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", c.Socket)
+}
+
+func newRequestID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "c-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,179 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/pkg/client"
+	"github.com/HarounAhmad/vpn-certd/pkg/client/clienttest"
+)
+
+// lossy serves the clienttest fake but loses the response to the first
+// drop[op] requests of each op after handling them, as a daemon that committed
+// the request and then went away would.
+type lossy struct {
+	fake *clienttest.Server
+	// hello is the Idempotency HELLO reports.
+	hello bool
+
+	mu   sync.Mutex
+	drop map[api.Op]int
+	seen []client.Request
+}
+
+func newLossy(hello bool, drop map[api.Op]int) *lossy {
+	return &lossy{fake: clienttest.NewServer(), hello: hello, drop: drop}
+}
+
+func (l *lossy) client() *client.Client {
+	c := client.NewWithDialer(func(ctx context.Context) (net.Conn, error) {
+		cli, srv := net.Pipe()
+		go l.serve(srv)
+		return cli, nil
+	})
+	c.Retries, c.Backoff = 3, time.Millisecond
+	return c
+}
+
+func (l *lossy) serve(c net.Conn) {
+	defer c.Close()
+	var req client.Request
+	if err := json.NewDecoder(c).Decode(&req); err != nil {
+		return
+	}
+	var resp client.Response
+	if req.Op == api.OpHello {
+		resp.Hello = &api.Hello{Server: "lossy", Idempotency: l.hello}
+	} else {
+		var err error
+		if resp, err = l.fake.Handle(req); err != nil {
+			resp.Fail(err)
+		}
+	}
+	l.mu.Lock()
+	l.seen = append(l.seen, req)
+	lose := l.drop[req.Op] > 0
+	if lose {
+		l.drop[req.Op]--
+	}
+	l.mu.Unlock()
+	if !lose {
+		_ = json.NewEncoder(c).Encode(&resp)
+	}
+}
+
+// sent returns the requests received for op.
+func (l *lossy) sent(op api.Op) []client.Request {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []client.Request
+	for _, r := range l.seen {
+		if r.Op == op {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func TestKeyGeneratingOpsNotResent(t *testing.T) {
+	ctx := context.Background()
+	for _, op := range []api.Op{api.OpGenKeyAndSign, api.OpBatch} {
+		t.Run(string(op), func(t *testing.T) {
+			l := newLossy(true, map[api.Op]int{op: 1})
+			req := client.Request{Op: op, CN: "alice", Profile: client.ProfileClient, KeyType: client.KeyEd25519,
+				Passphrase: "correct horse battery", Items: []client.BatchItem{{CN: "alice"}}}
+			if _, err := l.client().Do(ctx, req); err == nil {
+				t.Fatal("lost response: want error")
+			}
+			got := l.sent(op)
+			if len(got) != 1 {
+				t.Fatalf("%s sent %d times, want 1", op, len(got))
+			}
+			if got[0].RequestID != "" {
+				t.Fatalf("request_id %q generated for %s", got[0].RequestID, op)
+			}
+		})
+	}
+}
+
+func TestKeyGeneratingOpRetriedWhenNeverSent(t *testing.T) {
+	l := newLossy(true, nil)
+	dials := 0
+	c := client.NewWithDialer(func(ctx context.Context) (net.Conn, error) {
+		if dials++; dials == 1 {
+			return nil, errors.New("connection refused")
+		}
+		cli, srv := net.Pipe()
+		go l.serve(srv)
+		return cli, nil
+	})
+	c.Backoff = time.Millisecond
+	if _, err := c.Issue(context.Background(), "alice", client.ProfileClient, client.KeyEd25519, "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(l.sent(api.OpGenKeyAndSign)); n != 1 {
+		t.Fatalf("GENKEY_AND_SIGN received %d times, want 1", n)
+	}
+}
+
+func TestResendOnlyWhenHelloReportsIdempotency(t *testing.T) {
+	for _, tc := range []struct {
+		hello bool
+		sends int
+	}{{false, 1}, {true, 2}} {
+		l := newLossy(tc.hello, map[api.Op]int{})
+		c := l.client()
+		issued, err := c.Issue(context.Background(), "bob", client.ProfileClient, client.KeyEd25519, "correct horse battery")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.mu.Lock()
+		l.drop[api.OpRevoke] = 1
+		l.mu.Unlock()
+
+		_, err = c.Revoke(context.Background(), issued.Serial, "keyCompromise")
+		got := l.sent(api.OpRevoke)
+		if len(got) != tc.sends {
+			t.Fatalf("idempotency %v: REVOKE sent %d times, want %d", tc.hello, len(got), tc.sends)
+		}
+		if tc.hello {
+			if err != nil {
+				t.Fatalf("idempotency %v: %v", tc.hello, err)
+			}
+			if got[0].RequestID == "" || got[1].RequestID != got[0].RequestID {
+				t.Fatalf("retry request_ids %q, %q: want the same generated ID", got[0].RequestID, got[1].RequestID)
+			}
+		} else if err == nil {
+			t.Fatalf("idempotency %v: lost response: want error", tc.hello)
+		}
+	}
+}
+
+func TestBackoffStopsOnCancel(t *testing.T) {
+	s := clienttest.NewServer()
+	s.Fail = func(client.Request) *client.Error {
+		return &client.Error{Code: "internal_error", Message: "busy", Retryable: true}
+	}
+	c := s.Client()
+	c.Retries, c.Backoff = 5, time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.Do(ctx, client.Request{Op: api.OpHealth})
+	if err == nil {
+		t.Fatal("want the daemon error")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("Do returned after %v, want it to stop waiting on cancel", d)
+	}
+	if n := len(s.Requests()); n != 1 {
+		t.Fatalf("%d attempts, want 1", n)
+	}
+}
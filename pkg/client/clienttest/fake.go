@@ -0,0 +1,461 @@
+// Package clienttest provides an in-memory stand-in for vpn-certd so code
+// built on pkg/client can be unit tested without a daemon, a socket or a CA on
+// disk.
+package clienttest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HarounAhmad/vpn-certd/internal/api"
+	"github.com/HarounAhmad/vpn-certd/internal/bundle"
+	"github.com/HarounAhmad/vpn-certd/internal/constants"
+	"github.com/HarounAhmad/vpn-certd/internal/pki"
+	"github.com/HarounAhmad/vpn-certd/internal/validate"
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+	"github.com/HarounAhmad/vpn-certd/pkg/client"
+)
+
+// CAID is the ca_id the fake reports.
+const CAID = "fake"
+
+// Server answers HELLO, HEALTH, SIGN, GENKEY_AND_SIGN, REVOKE, GET_CERT, GET_CRL,
+// LIST_ISSUED, BUILD_BUNDLE and BATCH from memory with the daemon's validation and
+// error codes. It signs real certificates with a throwaway Ed25519 CA; every
+// generated key is Ed25519 whatever key_type asks for, to keep tests fast.
+type Server struct {
+	// Fail, when set, is called before each request; a non-nil result is
+	// returned instead of handling it. Use it to inject daemon errors.
+	Fail func(req client.Request) *client.Error
+
+	mu       sync.Mutex
+	caKey    ed25519.PrivateKey
+	ca       *x509.Certificate
+	serial   int64
+	issued   []api.IssuedMeta
+	certs    map[string]string // serial -> cert PEM
+	revoked  map[string]pki.RevokedEntry
+	requests []client.Request
+	replies  map[string]api.Response // request_id -> response
+}
+
+// NewServer returns an empty fake with a fresh CA.
+func NewServer() *Server {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "clienttest CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, pub, key)
+	if err != nil {
+		panic(err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	return &Server{
+		caKey:   key,
+		ca:      ca,
+		serial:  1000,
+		certs:   map[string]string{},
+		revoked: map[string]pki.RevokedEntry{},
+		replies: map[string]api.Response{},
+	}
+}
+
+// Client returns a client connected to s over in-memory pipes.
+func (s *Server) Client() *client.Client {
+	c := client.NewWithDialer(func(ctx context.Context) (net.Conn, error) {
+		cli, srv := net.Pipe()
+		go s.serve(srv)
+		return cli, nil
+	})
+	c.Backoff = time.Millisecond
+	return c
+}
+
+// CAPEM is the PEM certificate of the fake's CA.
+func (s *Server) CAPEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw}))
+}
+
+// Requests returns every request received so far.
+func (s *Server) Requests() []client.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]client.Request(nil), s.requests...)
+}
+
+func (s *Server) serve(c net.Conn) {
+	defer c.Close()
+	var req api.Request
+	dec := json.NewDecoder(c)
+	dec.DisallowUnknownFields()
+	var resp api.Response
+	if err := dec.Decode(&req); err != nil {
+		resp.Fail(xerr.Bad(err.Error()))
+	} else {
+		var err error
+		if resp, err = s.Handle(req); err != nil {
+			resp.Fail(err)
+		}
+	}
+	_ = json.NewEncoder(c).Encode(&resp)
+}
+
+// Handle processes req as the daemon would.
+func (s *Server) Handle(req client.Request) (client.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if s.Fail != nil {
+		if e := s.Fail(req); e != nil {
+			return api.Response{RequestID: req.RequestID},
+				xerr.E{Code: e.Code, Msg: e.Message, Field: e.Field, Retry: e.Retryable}
+		}
+	}
+	return s.replayable(req)
+}
+
+// replayable answers a repeated request_id from memory.
+func (s *Server) replayable(req api.Request) (api.Response, error) {
+	if req.RequestID != "" {
+		if resp, ok := s.replies[req.RequestID]; ok {
+			resp.Replayed = true
+			return resp, nil
+		}
+	}
+	resp, err := s.handle(req)
+	resp.RequestID = req.RequestID
+	if err == nil && req.RequestID != "" && !req.DryRun && (resp.Serial != "" || resp.CRLPEM != "") {
+		stored := resp
+		stored.KeyPEMEnc = ""
+		s.replies[req.RequestID] = stored
+	}
+	return resp, err
+}
+
+func (s *Server) handle(req api.Request) (api.Response, error) {
+	switch req.Op {
+	case api.OpHello:
+		return api.Response{Hello: &api.Hello{Server: "clienttest", Protocol: api.ProtocolLegacy,
+			Protocols: []int{api.ProtocolLegacy}, Ops: api.Ops, Idempotency: true}}, nil
+
+	case api.OpHealth:
+		return api.Response{Serial: "ok", NotAfter: time.Now().UTC().Format(time.RFC3339)}, nil
+
+	case api.OpGenKeyAndSign, api.OpSign:
+		if validate.CN(req.CN) != nil {
+			return api.Response{}, xerr.Invalid("cn")
+		}
+		if validate.Profile(req.Profile) != nil {
+			return api.Response{}, xerr.Invalid("profile")
+		}
+		var pub any
+		var keyPEM string
+		if req.Op == api.OpSign {
+			if validate.CSR(req.CSRPEM) != nil {
+				return api.Response{}, xerr.Invalid("csr_pem")
+			}
+			block, _ := pem.Decode([]byte(req.CSRPEM))
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			if err != nil {
+				return api.Response{}, xerr.Invalid("csr_pem")
+			}
+			pub = csr.PublicKey
+		} else {
+			if validate.KeyType(req.KeyType) != nil {
+				return api.Response{}, xerr.Invalid("key_type")
+			}
+			if validate.Passphrase(req.Passphrase) != nil {
+				return api.Response{}, xerr.Invalid("passphrase")
+			}
+			if !req.DryRun {
+				k, enc, err := newKey(req.Passphrase)
+				if err != nil {
+					return api.Response{}, xerr.Wrap("issue_failed", err)
+				}
+				pub, keyPEM = k, enc
+			}
+		}
+		if s.activeCN(req.CN) {
+			return api.Response{}, xerr.E{Code: xerr.Conflict, Msg: "cn_exists_active", Field: "cn"}
+		}
+		if req.DryRun {
+			return api.Response{DryRun: true, CAID: CAID, Preview: &api.CertPreview{
+				Subject: "CN=" + req.CN, Issuer: s.ca.Subject.String(), CAID: CAID, Profile: req.Profile,
+			}}, nil
+		}
+		return s.issue(req.CN, req.Profile, pub, keyPEM)
+
+	case api.OpRevoke:
+		if validate.SerialDec(req.Serial) != nil {
+			return api.Response{}, xerr.Invalid("serial")
+		}
+		if validate.Reason(req.Reason) != nil {
+			return api.Response{}, xerr.Invalid("reason")
+		}
+		if req.DryRun {
+			_, known := s.certs[req.Serial]
+			p := &api.RevokePreview{Serial: req.Serial, CAID: CAID, Known: known, Reason: req.Reason, Effect: api.EffectRevoke}
+			if e, ok := s.revoked[req.Serial]; ok {
+				p.Current, p.Effect = e.Reason, api.EffectNoop
+			}
+			return api.Response{DryRun: true, CAID: CAID, Revoke: p}, nil
+		}
+		if _, ok := s.revoked[req.Serial]; !ok {
+			s.revoked[req.Serial] = pki.RevokedEntry{Serial: req.Serial, Reason: req.Reason, RevokedAtUnix: time.Now().Unix()}
+		}
+		crl, err := s.crl()
+		if err != nil {
+			return api.Response{}, xerr.Wrap("revoke_failed", err)
+		}
+		return api.Response{CRLPEM: crl, Serial: req.Serial, CAID: CAID}, nil
+
+	case api.OpGetCert:
+		if validate.SerialDec(req.Serial) != nil {
+			return api.Response{}, xerr.Invalid("serial")
+		}
+		for _, m := range s.issued {
+			if m.Serial == req.Serial {
+				info := &api.CertInfo{IssuedMeta: m}
+				if e, ok := s.revoked[m.Serial]; ok {
+					info.Revoked, info.RevocationReason = true, e.Reason
+					info.RevokedAt = time.Unix(e.RevokedAtUnix, 0).UTC().Format(time.RFC3339)
+				}
+				return api.Response{Cert: info, CertPEM: s.certs[m.Serial], Serial: m.Serial, CAID: CAID}, nil
+			}
+		}
+		return api.Response{}, xerr.NotFoundErr("serial")
+
+	case api.OpGetCRL:
+		crl, err := s.crl()
+		if err != nil {
+			return api.Response{}, xerr.Wrap("crl_failed", err)
+		}
+		return api.Response{CRLPEM: crl, ChainPEM: s.CAPEM()}, nil
+
+	case api.OpListIssued:
+		return api.Response{Issued: append([]api.IssuedMeta(nil), s.issued...)}, nil
+
+	case api.OpBuildBundle:
+		if req.Bundle == nil {
+			return api.Response{}, xerr.Invalid("bundle")
+		}
+		if validate.CN(req.Bundle.CN) != nil {
+			return api.Response{}, xerr.Invalid("cn")
+		}
+		if err := checkBundle(req.Bundle); err != nil {
+			return api.Response{}, err
+		}
+		cert := ""
+		for _, m := range s.issued {
+			if m.CN == req.Bundle.CN {
+				cert = s.certs[m.Serial]
+			}
+		}
+		if cert == "" {
+			return api.Response{}, xerr.E{Code: xerr.NotFound, Msg: "cert_missing", Field: "cn"}
+		}
+		out, err := bundle.Build(bundle.Inputs{
+			CN:         req.Bundle.CN,
+			CAPEM:      s.CAPEM(),
+			CertPEM:    cert,
+			RemoteHost: req.Bundle.RemoteHost,
+			RemotePort: req.Bundle.RemotePort,
+			Proto:      req.Bundle.Proto,
+		})
+		if err != nil {
+			return api.Response{}, xerr.Wrap("bundle_failed", err)
+		}
+		return api.Response{ZipB64: base64.StdEncoding.EncodeToString(out.ZipBytes)}, nil
+
+	case api.OpBatch:
+		if len(req.Items) == 0 || len(req.Items) > constants.MaxBatchItems {
+			return api.Response{}, xerr.Invalid("items")
+		}
+		out := make([]api.BatchResult, len(req.Items))
+		for i, it := range req.Items {
+			out[i] = s.batchItem(req, it)
+		}
+		return api.Response{Batch: out, DryRun: req.DryRun}, nil
+
+	default:
+		return api.Response{}, xerr.NotImplemented(string(req.Op))
+	}
+}
+
+func (s *Server) batchItem(req api.Request, it api.BatchItem) api.BatchResult {
+	res := api.BatchResult{CN: it.CN, RequestID: it.RequestID}
+	if res.RequestID == "" && req.RequestID != "" && !req.DryRun {
+		res.RequestID = req.RequestID + ":" + it.CN
+	}
+	fail := func(err error) api.BatchResult {
+		res.Err = api.ErrorOf(err)
+		return res
+	}
+	if validate.CN(it.CN) != nil {
+		return fail(xerr.Invalid("cn"))
+	}
+	if res.RequestID != "" && validate.RequestID(res.RequestID) != nil {
+		return fail(xerr.Invalid("request_id"))
+	}
+	if it.Bundle != nil {
+		if err := checkBundle(it.Bundle); err != nil {
+			return fail(err)
+		}
+	}
+	sub := api.Request{Op: api.OpGenKeyAndSign, CN: it.CN, Profile: it.Profile, KeyType: it.KeyType,
+		Passphrase: it.Passphrase, DryRun: req.DryRun, RequestID: res.RequestID}
+	if sub.Profile == "" {
+		sub.Profile = req.Profile
+	}
+	if sub.KeyType == "" {
+		sub.KeyType = req.KeyType
+	}
+	if sub.Passphrase == "" {
+		sub.Passphrase = req.Passphrase
+	}
+	resp, err := s.replayable(sub)
+	res.Serial, res.CAID, res.NotAfter = resp.Serial, resp.CAID, resp.NotAfter
+	res.CertPEM, res.KeyPEMEnc = resp.CertPEM, resp.KeyPEMEnc
+	res.Preview, res.Replayed = resp.Preview, resp.Replayed
+	if err != nil {
+		return fail(err)
+	}
+	if resp.Serial == "" || it.Bundle == nil {
+		return res
+	}
+	b := *it.Bundle
+	b.CN = it.CN
+	bresp, err := s.handle(api.Request{Op: api.OpBuildBundle, Bundle: &b})
+	if err != nil {
+		return fail(err)
+	}
+	res.ZipB64 = bresp.ZipB64
+	return res
+}
+
+func (s *Server) activeCN(cn string) bool {
+	for _, m := range s.issued {
+		if _, revoked := s.revoked[m.Serial]; m.CN == cn && !revoked {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) issue(cn string, profile api.Profile, pub any, keyPEM string) (api.Response, error) {
+	s.serial++
+	now := time.Now()
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(s.serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if profile == api.ProfileServer {
+		tpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, s.ca, pub, s.caKey)
+	if err != nil {
+		return api.Response{}, xerr.Wrap("issue_failed", err)
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	serial := strconv.FormatInt(s.serial, 10)
+	sum := sha256.Sum256(der)
+	notAfter := tpl.NotAfter.UTC().Format(time.RFC3339)
+	s.certs[serial] = certPEM
+	s.issued = append(s.issued, api.IssuedMeta{
+		Serial: serial, CN: cn, Profile: string(profile), NotAfter: notAfter,
+		SHA256: hex.EncodeToString(sum[:]), CAID: CAID,
+	})
+	return api.Response{
+		CertPEM:   certPEM,
+		ChainPEM:  s.CAPEM(),
+		KeyPEMEnc: keyPEM,
+		Serial:    serial,
+		CAID:      CAID,
+		NotAfter:  notAfter,
+	}, nil
+}
+
+func (s *Server) crl() (string, error) {
+	tpl := &x509.RevocationList{
+		Number:     big.NewInt(int64(len(s.revoked)) + 1),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(24 * time.Hour),
+	}
+	for serial, e := range s.revoked {
+		n, _ := new(big.Int).SetString(serial, 10)
+		tpl.RevokedCertificateEntries = append(tpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   n,
+			RevocationTime: time.Unix(e.RevokedAtUnix, 0),
+			ReasonCode:     pki.ReasonCode(e.Reason),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tpl, s.ca, s.caKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})), nil
+}
+
+// newKey returns an Ed25519 public key and its private half as an encrypted
+// PKCS#8 PEM, as GENKEY_AND_SIGN returns it.
+func newKey(passphrase string) (ed25519.PublicKey, string, error) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	block, err := pki.EncryptPKCS8(der, []byte(passphrase))
+	if err != nil {
+		return nil, "", err
+	}
+	var b bytes.Buffer
+	if err := pem.Encode(&b, block); err != nil {
+		return nil, "", err
+	}
+	return pub, b.String(), nil
+}
+
+// checkBundle mirrors the daemon's bundle checks; the fake only has the
+// built-in template.
+func checkBundle(b *api.BundleReq) error {
+	if b.RemoteHost == "" || b.RemotePort <= 0 {
+		return xerr.E{Code: xerr.BadRequest, Msg: "remote", Field: "remote_host"}
+	}
+	switch {
+	case b.Template == "" || b.Template == bundle.DefaultTemplate:
+		return nil
+	case validate.Template(b.Template) != nil:
+		return xerr.Invalid("template")
+	}
+	return xerr.E{Code: xerr.NotFound, Msg: "template", Field: "template"}
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/HarounAhmad/vpn-certd/internal/xerr"
+)
+
+// Code is the daemon error code.
+type Code = xerr.Code
+
+const (
+	BadRequest = xerr.BadRequest
+	Forbidden  = xerr.Forbidden
+	NotFound   = xerr.NotFound
+	Conflict   = xerr.Conflict
+	Internal   = xerr.Internal
+	NotImpl    = xerr.NotImpl
+)
+
+// Error is a failure reported by the daemon.
+type Error struct {
+	Code Code
+	// Field is the request field at fault, if any.
+	Field   string
+	Message string
+	// Retryable says the same request may succeed later.
+	Retryable bool
+}
+
+func (e *Error) Error() string { return string(e.Code) + ": " + e.Message }
+
+// CodeOf returns the daemon code of err, or "" when err did not come from the
+// daemon (e.g. the socket could not be reached).
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// BatchErr returns the failure of one Batch result as an *Error, or nil.
+func BatchErr(r BatchResult) error {
+	if e := r.Err; e != nil {
+		return &Error{Code: Code(e.Code), Field: e.Field, Message: e.Message, Retryable: e.Retryable}
+	}
+	return nil
+}
+
+func errorOf(resp Response) *Error {
+	if e := resp.Err; e != nil {
+		return &Error{Code: Code(e.Code), Field: e.Field, Message: e.Message, Retryable: e.Retryable}
+	}
+	// daemons predating the error object only send "code: message"
+	code, msg, ok := strings.Cut(resp.Error, ": ")
+	if !ok {
+		code, msg = string(Internal), resp.Error
+	}
+	return &Error{Code: Code(code), Message: msg}
+}